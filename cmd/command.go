@@ -0,0 +1,740 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+	"github.com/fyvri/fresh-proxy-list/internal/handler"
+	"github.com/fyvri/fresh-proxy-list/internal/infrastructure/config"
+	"github.com/fyvri/fresh-proxy-list/internal/infrastructure/repository"
+	"github.com/fyvri/fresh-proxy-list/internal/usecase"
+	"github.com/fyvri/fresh-proxy-list/pkg/utils"
+)
+
+type Options struct {
+	Config             string
+	Concurrency        int
+	Timeout            time.Duration
+	HTTPTestingSites   []string
+	HTTPSTestingSites  []string
+	JudgeURL           string
+	Sources            string
+	Input              string
+	Output             string
+	History            string
+	HistoryRetention   time.Duration
+	Incremental        bool
+	RefreshInterval    time.Duration
+	SourceState        string
+	CredentialsFile    string
+	Categories         []string
+	Formats            []string
+	Compressions       []string
+	Readme             string
+	ReadmeTemplate     string
+	GeoIP              string
+	IncludeCredentials bool
+	IncludeMITM        bool
+	VerifyTLS          bool
+	ConnectTargets     []string
+	PACInclude         []string
+	PACExclude         []string
+	PACMax             *int
+	Addr               string
+	SOCKSAddr          string
+	Auth               string
+	Strategy           string
+	RecheckInterval    time.Duration
+	Interval           time.Duration
+	Schedule           string
+	Snapshot           bool
+	KeepSnapshots      int
+	Deadline           time.Duration
+}
+
+const snapshotVersionLayout = "20060102T150405.000Z"
+
+// Number of the fastest proxies of each category listed in the README
+const readmeSampleSize = 10
+
+type Command struct {
+	Name        string
+	Description string
+	Run         func(ctx context.Context, runners Runners, options Options) error
+}
+
+var commands = []Command{
+	{Name: "run", Description: "Collect proxies from every source, check them and export the results (default)", Run: run},
+	{Name: "collect", Description: "Collect proxies from every source and export them without checking", Run: collect},
+	{Name: "check", Description: "Check proxies from a previous advanced output and export the working ones", Run: check},
+	{Name: "daemon", Description: "Collect, check and export proxies on a schedule, rechecking the live pool in between", Run: daemon},
+	{Name: "export", Description: "Export proxies from a previous advanced output into every format", Run: export},
+	{Name: "serve", Description: "Serve the output directory over HTTP", Run: serve},
+	{Name: "serve-api", Description: "Serve the proxies of a previous advanced output over a REST API", Run: serveAPI},
+	{Name: "serve-gateway", Description: "Serve a rotating HTTP/SOCKS5 proxy forwarding through the proxies of a previous advanced output", Run: serveGateway},
+	{Name: "judge", Description: "Serve the proxy judge that echoes the client IP and request headers", Run: judge},
+	{Name: "validate-sources", Description: "Validate the configured proxy sources", Run: validateSources},
+}
+
+func parseCommand(args []string) (*Command, Options, error) {
+	name := "run"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	i := slices.IndexFunc(commands, func(c Command) bool {
+		return c.Name == name
+	})
+	if i < 0 {
+		printUsage()
+		return nil, Options{}, fmt.Errorf("command not found: %s", name)
+	}
+	command := &commands[i]
+
+	var (
+		options           = Options{}
+		categories        string
+		formats           string
+		compressions      string
+		httpTestingSites  string
+		httpsTestingSites string
+		connectTargets    string
+		pacInclude        string
+		pacExclude        string
+		pacMax            int
+		flagSet           = flag.NewFlagSet(command.Name, flag.ContinueOnError)
+	)
+	flagSet.StringVar(&options.Config, "config", os.Getenv("CONFIG_FILE"), "YAML config file")
+	flagSet.StringVar(&options.Output, "output", "storage", "output directory")
+	flagSet.StringVar(&categories, "categories", strings.Join(config.ProxyCategories, ","), "comma-separated proxy categories")
+	flagSet.StringVar(&formats, "formats", strings.Join(config.FileOutputExtensions, ","), "comma-separated output formats")
+	if command.Name != "serve" && command.Name != "serve-gateway" && command.Name != "judge" && command.Name != "validate-sources" {
+		flagSet.BoolVar(&options.IncludeCredentials, "include-credentials", false, "keep proxy credentials in the outputs instead of redacting them")
+	}
+	if command.Name != "serve" && command.Name != "judge" && command.Name != "validate-sources" {
+		flagSet.BoolVar(&options.IncludeMITM, "include-mitm", false, "keep proxies that intercept TLS as HTTPS proxies in the outputs and the gateway")
+	}
+	switch command.Name {
+	case "run", "collect", "daemon", "validate-sources":
+		flagSet.StringVar(&options.Sources, "sources", os.Getenv("PROXY_SOURCES"), "sources file or directory merged with PROXY_RESOURCES")
+	}
+	switch command.Name {
+	case "run", "collect":
+		flagSet.BoolVar(&options.Incremental, "incremental", false, "recheck the proxies from -input first and only fetch sources not fetched within -refresh-interval")
+		flagSet.DurationVar(&options.RefreshInterval, "refresh-interval", 6*time.Hour, "minimum time between two fetches of a source in incremental mode")
+		flagSet.StringVar(&options.SourceState, "source-state", filepath.Join("storage", "sources.state.json"), "file that records when each source was last fetched in incremental mode")
+		fallthrough
+	case "check", "daemon", "export", "serve-api", "serve-gateway":
+		flagSet.StringVar(&options.Input, "input", filepath.Join("storage", "advanced", "all.json"), "advanced output file to read proxies from")
+		flagSet.StringVar(&options.CredentialsFile, "credentials-file", cmp.Or(os.Getenv("CREDENTIALS_FILE"), filepath.Join(".state", "credentials.json")), "private file that the credentials redacted from the outputs are kept in and restored from when -input is read")
+	}
+	switch command.Name {
+	case "serve", "serve-api", "judge":
+		flagSet.StringVar(&options.Addr, "addr", ":8080", "address to listen on")
+	}
+	if command.Name == "serve-gateway" {
+		flagSet.StringVar(&options.Addr, "addr", "127.0.0.1:8080", "address to listen on (a non-loopback address requires -auth)")
+		flagSet.StringVar(&options.SOCKSAddr, "socks-addr", "127.0.0.1:1080", "address to listen on as a SOCKS5 proxy (disabled when empty, a non-loopback address requires -auth)")
+		flagSet.StringVar(&options.Auth, "auth", os.Getenv("GATEWAY_AUTH"), "user:password that clients must authenticate with")
+		flagSet.StringVar(&options.Strategy, "strategy", config.GatewayStrategies[0], "upstream selection strategy: "+strings.Join(config.GatewayStrategies, ", "))
+		flagSet.DurationVar(&options.RecheckInterval, "recheck-interval", 5*time.Minute, "interval between two rechecks of the evicted upstreams (0 disables them)")
+	}
+	if command.Name == "daemon" {
+		flagSet.DurationVar(&options.Interval, "interval", time.Hour, "interval between two full runs")
+		flagSet.StringVar(&options.Schedule, "schedule", "", "cron expression of the full runs, e.g. \"0 * * * *\" (overrides -interval)")
+		flagSet.DurationVar(&options.RecheckInterval, "recheck-interval", 15*time.Minute, "interval between two rechecks of the live pool between full runs (0 disables them)")
+	}
+	switch command.Name {
+	case "run", "collect", "check", "daemon", "export":
+		flagSet.StringVar(&options.History, "history", os.Getenv("HISTORY_FILE"), "JSONL file that check results are appended to and uptime is computed from (disabled when empty)")
+		flagSet.DurationVar(&options.HistoryRetention, "history-retention", 7*24*time.Hour, "age after which check results are dropped from -history (0 keeps all of them)")
+		flagSet.BoolVar(&options.Snapshot, "snapshot", false, "write the outputs into a new snapshot directory and point the current link at it once every file is saved")
+		flagSet.IntVar(&options.KeepSnapshots, "keep-snapshots", 3, "number of snapshots kept in snapshot mode (0 keeps all of them)")
+		flagSet.StringVar(&compressions, "compress", "", "comma-separated compressed variants written next to every output: "+strings.Join(config.FileCompressions, ", ")+" (disabled when empty)")
+		flagSet.StringVar(&options.Readme, "readme", "", "file the README template is rendered into after every run, e.g. README.md (disabled when empty)")
+		flagSet.StringVar(&options.ReadmeTemplate, "readme-template", filepath.Join("docs", "README.template.md"), "text/template file rendered into -readme")
+		flagSet.StringVar(&options.GeoIP, "geoip", os.Getenv("GEOIP_FILE"), "start,end,country CSV of IP ranges that the README countries are computed from (disabled when empty)")
+	}
+	switch command.Name {
+	case "run", "collect", "check", "daemon", "export", "serve-api":
+		flagSet.StringVar(&pacInclude, "pac-include", "", "comma-separated domains or shell patterns sent through the proxies by the PAC files, e.g. example.com,*.example.org (all when empty)")
+		flagSet.StringVar(&pacExclude, "pac-exclude", "", "comma-separated domains or shell patterns always connected to directly by the PAC files")
+		flagSet.IntVar(&pacMax, "pac-max", config.PACMax, "number of the fastest proxies that the PAC files fail over between (0 lists all of them)")
+	}
+	if command.Name == "run" || command.Name == "collect" || command.Name == "check" {
+		flagSet.DurationVar(&options.Deadline, "deadline", 0, "total run deadline after which partial results are saved, e.g. 50m (0 disables it)")
+	}
+	if command.Name == "run" || command.Name == "collect" || command.Name == "check" || command.Name == "daemon" {
+		flagSet.IntVar(&options.Concurrency, "concurrency", 0, fmt.Sprintf("number of concurrent checks (default %d)", config.CheckerConcurrency))
+		flagSet.DurationVar(&options.Timeout, "timeout", 0, fmt.Sprintf("timeout of a single check (default %v)", config.CheckerTimeout))
+		flagSet.StringVar(&httpTestingSites, "http-testing-sites", "", "comma-separated HTTP testing site URLs")
+		flagSet.StringVar(&httpsTestingSites, "https-testing-sites", "", "comma-separated HTTPS testing site URLs")
+		flagSet.StringVar(&options.JudgeURL, "judge-url", "", "judge URL that working proxies are sent to in order to classify their anonymity")
+		flagSet.BoolVar(&options.VerifyTLS, "verify-tls", false, "verify the testing site certificate through HTTPS proxies to detect TLS interception")
+		flagSet.StringVar(&connectTargets, "connect-targets", "", "comma-separated host:port targets that HTTP/HTTPS proxies are probed with CONNECT, e.g. example.com:443")
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return nil, Options{}, err
+	}
+	// Snapshot mode publishes under the current link, so that is where the previous output is read back from
+	if options.Snapshot && !isFlagSet(flagSet, "input") {
+		options.Input = filepath.Join(options.Output, "current", "advanced", "all.json")
+	}
+	options.HTTPTestingSites = splitList(httpTestingSites)
+	options.HTTPSTestingSites = splitList(httpsTestingSites)
+	options.ConnectTargets = splitList(connectTargets)
+	options.PACInclude = splitList(pacInclude)
+	options.PACExclude = splitList(pacExclude)
+	// Only an explicit flag overrides pac.max and PAC_MAX, since 0 is a valid value
+	if isFlagSet(flagSet, "pac-max") {
+		options.PACMax = &pacMax
+	}
+
+	for _, category := range strings.Split(categories, ",") {
+		category = strings.ToUpper(strings.TrimSpace(category))
+		if !slices.Contains(config.ProxyCategories, category) {
+			return nil, Options{}, fmt.Errorf("proxy category not found: %s", category)
+		}
+		options.Categories = append(options.Categories, category)
+	}
+
+	if options.Strategy != "" && !slices.Contains(config.GatewayStrategies, options.Strategy) {
+		return nil, Options{}, fmt.Errorf("unsupported strategy: %s", options.Strategy)
+	}
+
+	if command.Name == "serve-gateway" {
+		if options.Auth != "" {
+			if username, _, found := strings.Cut(options.Auth, ":"); !found || username == "" {
+				return nil, Options{}, errors.New("auth must be user:password")
+			}
+		} else {
+			// Without credentials the gateway is an open proxy, so it only listens on loopback addresses
+			for _, addr := range []string{options.Addr, options.SOCKSAddr} {
+				if addr != "" && !isLoopback(addr) {
+					return nil, Options{}, fmt.Errorf("refusing to serve the gateway on %s without -auth", addr)
+				}
+			}
+		}
+	}
+
+	if options.HistoryRetention < 0 {
+		return nil, Options{}, errors.New("history-retention must not be negative")
+	}
+
+	if options.RecheckInterval < 0 {
+		return nil, Options{}, errors.New("recheck-interval must not be negative")
+	}
+
+	if command.Name == "daemon" && options.Schedule == "" && options.Interval <= 0 {
+		return nil, Options{}, errors.New("interval must be positive")
+	}
+
+	for _, format := range strings.Split(formats, ",") {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if !slices.Contains(config.FileOutputExtensions, format) {
+			return nil, Options{}, fmt.Errorf("unsupported format: %s", format)
+		}
+		options.Formats = append(options.Formats, format)
+	}
+
+	for _, compression := range splitList(compressions) {
+		compression = strings.ToLower(compression)
+		if !slices.Contains(config.FileCompressions, compression) {
+			return nil, Options{}, fmt.Errorf("unsupported compression: %s", compression)
+		}
+		options.Compressions = append(options.Compressions, compression)
+	}
+
+	return command, options, nil
+}
+
+func isFlagSet(flagSet *flag.FlagSet, name string) bool {
+	found := false
+	flagSet.Visit(func(f *flag.Flag) {
+		found = found || f.Name == name
+	})
+	return found
+}
+
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", filepath.Base(os.Args[0]))
+	for _, command := range commands {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", command.Name, command.Description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' to see the flags of a command.\n", filepath.Base(os.Args[0]))
+}
+
+func run(ctx context.Context, runners Runners, options Options) error {
+	return processSources(ctx, runners, options, true)
+}
+
+func collect(ctx context.Context, runners Runners, options Options) error {
+	return processSources(ctx, runners, options, false)
+}
+
+func processSources(ctx context.Context, runners Runners, options Options, isChecked bool) error {
+	startTime := time.Now()
+	if isChecked {
+		if err := requireRealIP(ctx, runners); err != nil {
+			return err
+		}
+	}
+
+	sourceUsecase := usecase.NewSourceUsecase(runners.sourceRepository, runners.sourceStateRepository, runners.fetcherUtil)
+	selectedSources, err := selectSources(sourceUsecase, options)
+	if err != nil {
+		return err
+	}
+
+	var previousProxies []entity.AdvancedProxy
+	if options.Incremental {
+		fileUsecase := usecase.NewFileUsecase(runners.fileRepository, runners.proxyRepository, runners.credentialRepository, options.Formats, options.Compressions, options.Output, options.Categories, anonymityLevels(runners), options.IncludeCredentials, options.IncludeMITM)
+		proxies, err := fileUsecase.LoadFile(options.Input)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		previousProxies = filterCategories(proxies, options.Categories)
+
+		dueSources := sourceUsecase.SelectDueSources(selectedSources, options.RefreshInterval)
+		log.Printf("Incremental run: %v previous proxies, %v of %v sources due", len(previousProxies), len(dueSources), len(selectedSources))
+		selectedSources = dueSources
+	}
+
+	specialIPs := config.SpecialIPs
+	privateIPs := config.PrivateIPs
+	proxyUsecase := usecase.NewProxyUsecase(runners.proxyRepository, runners.historyRepository, runners.proxyService, specialIPs, privateIPs)
+	pipelineUsecase := usecase.NewPipelineUsecase(sourceUsecase, proxyUsecase, runners.config.Checker.Concurrency, config.SourceConcurrency)
+	pipelineUsecase.Process(ctx, previousProxies, selectedSources, isChecked)
+
+	// Candidates of an interrupted run were not all checked, so its sources stay due for the next run
+	if ctx.Err() != nil {
+		log.Printf("Source state not saved, the run was interrupted")
+	} else if err := sourceUsecase.SaveState(); err != nil {
+		log.Printf("Failed to save source state: %v", err)
+	}
+
+	return saveFiles(runners, options, proxyUsecase, pipelineUsecase.SourceYields(), startTime)
+}
+
+func selectSources(sourceUsecase usecase.SourceUsecaseInterface, options Options) ([]entity.Source, error) {
+	sources, err := sourceUsecase.LoadSources()
+	if err != nil {
+		return nil, err
+	}
+
+	var selectedSources []entity.Source
+	proxyCategories := config.ProxyCategories
+	for i, source := range sources {
+		if slices.Contains(proxyCategories, source.Category) {
+			if slices.Contains(options.Categories, source.Category) {
+				selectedSources = append(selectedSources, source)
+			}
+		} else if source.Origin != "" {
+			log.Printf("%s: proxy category not found: %s", source.Origin, source.Category)
+		} else {
+			log.Printf("Index %v: proxy category not found: %s", i, source.Category)
+		}
+	}
+	return selectedSources, nil
+}
+
+func check(ctx context.Context, runners Runners, options Options) error {
+	startTime := time.Now()
+	if err := requireRealIP(ctx, runners); err != nil {
+		return err
+	}
+
+	fileUsecase := usecase.NewFileUsecase(runners.fileRepository, runners.proxyRepository, runners.credentialRepository, options.Formats, options.Compressions, options.Output, options.Categories, anonymityLevels(runners), options.IncludeCredentials, options.IncludeMITM)
+	proxies, err := fileUsecase.LoadFile(options.Input)
+	if err != nil {
+		return err
+	}
+
+	sourceUsecase := usecase.NewSourceUsecase(runners.sourceRepository, nil, runners.fetcherUtil)
+	proxyUsecase := usecase.NewProxyUsecase(runners.proxyRepository, runners.historyRepository, runners.proxyService, config.SpecialIPs, config.PrivateIPs)
+	pipelineUsecase := usecase.NewPipelineUsecase(sourceUsecase, proxyUsecase, runners.config.Checker.Concurrency, config.SourceConcurrency)
+	pipelineUsecase.Process(ctx, filterCategories(proxies, options.Categories), nil, true)
+
+	return saveFiles(runners, options, proxyUsecase, nil, startTime)
+}
+
+func export(ctx context.Context, runners Runners, options Options) error {
+	startTime := time.Now()
+
+	proxyUsecase, err := restoreProxies(runners, options)
+	if err != nil {
+		return err
+	}
+
+	return saveFiles(runners, options, proxyUsecase, nil, startTime)
+}
+
+func restoreProxies(runners Runners, options Options) (usecase.ProxyUsecaseInterface, error) {
+	fileUsecase := usecase.NewFileUsecase(runners.fileRepository, runners.proxyRepository, runners.credentialRepository, options.Formats, options.Compressions, options.Output, options.Categories, anonymityLevels(runners), options.IncludeCredentials, options.IncludeMITM)
+	proxies, err := fileUsecase.LoadFile(options.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	proxyUsecase := usecase.NewProxyUsecase(runners.proxyRepository, runners.historyRepository, runners.proxyService, config.SpecialIPs, config.PrivateIPs)
+	for _, proxy := range filterCategories(proxies, options.Categories) {
+		for _, category := range proxy.Categories {
+			proxyUsecase.RestoreProxy(category, &proxy)
+		}
+	}
+	return proxyUsecase, nil
+}
+
+func daemon(ctx context.Context, runners Runners, options Options) error {
+	var schedule utils.CronUtilInterface
+	if options.Schedule != "" {
+		var err error
+		if schedule, err = utils.NewCron(options.Schedule); err != nil {
+			return err
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("cron expression never matches: %s", options.Schedule)
+		}
+	}
+
+	if err := requireRealIP(ctx, runners); err != nil {
+		return err
+	}
+
+	fileUsecase := usecase.NewFileUsecase(runners.fileRepository, runners.proxyRepository, runners.credentialRepository, options.Formats, options.Compressions, options.Output, options.Categories, anonymityLevels(runners), options.IncludeCredentials, options.IncludeMITM)
+	proxies, err := fileUsecase.LoadFile(options.Input)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	livePool := filterCategories(proxies, options.Categories)
+	log.Printf("Daemon started with %v live proxies", len(livePool))
+
+	// A single loop runs every cycle, so a slow cycle delays the next one instead of overlapping it
+	var (
+		nextRun     = time.Now()
+		nextRecheck = nextRun.Add(options.RecheckInterval)
+		sources     []entity.SourceYield
+	)
+	for {
+		isFull, next := nextCycle(nextRun, nextRecheck, options.RecheckInterval, len(livePool))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		startTime := time.Now()
+		if isFull {
+			log.Printf("Starting full run")
+			if schedule != nil {
+				nextRun = schedule.Next(startTime)
+			} else {
+				nextRun = startTime.Add(options.Interval)
+			}
+		} else {
+			log.Printf("Rechecking %v live proxies", len(livePool))
+		}
+
+		proxies, yields, err := runCycle(ctx, runners, options, livePool, isFull, sources)
+		if ctx.Err() != nil {
+			return nil
+		} else if err != nil {
+			log.Printf("Cycle failed, keeping the last good result: %v", err)
+		} else {
+			livePool, sources = proxies, yields
+		}
+
+		nextRecheck = time.Now().Add(options.RecheckInterval)
+		if isFull {
+			log.Printf("Next full run at %v", nextRun.Format(time.RFC3339))
+		}
+	}
+}
+
+// nextCycle picks the next daemon cycle, a recheck of the live pool only runs when it is due strictly before the next full run
+func nextCycle(nextRun time.Time, nextRecheck time.Time, recheckInterval time.Duration, livePool int) (bool, time.Time) {
+	if recheckInterval > 0 && livePool > 0 && nextRecheck.Before(nextRun) {
+		return false, nextRecheck
+	}
+	return true, nextRun
+}
+
+// runCycle checks the live pool, along with every source on full runs, and publishes the result. Recheck cycles keep
+// the source yields of the last full run for the README.
+func runCycle(ctx context.Context, runners Runners, options Options, livePool []entity.AdvancedProxy, isFull bool, yields []entity.SourceYield) ([]entity.AdvancedProxy, []entity.SourceYield, error) {
+	startTime := time.Now()
+	runners.proxyRepository = repository.NewProxyRepository(config.ProxyCategories)
+
+	sourceUsecase := usecase.NewSourceUsecase(runners.sourceRepository, runners.sourceStateRepository, runners.fetcherUtil)
+	var sources []entity.Source
+	if isFull {
+		var err error
+		if sources, err = selectSources(sourceUsecase, options); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	proxyUsecase := usecase.NewProxyUsecase(runners.proxyRepository, runners.historyRepository, runners.proxyService, config.SpecialIPs, config.PrivateIPs)
+	pipelineUsecase := usecase.NewPipelineUsecase(sourceUsecase, proxyUsecase, runners.config.Checker.Concurrency, config.SourceConcurrency)
+	pipelineUsecase.Process(ctx, livePool, sources, true)
+	if err := ctx.Err(); err != nil {
+		return nil, nil, fmt.Errorf("cycle interrupted: %v", err)
+	}
+
+	proxies := proxyUsecase.GetAllAdvancedView()
+	if len(proxies) == 0 {
+		return nil, nil, errors.New("no working proxies found")
+	}
+	if isFull {
+		yields = pipelineUsecase.SourceYields()
+	}
+
+	if runners.snapshotRepository != nil {
+		if err := saveFiles(runners, options, proxyUsecase, yields, startTime); err != nil {
+			return nil, nil, err
+		}
+		return proxies, yields, nil
+	}
+
+	// Files are written next to the output first and swapped in at once, so that readers never see a partial set
+	stagingOptions := options
+	stagingOptions.Output = runners.stagingRepository.Path()
+	if err := runners.stagingRepository.Discard(); err != nil {
+		return nil, nil, err
+	}
+	defer runners.stagingRepository.Discard()
+
+	if err := saveFiles(runners, stagingOptions, proxyUsecase, yields, startTime); err != nil {
+		return nil, nil, err
+	}
+	if err := runners.stagingRepository.Publish(); err != nil {
+		return nil, nil, err
+	}
+	return proxies, yields, nil
+}
+
+func serve(ctx context.Context, runners Runners, options Options) error {
+	log.Printf("Serving %s on %s", options.Output, options.Addr)
+	return listenAndServe(ctx, options.Addr, http.FileServer(http.Dir(options.Output)))
+}
+
+func serveAPI(ctx context.Context, runners Runners, options Options) error {
+	proxyUsecase, err := restoreProxies(runners, options)
+	if err != nil {
+		return err
+	}
+
+	queryUsecase := usecase.NewQueryUsecase(runners.proxyRepository, runners.fileRepository, options.Categories, options.IncludeCredentials, options.IncludeMITM)
+	log.Printf("Serving API for %v proxies on %s", len(proxyUsecase.GetAllAdvancedView()), options.Addr)
+	return listenAndServe(ctx, options.Addr, handler.NewAPIHandler(queryUsecase))
+}
+
+func serveGateway(ctx context.Context, runners Runners, options Options) error {
+	if _, err := restoreProxies(runners, options); err != nil {
+		return err
+	}
+
+	gatewayUsecase := usecase.NewGatewayUsecase(runners.proxyRepository, runners.proxyService, options.Strategy, options.IncludeMITM)
+	upstreams := gatewayUsecase.Load(config.GatewayCategories)
+	if upstreams == 0 {
+		return fmt.Errorf("no upstream proxies found in %s", options.Input)
+	}
+
+	if options.RecheckInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(options.RecheckInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+					if restored := gatewayUsecase.Recheck(ctx); restored > 0 {
+						log.Printf("Restored %v evicted upstreams", restored)
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	username, password, _ := strings.Cut(options.Auth, ":")
+	gatewayHandler := handler.NewGatewayHandler(gatewayUsecase, username, password)
+	if options.SOCKSAddr != "" {
+		listener, err := net.Listen("tcp", options.SOCKSAddr)
+		if err != nil {
+			return err
+		}
+		context.AfterFunc(ctx, func() {
+			listener.Close()
+		})
+		go func() {
+			if err := gatewayHandler.ServeSOCKS5(ctx, listener); err != nil {
+				log.Printf("SOCKS5 gateway stopped: %v", err)
+			}
+		}()
+		log.Printf("Serving SOCKS5 gateway on %s", options.SOCKSAddr)
+	}
+
+	log.Printf("Serving HTTP gateway on %s (%v upstreams, %s)", options.Addr, upstreams, options.Strategy)
+	return listenAndServe(ctx, options.Addr, gatewayHandler)
+}
+
+func judge(ctx context.Context, runners Runners, options Options) error {
+	log.Printf("Serving proxy judge on %s", options.Addr)
+	return listenAndServe(ctx, options.Addr, handler.NewJudgeHandler())
+}
+
+func listenAndServe(ctx context.Context, addr string, httpHandler http.Handler) error {
+	server := &http.Server{
+		Addr:    addr,
+		Handler: httpHandler,
+	}
+	context.AfterFunc(ctx, func() {
+		server.Shutdown(context.Background())
+	})
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func validateSources(ctx context.Context, runners Runners, options Options) error {
+	sourceUsecase := usecase.NewSourceUsecase(runners.sourceRepository, runners.sourceStateRepository, runners.fetcherUtil)
+	sources, err := sourceUsecase.LoadSources()
+	if err != nil {
+		return err
+	}
+
+	invalid := 0
+	for i, source := range sources {
+		if err := sourceUsecase.ValidateSource(&source, config.ProxyCategories); err != nil {
+			invalid++
+			if source.Origin != "" {
+				log.Printf("%s: %v", source.Origin, err)
+			} else {
+				log.Printf("Index %v: %v", i, err)
+			}
+		}
+	}
+
+	log.Printf("Number of sources: %v (%v invalid)", len(sources), invalid)
+	if invalid > 0 {
+		return errors.New("invalid proxy sources found")
+	}
+	return nil
+}
+
+func filterCategories(proxies []entity.AdvancedProxy, categories []string) []entity.AdvancedProxy {
+	var filtered []entity.AdvancedProxy
+	for _, proxy := range proxies {
+		proxyCategories := slices.DeleteFunc(slices.Clone(proxy.Categories), func(category string) bool {
+			return !slices.Contains(categories, category)
+		})
+		if len(proxyCategories) > 0 {
+			proxy.Categories = proxyCategories
+			filtered = append(filtered, proxy)
+		}
+	}
+	return filtered
+}
+
+// requireRealIP stops a judge run before any check, as transparent proxies cannot be told apart without the real IP
+func requireRealIP(ctx context.Context, runners Runners) error {
+	if runners.config.Checker.JudgeURL == "" {
+		return nil
+	}
+	if runners.proxyService.GetRealIP(ctx) == "" {
+		return fmt.Errorf("failed to get the real IP from the judge %s", runners.config.Checker.JudgeURL)
+	}
+	return nil
+}
+
+func anonymityLevels(runners Runners) []string {
+	if runners.config.Checker.JudgeURL == "" {
+		return nil
+	}
+	return config.AnonymityLevels
+}
+
+func saveFiles(runners Runners, options Options, proxyUsecase usecase.ProxyUsecaseInterface, sources []entity.SourceYield, startTime time.Time) error {
+	if err := proxyUsecase.SaveHistory(); err != nil {
+		log.Printf("Failed to save history: %v", err)
+	}
+
+	output, version := options.Output, ""
+	if runners.snapshotRepository != nil {
+		version = startTime.UTC().Format(snapshotVersionLayout)
+		output = runners.snapshotRepository.Path(version)
+	}
+
+	fileUsecase := usecase.NewFileUsecase(runners.fileRepository, runners.proxyRepository, runners.credentialRepository, options.Formats, options.Compressions, output, options.Categories, anonymityLevels(runners), options.IncludeCredentials, options.IncludeMITM)
+	if err := fileUsecase.SaveFiles(); err != nil {
+		if version != "" {
+			runners.snapshotRepository.Discard(version)
+		}
+		return fmt.Errorf("failed to save files: %w", err)
+	}
+
+	if version != "" {
+		if err := runners.snapshotRepository.Publish(version); err != nil {
+			return err
+		}
+		if err := runners.snapshotRepository.Prune(version); err != nil {
+			log.Printf("Failed to prune snapshots: %v", err)
+		}
+		log.Printf("Published snapshot    : %v", version)
+	}
+
+	if runners.templateRepository != nil {
+		reportUsecase := usecase.NewReportUsecase(runners.proxyRepository, runners.templateRepository, runners.geoIPRepository, options.Categories, anonymityLevels(runners), readmeSampleSize, options.IncludeMITM)
+		if err := reportUsecase.Render(options.Readme, reportUsecase.BuildReport(sources, startTime)); err != nil {
+			log.Printf("Failed to render README: %v", err)
+		} else {
+			log.Printf("Rendered README       : %v", options.Readme)
+		}
+	}
+
+	log.Printf("Number of proxies     : %v", len(proxyUsecase.GetAllAdvancedView()))
+	log.Printf("Time-consuming process: %v", time.Since(startTime))
+	return nil
+}
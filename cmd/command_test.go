@@ -0,0 +1,323 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+var (
+	unexpectedMessage          = "Unexpected %v: %v"
+	expectedButGotMessage      = "Expected %v = %v, but got = %v"
+	expectedErrorButGotMessage = "Expected %v error = %v, but got = %v"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		want      string
+		wantError error
+	}{
+		{
+			name:      "DefaultCommand",
+			args:      []string{"-categories", "http"},
+			want:      "run",
+			wantError: nil,
+		},
+		{
+			name:      "Command",
+			args:      []string{"export", "-formats", "json"},
+			want:      "export",
+			wantError: nil,
+		},
+		{
+			name:      "CommandNotFound",
+			args:      []string{"nope"},
+			wantError: errors.New("command not found: nope"),
+		},
+		{
+			name:      "FlagOfAnotherCommand",
+			args:      []string{"serve-gateway", "-include-credentials"},
+			wantError: errors.New("flag provided but not defined: -include-credentials"),
+		},
+		{
+			name:      "DaemonFlagOnRun",
+			args:      []string{"run", "-interval", "1h"},
+			wantError: errors.New("flag provided but not defined: -interval"),
+		},
+		{
+			name:      "InvalidCategory",
+			args:      []string{"-categories", "http,ftp"},
+			wantError: errors.New("proxy category not found: FTP"),
+		},
+		{
+			name:      "InvalidFormat",
+			args:      []string{"-formats", "json,doc"},
+			wantError: errors.New("unsupported format: doc"),
+		},
+		{
+			name:      "InvalidCompression",
+			args:      []string{"-compress", "gz,br"},
+			wantError: errors.New("unsupported compression: br"),
+		},
+		{
+			name:      "InvalidStrategy",
+			args:      []string{"serve-gateway", "-strategy", "fastest"},
+			wantError: errors.New("unsupported strategy: fastest"),
+		},
+		{
+			name:      "InvalidAuth",
+			args:      []string{"serve-gateway", "-auth", ":password"},
+			wantError: errors.New("auth must be user:password"),
+		},
+		{
+			name:      "GatewayWithoutAuthOnPublicAddress",
+			args:      []string{"serve-gateway", "-addr", "0.0.0.0:8080"},
+			wantError: errors.New("refusing to serve the gateway on 0.0.0.0:8080 without -auth"),
+		},
+		{
+			name:      "GatewayWithAuthOnPublicAddress",
+			args:      []string{"serve-gateway", "-addr", "0.0.0.0:8080", "-socks-addr", "0.0.0.0:1080", "-auth", "user:password"},
+			want:      "serve-gateway",
+			wantError: nil,
+		},
+		{
+			name:      "NegativeHistoryRetention",
+			args:      []string{"check", "-history-retention", "-1h"},
+			wantError: errors.New("history-retention must not be negative"),
+		},
+		{
+			name:      "NegativeRecheckInterval",
+			args:      []string{"daemon", "-recheck-interval", "-1m"},
+			wantError: errors.New("recheck-interval must not be negative"),
+		},
+		{
+			name:      "DaemonWithoutInterval",
+			args:      []string{"daemon", "-interval", "0"},
+			wantError: errors.New("interval must be positive"),
+		},
+		{
+			name:      "DaemonWithSchedule",
+			args:      []string{"daemon", "-interval", "0", "-schedule", "0 * * * *"},
+			want:      "daemon",
+			wantError: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, _, err := parseCommand(tt.args)
+			if (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) ||
+				(err == nil && tt.wantError != nil) ||
+				(err != nil && tt.wantError == nil) {
+				t.Fatalf(expectedErrorButGotMessage, "parseCommand()", tt.wantError, err)
+			}
+
+			if tt.wantError == nil && command.Name != tt.want {
+				t.Errorf(expectedButGotMessage, "Name", tt.want, command.Name)
+			}
+		})
+	}
+}
+
+func TestParseCommandOptions(t *testing.T) {
+	_, options, err := parseCommand([]string{"export", "-categories", " socks5 ,http", "-formats", "JSON,pac", "-compress", "GZ", "-pac-include", "example.com, *.example.org", "-pac-max", "0"})
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+
+	if want := []string{"SOCKS5", "HTTP"}; !reflect.DeepEqual(options.Categories, want) {
+		t.Errorf(expectedButGotMessage, "Categories", want, options.Categories)
+	}
+
+	if want := []string{"json", "pac"}; !reflect.DeepEqual(options.Formats, want) {
+		t.Errorf(expectedButGotMessage, "Formats", want, options.Formats)
+	}
+
+	if want := []string{"gz"}; !reflect.DeepEqual(options.Compressions, want) {
+		t.Errorf(expectedButGotMessage, "Compressions", want, options.Compressions)
+	}
+
+	if want := []string{"example.com", "*.example.org"}; !reflect.DeepEqual(options.PACInclude, want) {
+		t.Errorf(expectedButGotMessage, "PACInclude", want, options.PACInclude)
+	}
+
+	if options.PACMax == nil || *options.PACMax != 0 {
+		t.Errorf(expectedButGotMessage, "PACMax", 0, options.PACMax)
+	}
+
+	if want := filepath.Join("storage", "advanced", "all.json"); options.Input != want {
+		t.Errorf(expectedButGotMessage, "Input", want, options.Input)
+	}
+
+	_, options, err = parseCommand([]string{"daemon", "-output", "public"})
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+
+	if options.PACMax != nil {
+		t.Errorf(expectedButGotMessage, "PACMax", nil, *options.PACMax)
+	}
+}
+
+func TestParseCommandSnapshotInput(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "Default",
+			args: []string{"run", "-incremental"},
+			want: filepath.Join("storage", "advanced", "all.json"),
+		},
+		{
+			name: "Snapshot",
+			args: []string{"daemon", "-snapshot", "-output", "public"},
+			want: filepath.Join("public", "current", "advanced", "all.json"),
+		},
+		{
+			name: "SnapshotWithInput",
+			args: []string{"check", "-snapshot", "-input", "proxies.json"},
+			want: "proxies.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, options, err := parseCommand(tt.args)
+			if err != nil {
+				t.Fatalf(unexpectedMessage, "error", err)
+			}
+
+			if options.Input != tt.want {
+				t.Errorf(expectedButGotMessage, "Input", tt.want, options.Input)
+			}
+		})
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "127.0.0.1:8080", want: true},
+		{addr: "127.1.2.3:8080", want: true},
+		{addr: "[::1]:1080", want: true},
+		{addr: "localhost:8080", want: true},
+		{addr: ":8080", want: false},
+		{addr: "0.0.0.0:8080", want: false},
+		{addr: "192.168.1.1:8080", want: false},
+		{addr: "example.com:8080", want: false},
+		{addr: "127.0.0.1", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isLoopback(tt.addr); got != tt.want {
+				t.Errorf(expectedButGotMessage, "isLoopback()", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFilterCategories(t *testing.T) {
+	proxies := []entity.AdvancedProxy{
+		{Proxy: "1.1.1.1:80", Categories: []string{"HTTP", "SOCKS5"}},
+		{Proxy: "2.2.2.2:1080", Categories: []string{"SOCKS4"}},
+		{Proxy: "3.3.3.3:443", Categories: []string{"HTTPS", "HTTP"}},
+	}
+
+	got := filterCategories(proxies, []string{"HTTP", "HTTPS"})
+	want := []entity.AdvancedProxy{
+		{Proxy: "1.1.1.1:80", Categories: []string{"HTTP"}},
+		{Proxy: "3.3.3.3:443", Categories: []string{"HTTPS", "HTTP"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "filterCategories()", want, got)
+	}
+
+	if want := []string{"HTTP", "SOCKS5"}; !reflect.DeepEqual(proxies[0].Categories, want) {
+		t.Errorf(expectedButGotMessage, "Categories", want, proxies[0].Categories)
+	}
+
+	if got := filterCategories(proxies, []string{"SOCKS5H"}); got != nil {
+		t.Errorf(expectedButGotMessage, "filterCategories()", nil, got)
+	}
+}
+
+func TestNextCycle(t *testing.T) {
+	var (
+		now      = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		nextRun  = now.Add(time.Hour)
+		interval = 15 * time.Minute
+	)
+	tests := []struct {
+		name            string
+		nextRecheck     time.Time
+		recheckInterval time.Duration
+		livePool        int
+		wantIsFull      bool
+		wantNext        time.Time
+	}{
+		{
+			name:            "Recheck",
+			nextRecheck:     now.Add(interval),
+			recheckInterval: interval,
+			livePool:        10,
+			wantIsFull:      false,
+			wantNext:        now.Add(interval),
+		},
+		{
+			name:            "RecheckAfterFullRun",
+			nextRecheck:     nextRun.Add(time.Minute),
+			recheckInterval: interval,
+			livePool:        10,
+			wantIsFull:      true,
+			wantNext:        nextRun,
+		},
+		{
+			name:            "RecheckWithFullRun",
+			nextRecheck:     nextRun,
+			recheckInterval: interval,
+			livePool:        10,
+			wantIsFull:      true,
+			wantNext:        nextRun,
+		},
+		{
+			name:            "EmptyLivePool",
+			nextRecheck:     now.Add(interval),
+			recheckInterval: interval,
+			livePool:        0,
+			wantIsFull:      true,
+			wantNext:        nextRun,
+		},
+		{
+			name:            "RecheckDisabled",
+			nextRecheck:     now,
+			recheckInterval: 0,
+			livePool:        10,
+			wantIsFull:      true,
+			wantNext:        nextRun,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isFull, next := nextCycle(nextRun, tt.nextRecheck, tt.recheckInterval, tt.livePool)
+			if isFull != tt.wantIsFull {
+				t.Errorf(expectedButGotMessage, "isFull", tt.wantIsFull, isFull)
+			}
+
+			if !next.Equal(tt.wantNext) {
+				t.Errorf(expectedButGotMessage, "next", tt.wantNext, next)
+			}
+		})
+	}
+}
@@ -1,44 +1,64 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
-	"slices"
-	"sync"
-	"time"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
 	"github.com/fyvri/fresh-proxy-list/internal/entity"
 	"github.com/fyvri/fresh-proxy-list/internal/infrastructure/config"
 	"github.com/fyvri/fresh-proxy-list/internal/infrastructure/repository"
 	"github.com/fyvri/fresh-proxy-list/internal/service"
-	"github.com/fyvri/fresh-proxy-list/internal/usecase"
 	"github.com/fyvri/fresh-proxy-list/pkg/utils"
 
 	"github.com/joho/godotenv"
 )
 
 type Runners struct {
-	fetcherUtil      utils.FetcherUtilInterface
-	urlParserUtil    utils.URLParserUtilInterface
-	proxyService     service.ProxyServiceInterface
-	sourceRepository repository.SourceRepositoryInterface
-	proxyRepository  repository.ProxyRepositoryInterface
-	fileRepository   repository.FileRepositoryInterface
+	config                *entity.Config
+	fetcherUtil           utils.FetcherUtilInterface
+	urlParserUtil         utils.URLParserUtilInterface
+	proxyService          service.ProxyServiceInterface
+	sourceRepository      repository.SourceRepositoryInterface
+	proxyRepository       repository.ProxyRepositoryInterface
+	historyRepository     repository.HistoryRepositoryInterface
+	sourceStateRepository repository.SourceStateRepositoryInterface
+	credentialRepository  repository.CredentialRepositoryInterface
+	fileRepository        repository.FileRepositoryInterface
+	snapshotRepository    repository.SnapshotRepositoryInterface
+	stagingRepository     repository.StagingRepositoryInterface
+	templateRepository    repository.TemplateRepositoryInterface
+	geoIPRepository       repository.GeoIPRepositoryInterface
 }
 
 func main() {
-	if err := runApplication(); err != nil {
+	if err := runApplication(os.Args[1:]); err != nil {
 		log.Fatalf("Application error: %v", err)
 	}
 }
 
-func runApplication() error {
+func runApplication(args []string) error {
 	loadEnv()
 
-	httpTestingSites := config.HTTPTestingSites
-	httpsTestingSites := config.HTTPSTestingSites
+	command, options, err := parseCommand(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	appConfig, err := loadConfig(options)
+	if err != nil {
+		return err
+	}
 	userAgents := config.UserAgents
 
 	mkdirAll := func(path string, perm os.FileMode) error {
@@ -51,77 +71,211 @@ func runApplication() error {
 		}
 		return file, nil
 	}
+	open := func(name string) (io.Reader, error) {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		return file, nil
+	}
+	writeFile := func(name string, data []byte) error {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		return os.WriteFile(name, data, 0644)
+	}
+	writePrivateFile := func(name string, data []byte) error {
+		if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+			return err
+		}
+		return os.WriteFile(name, data, 0600)
+	}
+	// Written and synced next to the file first so that a crash never leaves it half rewritten
+	replaceFile := func(name string, data []byte) error {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		file, err := os.Create(name + ".tmp")
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(data); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Sync(); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		return os.Rename(name+".tmp", name)
+	}
+	appendFile := func(name string, data []byte) error {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = file.Write(data)
+		return err
+	}
 
-	fetcherUtil := utils.NewFetcher(http.DefaultClient, http.NewRequest)
+	fetcherUtil := utils.NewFetcher(http.DefaultClient, http.NewRequestWithContext)
 	urlParserUtil := utils.NewURLParser()
 	csvWriterUtil := utils.NewCSVWriter()
-	proxyService := service.NewProxyService(fetcherUtil, urlParserUtil, httpTestingSites, httpsTestingSites, userAgents)
+	proxyService := service.NewProxyService(
+		fetcherUtil,
+		urlParserUtil,
+		appConfig.Checker.HTTPTestingSites,
+		appConfig.Checker.HTTPSTestingSites,
+		userAgents,
+		appConfig.Checker.Concurrency,
+		appConfig.Checker.Timeout,
+		appConfig.Checker.JudgeURL,
+		appConfig.Checker.VerifyTLS,
+		appConfig.Checker.ConnectTargets,
+		config.ProxyHeaders,
+	)
 	sourceRepository := repository.NewSourceRepository(os.Getenv("PROXY_RESOURCES"))
-	proxyRepository := repository.NewProxyRepository()
-	fileRepository := repository.NewFileRepository(mkdirAll, create, csvWriterUtil)
+	if options.Sources != "" {
+		sourceRepository = repository.NewSourceFileRepository(options.Sources, os.Getenv("PROXY_RESOURCES"), os.Stat, os.ReadFile, os.ReadDir)
+	}
+	proxyRepository := repository.NewProxyRepository(config.ProxyCategories)
+	var historyRepository repository.HistoryRepositoryInterface
+	if options.History != "" {
+		historyRepository = repository.NewHistoryRepository(options.History, options.HistoryRetention, os.ReadFile, appendFile, replaceFile)
+		if err := historyRepository.Load(); err != nil {
+			return err
+		}
+	}
+	var sourceStateRepository repository.SourceStateRepositoryInterface
+	if options.Incremental {
+		sourceStateRepository = repository.NewSourceStateRepository(options.SourceState, os.ReadFile, writeFile)
+		if err := sourceStateRepository.Load(); err != nil {
+			return err
+		}
+	}
+	var credentialRepository repository.CredentialRepositoryInterface
+	if options.CredentialsFile != "" {
+		credentialRepository = repository.NewCredentialRepository(options.CredentialsFile, os.ReadFile, writePrivateFile)
+		if err := credentialRepository.Load(); err != nil {
+			return err
+		}
+	}
+	fileRepository := repository.NewFileRepository(mkdirAll, create, open, os.Rename, os.Remove, csvWriterUtil, appConfig.PAC)
+	var snapshotRepository repository.SnapshotRepositoryInterface
+	if options.Snapshot {
+		snapshotRepository = repository.NewSnapshotRepository(options.Output, options.KeepSnapshots, os.Symlink, os.Rename, os.RemoveAll, os.ReadDir)
+	}
+	var stagingRepository repository.StagingRepositoryInterface
+	if command.Name == "daemon" && !options.Snapshot {
+		stagingRepository = repository.NewStagingRepository(options.Output, os.ReadFile, os.ReadDir, os.Rename, os.RemoveAll)
+	}
+	var templateRepository repository.TemplateRepositoryInterface
+	if options.Readme != "" {
+		templateRepository = repository.NewTemplateRepository(options.ReadmeTemplate, os.ReadFile, writeFile)
+	}
+	var geoIPRepository repository.GeoIPRepositoryInterface
+	if options.GeoIP != "" {
+		geoIPRepository = repository.NewGeoIPRepository(options.GeoIP, os.ReadFile)
+		if err := geoIPRepository.Load(); err != nil {
+			return err
+		}
+	}
 
 	runners := Runners{
-		fetcherUtil:      fetcherUtil,
-		urlParserUtil:    urlParserUtil,
-		proxyService:     proxyService,
-		sourceRepository: sourceRepository,
-		proxyRepository:  proxyRepository,
-		fileRepository:   fileRepository,
+		config:                appConfig,
+		fetcherUtil:           fetcherUtil,
+		urlParserUtil:         urlParserUtil,
+		proxyService:          proxyService,
+		sourceRepository:      sourceRepository,
+		proxyRepository:       proxyRepository,
+		historyRepository:     historyRepository,
+		sourceStateRepository: sourceStateRepository,
+		credentialRepository:  credentialRepository,
+		fileRepository:        fileRepository,
+		snapshotRepository:    snapshotRepository,
+		stagingRepository:     stagingRepository,
+		templateRepository:    templateRepository,
+		geoIPRepository:       geoIPRepository,
 	}
 
-	return run(runners)
-}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if options.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Deadline)
+		defer cancel()
+	}
+	stopInterrupt := context.AfterFunc(ctx, func() {
+		stop()
+		log.Printf("Run interrupted (%v), stopping new checks and saving partial results", ctx.Err())
+	})
+	defer stopInterrupt()
 
-func loadEnv() error {
-	return godotenv.Load()
+	return command.Run(ctx, runners, options)
 }
 
-func run(runners Runners) error {
-	startTime := time.Now()
-
-	sourceUsecase := usecase.NewSourceUsecase(runners.sourceRepository, runners.fetcherUtil)
-	sources, err := sourceUsecase.LoadSources()
+func loadConfig(options Options) (*entity.Config, error) {
+	defaults := entity.Config{
+		Checker: entity.CheckerConfig{
+			Concurrency:       config.CheckerConcurrency,
+			Timeout:           config.CheckerTimeout,
+			HTTPTestingSites:  config.HTTPTestingSites,
+			HTTPSTestingSites: config.HTTPSTestingSites,
+		},
+		PAC: entity.PACConfig{
+			Max: config.PACMax,
+		},
+	}
+	appConfig, err := repository.NewConfigRepository(options.Config, defaults, os.ReadFile, os.Getenv).LoadConfig()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	wg := sync.WaitGroup{}
-	proxyCategories := config.ProxyCategories
-	specialIPs := config.SpecialIPs
-	privateIPs := config.PrivateIPs
-	proxyUsecase := usecase.NewProxyUsecase(runners.proxyRepository, runners.proxyService, specialIPs, privateIPs)
-	for i, source := range sources {
-		if _, found := slices.BinarySearch(proxyCategories, source.Category); found {
-			wg.Add(1)
-			go func(source entity.Source) {
-				defer wg.Done()
-
-				proxies, err := sourceUsecase.ProcessSource(&source)
-				if err != nil {
-					return
-				}
-
-				innerWG := sync.WaitGroup{}
-				for _, proxy := range proxies {
-					innerWG.Add(1)
-					go func(source entity.Source, proxy string) {
-						defer innerWG.Done()
-						proxyUsecase.ProcessProxy(source.Category, proxy, source.IsChecked)
-					}(source, proxy)
-				}
-				innerWG.Wait()
-			}(source)
-		} else {
-			log.Printf("Index %v: proxy category not found", i)
-		}
+	if options.Concurrency != 0 {
+		appConfig.Checker.Concurrency = options.Concurrency
+	}
+	if options.Timeout != 0 {
+		appConfig.Checker.Timeout = options.Timeout
+	}
+	if len(options.HTTPTestingSites) > 0 {
+		appConfig.Checker.HTTPTestingSites = entity.NewTestingSites(options.HTTPTestingSites)
+	}
+	if len(options.HTTPSTestingSites) > 0 {
+		appConfig.Checker.HTTPSTestingSites = entity.NewTestingSites(options.HTTPSTestingSites)
+	}
+	if options.JudgeURL != "" {
+		appConfig.Checker.JudgeURL = options.JudgeURL
+	}
+	if options.VerifyTLS {
+		appConfig.Checker.VerifyTLS = true
+	}
+	if len(options.ConnectTargets) > 0 {
+		appConfig.Checker.ConnectTargets = options.ConnectTargets
+	}
+	if len(options.PACInclude) > 0 {
+		appConfig.PAC.Include = options.PACInclude
+	}
+	if len(options.PACExclude) > 0 {
+		appConfig.PAC.Exclude = options.PACExclude
+	}
+	if options.PACMax != nil {
+		appConfig.PAC.Max = *options.PACMax
 	}
-	wg.Wait()
 
-	fileOutputExtensions := config.FileOutputExtensions
-	fileUsecase := usecase.NewFileUsecase(runners.fileRepository, runners.proxyRepository, fileOutputExtensions)
-	fileUsecase.SaveFiles()
+	if err := appConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return appConfig, nil
+}
 
-	log.Printf("Number of proxies     : %v", len(proxyUsecase.GetAllAdvancedView()))
-	log.Printf("Time-consuming process: %v", time.Since(startTime))
-	return nil
+func loadEnv() error {
+	return godotenv.Load()
 }
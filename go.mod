@@ -4,6 +4,7 @@ go 1.23
 
 require (
 	github.com/joho/godotenv v1.5.1
+	github.com/klauspost/compress v1.17.11
 	gopkg.in/yaml.v3 v3.0.1
 	h12.io/socks v1.0.3
 )
@@ -0,0 +1,46 @@
+package entity
+
+type ClashConfig struct {
+	Proxies     []ClashProxy      `yaml:"proxies"`
+	ProxyGroups []ClashProxyGroup `yaml:"proxy-groups"`
+}
+
+type ClashProxy struct {
+	Name     string `yaml:"name"`
+	Type     string `yaml:"type"`
+	Server   string `yaml:"server"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	TLS      bool   `yaml:"tls,omitempty"`
+}
+
+type ClashProxyGroup struct {
+	Name     string   `yaml:"name"`
+	Type     string   `yaml:"type"`
+	Proxies  []string `yaml:"proxies"`
+	URL      string   `yaml:"url"`
+	Interval int      `yaml:"interval"`
+}
+
+type SingBoxConfig struct {
+	Outbounds []SingBoxOutbound `json:"outbounds"`
+}
+
+type SingBoxOutbound struct {
+	Type       string      `json:"type"`
+	Tag        string      `json:"tag"`
+	Server     string      `json:"server,omitempty"`
+	ServerPort int         `json:"server_port,omitempty"`
+	Version    string      `json:"version,omitempty"`
+	Username   string      `json:"username,omitempty"`
+	Password   string      `json:"password,omitempty"`
+	TLS        *SingBoxTLS `json:"tls,omitempty"`
+	Outbounds  []string    `json:"outbounds,omitempty"`
+	URL        string      `json:"url,omitempty"`
+	Interval   string      `json:"interval,omitempty"`
+}
+
+type SingBoxTLS struct {
+	Enabled bool `json:"enabled"`
+}
@@ -0,0 +1,126 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	Checker CheckerConfig `json:"checker" yaml:"checker"`
+	PAC     PACConfig     `json:"pac" yaml:"pac"`
+}
+
+type PACConfig struct {
+	Include []string `json:"include" yaml:"include"`
+	Exclude []string `json:"exclude" yaml:"exclude"`
+	Max     int      `json:"max" yaml:"max"`
+}
+
+var pacRuleRegex = regexp.MustCompile(`^[A-Za-z0-9*?.\-]+$`)
+
+type CheckerConfig struct {
+	Concurrency       int           `json:"concurrency" yaml:"concurrency"`
+	Timeout           time.Duration `json:"timeout" yaml:"timeout"`
+	HTTPTestingSites  []TestingSite `json:"http_testing_sites" yaml:"http_testing_sites"`
+	HTTPSTestingSites []TestingSite `json:"https_testing_sites" yaml:"https_testing_sites"`
+	JudgeURL          string        `json:"judge_url" yaml:"judge_url"`
+	VerifyTLS         bool          `json:"verify_tls" yaml:"verify_tls"`
+	ConnectTargets    []string      `json:"connect_targets" yaml:"connect_targets"`
+}
+
+func (c *Config) Validate() error {
+	return errors.Join(c.Checker.Validate(), c.PAC.Validate())
+}
+
+func (c *CheckerConfig) Validate() error {
+	var errs []error
+	if c.Concurrency < 1 {
+		errs = append(errs, fmt.Errorf("checker concurrency must be at least 1, got %d", c.Concurrency))
+	}
+
+	if c.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("checker timeout must be positive, got %v", c.Timeout))
+	}
+
+	// The sites are cloned before their patterns are compiled, they may still share the array of the defaults
+	validateTestingSites := func(name string, scheme string, testingSites []TestingSite) []TestingSite {
+		testingSites = slices.Clone(testingSites)
+		if len(testingSites) == 0 {
+			errs = append(errs, fmt.Errorf("%s testing sites must not be empty", name))
+		}
+
+		for i, testingSite := range testingSites {
+			siteURL, err := url.Parse(testingSite.URL)
+			if err != nil || siteURL.Scheme != scheme || siteURL.Host == "" {
+				errs = append(errs, fmt.Errorf("%s testing site invalid: %s", name, testingSite.URL))
+			}
+
+			if pattern, err := regexp.Compile(testingSite.Regex); err != nil {
+				errs = append(errs, fmt.Errorf("%s testing site regex invalid: %s: %v", name, testingSite.URL, err))
+			} else if testingSite.Regex != "" {
+				testingSites[i].Pattern = pattern
+			}
+
+			if testingSite.SHA256 != "" {
+				if sum, err := hex.DecodeString(testingSite.SHA256); err != nil || len(sum) != sha256.Size {
+					errs = append(errs, fmt.Errorf("%s testing site sha256 invalid: %s", name, testingSite.URL))
+				}
+			}
+
+			if testingSite.CertSHA256 != "" {
+				if sum, err := hex.DecodeString(testingSite.CertSHA256); err != nil || len(sum) != sha256.Size {
+					errs = append(errs, fmt.Errorf("%s testing site cert_sha256 invalid: %s", name, testingSite.URL))
+				}
+			}
+
+			if testingSite.SPKIPin != "" {
+				if sum, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(testingSite.SPKIPin, "sha256/")); err != nil || len(sum) != sha256.Size {
+					errs = append(errs, fmt.Errorf("%s testing site spki_pin invalid: %s", name, testingSite.URL))
+				}
+			}
+		}
+		return testingSites
+	}
+	c.HTTPTestingSites = validateTestingSites("HTTP", "http", c.HTTPTestingSites)
+	c.HTTPSTestingSites = validateTestingSites("HTTPS", "https", c.HTTPSTestingSites)
+
+	if c.JudgeURL != "" {
+		judgeURL, err := url.Parse(c.JudgeURL)
+		if err != nil || (judgeURL.Scheme != "http" && judgeURL.Scheme != "https") || judgeURL.Host == "" {
+			errs = append(errs, fmt.Errorf("judge url invalid: %s", c.JudgeURL))
+		}
+	}
+
+	for _, target := range c.ConnectTargets {
+		host, port, err := net.SplitHostPort(target)
+		if portNumber, _ := strconv.Atoi(port); err != nil || host == "" || portNumber < 1 || portNumber > 65535 {
+			errs = append(errs, fmt.Errorf("connect target invalid: %s", target))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *PACConfig) Validate() error {
+	var errs []error
+	for _, rule := range append(slices.Clone(c.Include), c.Exclude...) {
+		if !pacRuleRegex.MatchString(rule) {
+			errs = append(errs, fmt.Errorf("pac rule invalid: %s", rule))
+		}
+	}
+
+	if c.Max < 0 {
+		errs = append(errs, fmt.Errorf("pac max must not be negative, got %d", c.Max))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,160 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	valid := CheckerConfig{
+		Concurrency:       100,
+		Timeout:           10 * time.Second,
+		HTTPTestingSites:  []TestingSite{{URL: "http://example.com/ip", Regex: `^[0-9.]+$`}},
+		HTTPSTestingSites: []TestingSite{{URL: "https://example.com/ip", SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}},
+	}
+
+	tests := []struct {
+		name      string
+		config    Config
+		wantError error
+	}{
+		{
+			name:      "Valid",
+			config:    Config{Checker: valid},
+			wantError: nil,
+		},
+		{
+			name: "InvalidConcurrencyAndTimeout",
+			config: Config{
+				Checker: CheckerConfig{
+					Concurrency:       0,
+					Timeout:           -time.Second,
+					HTTPTestingSites:  valid.HTTPTestingSites,
+					HTTPSTestingSites: valid.HTTPSTestingSites,
+				},
+			},
+			wantError: errors.New("checker concurrency must be at least 1, got 0\nchecker timeout must be positive, got -1s"),
+		},
+		{
+			name: "InvalidTestingSites",
+			config: Config{
+				Checker: CheckerConfig{
+					Concurrency:       valid.Concurrency,
+					Timeout:           valid.Timeout,
+					HTTPTestingSites:  []TestingSite{{URL: "https://example.com/ip"}},
+					HTTPSTestingSites: nil,
+				},
+			},
+			wantError: errors.New("HTTP testing site invalid: https://example.com/ip\nHTTPS testing sites must not be empty"),
+		},
+		{
+			name: "InvalidTestingSiteRules",
+			config: Config{
+				Checker: CheckerConfig{
+					Concurrency:       valid.Concurrency,
+					Timeout:           valid.Timeout,
+					HTTPTestingSites:  []TestingSite{{URL: "http://example.com/ip", Regex: "("}},
+					HTTPSTestingSites: []TestingSite{{URL: "https://example.com/ip", SHA256: "abc"}},
+				},
+			},
+			wantError: errors.New("HTTP testing site regex invalid: http://example.com/ip: error parsing regexp: missing closing ): `(`\nHTTPS testing site sha256 invalid: https://example.com/ip"),
+		},
+		{
+			name: "InvalidCertificatePins",
+			config: Config{
+				Checker: CheckerConfig{
+					Concurrency:       valid.Concurrency,
+					Timeout:           valid.Timeout,
+					HTTPTestingSites:  valid.HTTPTestingSites,
+					HTTPSTestingSites: []TestingSite{{URL: "https://example.com/ip", CertSHA256: "abc", SPKIPin: "sha256/abc"}},
+					VerifyTLS:         true,
+				},
+			},
+			wantError: errors.New("HTTPS testing site cert_sha256 invalid: https://example.com/ip\nHTTPS testing site spki_pin invalid: https://example.com/ip"),
+		},
+		{
+			name: "InvalidJudgeURL",
+			config: Config{
+				Checker: CheckerConfig{
+					Concurrency:       valid.Concurrency,
+					Timeout:           valid.Timeout,
+					HTTPTestingSites:  valid.HTTPTestingSites,
+					HTTPSTestingSites: valid.HTTPSTestingSites,
+					JudgeURL:          "judge.example.com",
+				},
+			},
+			wantError: errors.New("judge url invalid: judge.example.com"),
+		},
+		{
+			name: "InvalidConnectTargets",
+			config: Config{
+				Checker: CheckerConfig{
+					Concurrency:       valid.Concurrency,
+					Timeout:           valid.Timeout,
+					HTTPTestingSites:  valid.HTTPTestingSites,
+					HTTPSTestingSites: valid.HTTPSTestingSites,
+					ConnectTargets:    []string{"example.com:443", "example.com", ":22", "example.com:70000"},
+				},
+			},
+			wantError: errors.New("connect target invalid: example.com\nconnect target invalid: :22\nconnect target invalid: example.com:70000"),
+		},
+		{
+			name: "InvalidPACRules",
+			config: Config{
+				Checker: valid,
+				PAC: PACConfig{
+					Include: []string{"example.com", "*.example.org", "bad rule"},
+					Exclude: []string{"localhost", `evil";alert(1)//`},
+				},
+			},
+			wantError: errors.New("pac rule invalid: bad rule\npac rule invalid: evil\";alert(1)//"),
+		},
+		{
+			name: "NegativePACMax",
+			config: Config{
+				Checker: valid,
+				PAC:     PACConfig{Max: -1},
+			},
+			wantError: errors.New("pac max must not be negative, got -1"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) ||
+				(err == nil && tt.wantError != nil) ||
+				(err != nil && tt.wantError == nil) {
+				t.Errorf(expectedErrorButGotMessage, "Validate()", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestConfigValidateCompilesRegex(t *testing.T) {
+	defaults := []TestingSite{{URL: "http://example.com/ip", Regex: `^[0-9.]+$`}}
+	config := Config{
+		Checker: CheckerConfig{
+			Concurrency:       1,
+			Timeout:           time.Second,
+			HTTPTestingSites:  defaults,
+			HTTPSTestingSites: []TestingSite{{URL: "https://example.com"}},
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "Validate()", nil, err)
+	}
+
+	if pattern := config.Checker.HTTPTestingSites[0].Pattern; pattern == nil || !pattern.MatchString("13.37.0.1") {
+		t.Errorf(expectedButGotMessage, "Pattern", `^[0-9.]+$`, pattern)
+	}
+	if pattern := config.Checker.HTTPSTestingSites[0].Pattern; pattern != nil {
+		t.Errorf(expectedButGotMessage, "Pattern", nil, pattern)
+	}
+
+	// The shared defaults are left untouched
+	if pattern := defaults[0].Pattern; pattern != nil {
+		t.Errorf(expectedButGotMessage, "Pattern", nil, pattern)
+	}
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type Upstream struct {
+	Proxy    AdvancedProxy
+	Category string
+	Evicted  bool
+}
+
+type GatewaySession struct {
+	Proxy    string
+	LastUsed time.Time
+}
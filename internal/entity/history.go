@@ -0,0 +1,17 @@
+package entity
+
+type CheckRecord struct {
+	Category  string  `json:"category"`
+	Proxy     string  `json:"proxy"`
+	OK        bool    `json:"ok"`
+	TimeTaken float64 `json:"time_taken,omitempty"`
+	CheckedAt string  `json:"checked_at"`
+}
+
+type ProxyHistory struct {
+	Uptime              float64
+	FirstSeen           string
+	LastSeen            string
+	ConsecutiveFailures int
+	AverageLatency      float64
+}
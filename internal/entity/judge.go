@@ -0,0 +1,6 @@
+package entity
+
+type JudgeResponse struct {
+	IP      string              `json:"ip"`
+	Headers map[string][]string `json:"headers"`
+}
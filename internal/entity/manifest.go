@@ -0,0 +1,16 @@
+package entity
+
+type Manifest struct {
+	GeneratedAt string         `json:"generated_at"`
+	Files       []ManifestFile `json:"files"`
+}
+
+type ManifestFile struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	Count       int    `json:"count"`
+	Category    string `json:"category"`
+	Format      string `json:"format"`
+	Compression string `json:"compression,omitempty"`
+}
@@ -1,19 +1,81 @@
 package entity
 
 type Proxy struct {
-	Category  string  `json:"category" yaml:"category"`
-	Proxy     string  `json:"proxy" yaml:"proxy"`
-	IP        string  `json:"ip"  yaml:"ip"`
-	Port      string  `json:"port" yaml:"port"`
-	TimeTaken float64 `json:"time_taken" yaml:"time_taken"`
-	CheckedAt string  `json:"checked_at" yaml:"checked_at"`
+	Category     string  `json:"category" yaml:"category"`
+	Proxy        string  `json:"proxy" yaml:"proxy"`
+	IP           string  `json:"ip"  yaml:"ip"`
+	Port         string  `json:"port" yaml:"port"`
+	Username     string  `json:"username,omitempty" yaml:"username,omitempty" xml:",omitempty"`
+	Password     string  `json:"password,omitempty" yaml:"password,omitempty" xml:",omitempty"`
+	TimeTaken    float64 `json:"time_taken" yaml:"time_taken"`
+	CheckedAt    string  `json:"checked_at" yaml:"checked_at"`
+	Anonymity    string  `json:"anonymity,omitempty" yaml:"anonymity,omitempty" xml:",omitempty"`
+	ExitIP       string  `json:"exit_ip,omitempty" yaml:"exit_ip,omitempty" xml:",omitempty"`
+	IsRotating   bool    `json:"is_rotating,omitempty" yaml:"is_rotating,omitempty" xml:",omitempty"`
+	IsMITM       bool    `json:"is_mitm,omitempty" yaml:"is_mitm,omitempty" xml:",omitempty"`
+	RemoteDNS    bool    `json:"remote_dns,omitempty" yaml:"remote_dns,omitempty" xml:",omitempty"`
+	ConnectPorts []int   `json:"connect_ports,omitempty" yaml:"connect_ports,omitempty" xml:",omitempty"`
 }
 
 type AdvancedProxy struct {
-	Proxy      string   `json:"proxy" yaml:"proxy"`
-	IP         string   `json:"ip" yaml:"ip"`
-	Port       string   `json:"port" yaml:"port"`
-	TimeTaken  float64  `json:"time_taken" yaml:"time_taken"`
-	CheckedAt  string   `json:"checked_at" yaml:"checked_at"`
-	Categories []string `json:"categories" yaml:"categories"`
+	Proxy        string   `json:"proxy" yaml:"proxy"`
+	IP           string   `json:"ip" yaml:"ip"`
+	Port         string   `json:"port" yaml:"port"`
+	Username     string   `json:"username,omitempty" yaml:"username,omitempty" xml:",omitempty"`
+	Password     string   `json:"password,omitempty" yaml:"password,omitempty" xml:",omitempty"`
+	TimeTaken    float64  `json:"time_taken" yaml:"time_taken"`
+	CheckedAt    string   `json:"checked_at" yaml:"checked_at"`
+	Anonymity    string   `json:"anonymity,omitempty" yaml:"anonymity,omitempty" xml:",omitempty"`
+	ExitIP       string   `json:"exit_ip,omitempty" yaml:"exit_ip,omitempty" xml:",omitempty"`
+	IsRotating   bool     `json:"is_rotating,omitempty" yaml:"is_rotating,omitempty" xml:",omitempty"`
+	IsMITM       bool     `json:"is_mitm,omitempty" yaml:"is_mitm,omitempty" xml:",omitempty"`
+	RemoteDNS    bool     `json:"remote_dns,omitempty" yaml:"remote_dns,omitempty" xml:",omitempty"`
+	ConnectPorts []int    `json:"connect_ports,omitempty" yaml:"connect_ports,omitempty" xml:",omitempty"`
+	Categories   []string `json:"categories" yaml:"categories"`
+
+	Uptime              float64 `json:"uptime,omitempty" yaml:"uptime,omitempty" xml:",omitempty"`
+	FirstSeen           string  `json:"first_seen,omitempty" yaml:"first_seen,omitempty" xml:",omitempty"`
+	LastSeen            string  `json:"last_seen,omitempty" yaml:"last_seen,omitempty" xml:",omitempty"`
+	ConsecutiveFailures int     `json:"consecutive_failures,omitempty" yaml:"consecutive_failures,omitempty" xml:",omitempty"`
+	AverageLatency      float64 `json:"average_latency,omitempty" yaml:"average_latency,omitempty" xml:",omitempty"`
+}
+
+type Credential struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type ProxyCandidate struct {
+	Category  string
+	Proxy     string
+	IsChecked bool
+	Source    *SourceYield
+}
+
+func (p *Proxy) WithCredentials() string {
+	return withCredentials(p.Proxy, p.Username, p.Password)
+}
+
+func (p *AdvancedProxy) WithCredentials() string {
+	return withCredentials(p.Proxy, p.Username, p.Password)
+}
+
+func (p *AdvancedProxy) History() *ProxyHistory {
+	if p.FirstSeen == "" {
+		return nil
+	}
+	return &ProxyHistory{
+		Uptime:              p.Uptime,
+		FirstSeen:           p.FirstSeen,
+		LastSeen:            p.LastSeen,
+		ConsecutiveFailures: p.ConsecutiveFailures,
+		AverageLatency:      p.AverageLatency,
+	}
+}
+
+func withCredentials(proxy string, username string, password string) string {
+	if username == "" && password == "" {
+		return proxy
+	}
+	return username + ":" + password + "@" + proxy
 }
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"testing"
+)
+
+func TestWithCredentials(t *testing.T) {
+	tests := []struct {
+		name  string
+		proxy Proxy
+		want  string
+	}{
+		{
+			name:  "WithoutCredentials",
+			proxy: Proxy{Proxy: "13.37.0.1:1337"},
+			want:  "13.37.0.1:1337",
+		},
+		{
+			name:  "WithCredentials",
+			proxy: Proxy{Proxy: "13.37.0.1:1337", Username: "user", Password: "pass"},
+			want:  "user:pass@13.37.0.1:1337",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.proxy.WithCredentials(); got != tt.want {
+				t.Errorf(expectedButGotMessage, "Proxy.WithCredentials()", tt.want, got)
+			}
+
+			advancedProxy := AdvancedProxy{Proxy: tt.proxy.Proxy, Username: tt.proxy.Username, Password: tt.proxy.Password}
+			if got := advancedProxy.WithCredentials(); got != tt.want {
+				t.Errorf(expectedButGotMessage, "AdvancedProxy.WithCredentials()", tt.want, got)
+			}
+		})
+	}
+}
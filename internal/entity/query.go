@@ -0,0 +1,17 @@
+package entity
+
+type ProxyQuery struct {
+	Category   string
+	Anonymity  string
+	MaxLatency float64
+	Limit      int
+}
+
+type ProxyStats struct {
+	Total          int            `json:"total"`
+	Categories     map[string]int `json:"categories"`
+	Anonymity      map[string]int `json:"anonymity,omitempty"`
+	Rotating       int            `json:"rotating"`
+	AverageLatency float64        `json:"average_latency"`
+	LastCheckedAt  string         `json:"last_checked_at,omitempty"`
+}
@@ -0,0 +1,38 @@
+package entity
+
+import "time"
+
+type Report struct {
+	UpdatedAt      time.Time
+	Duration       time.Duration
+	Total          int
+	Categories     map[string]int
+	Anonymity      map[string]int
+	Rotating       int
+	AverageLatency float64
+	MedianLatency  float64
+	Proxies        map[string][]string
+	Sources        []SourceYield
+	Countries      []CountryCount
+}
+
+type SourceYield struct {
+	Method    string
+	Category  string
+	URL       string
+	Collected int
+	Working   int
+}
+
+type CountryCount struct {
+	Country string
+	Count   int
+}
+
+// Yield returns the percentage of the collected proxies that ended up working
+func (y SourceYield) Yield() float64 {
+	if y.Collected == 0 {
+		return 0
+	}
+	return float64(y.Working) / float64(y.Collected) * 100
+}
@@ -1,12 +1,17 @@
 package entity
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
 
 type Source struct {
-	Method    string `json:"method"`
-	Category  string `json:"category"`
-	URL       string `json:"url"`
-	IsChecked bool   `json:"is_checked"`
+	Method    string `json:"method" yaml:"method"`
+	Category  string `json:"category" yaml:"category"`
+	URL       string `json:"url" yaml:"url"`
+	IsChecked bool   `json:"is_checked" yaml:"is_checked"`
+	Origin    string `json:"-" yaml:"-"`
 }
 
 func (s *Source) UnmarshalJSON(data []byte) error {
@@ -30,3 +35,11 @@ func (s *Source) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+func (s *Source) UnmarshalYAML(value *yaml.Node) error {
+	type Alias Source
+	alias := (*Alias)(s)
+	alias.IsChecked = true
+
+	return value.Decode(alias)
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"strconv"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -123,3 +125,74 @@ func TestUnmarshalJSONWithEmptyData(t *testing.T) {
 		t.Errorf(expectedButGotMessage, "is_checked", true, source.IsChecked)
 	}
 }
+
+func TestUnmarshalYAMLWithIsChecked(t *testing.T) {
+	var (
+		source = Source{}
+		data   = []byte(`
+method: ` + testMethod + `
+category: ` + testCategory + `
+url: ` + testURL + `
+is_checked: ` + strconv.FormatBool(testIsChecked) + `
+`)
+	)
+	err := yaml.Unmarshal(data, &source)
+
+	if err != nil {
+		t.Errorf(expectedErrorButGotMessage, "unmarshal", nil, err)
+	}
+
+	if source.Method != testMethod {
+		t.Errorf(expectedButGotMessage, "method", testMethod, source.Method)
+	}
+
+	if source.Category != testCategory {
+		t.Errorf(expectedButGotMessage, "category", testCategory, source.Category)
+	}
+
+	if source.URL != testURL {
+		t.Errorf(expectedButGotMessage, "url", testURL, source.URL)
+	}
+
+	if source.IsChecked != testIsChecked {
+		t.Errorf(expectedButGotMessage, "is_checked", testIsChecked, source.IsChecked)
+	}
+}
+
+func TestUnmarshalYAMLWithoutIsChecked(t *testing.T) {
+	var (
+		source = Source{}
+		data   = []byte(`
+method: ` + testMethod + `
+category: ` + testCategory + `
+url: ` + testURL + `
+`)
+	)
+	err := yaml.Unmarshal(data, &source)
+
+	if err != nil {
+		t.Errorf(expectedErrorButGotMessage, "unmarshal", nil, err)
+	}
+
+	if source.URL != testURL {
+		t.Errorf(expectedButGotMessage, "url", testURL, source.URL)
+	}
+
+	if source.IsChecked != true {
+		t.Errorf(expectedButGotMessage, "is_checked", true, source.IsChecked)
+	}
+}
+
+func TestUnmarshalYAMLWithInvalidData(t *testing.T) {
+	var (
+		source = Source{}
+		data   = []byte(`
+method: ` + testMethod + `
+is_checked: string_instead_of_bool
+`)
+	)
+	err := yaml.Unmarshal(data, &source)
+	if err == nil {
+		t.Errorf(expectedButGotMessage, "unmarshal", "any error", err)
+	}
+}
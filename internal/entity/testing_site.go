@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+type TestingSite struct {
+	URL        string `json:"url" yaml:"url"`
+	Regex      string `json:"regex,omitempty" yaml:"regex,omitempty"`
+	Body       string `json:"body,omitempty" yaml:"body,omitempty"`
+	Header     string `json:"header,omitempty" yaml:"header,omitempty"`
+	SHA256     string `json:"sha256,omitempty" yaml:"sha256,omitempty"`
+	CertSHA256 string `json:"cert_sha256,omitempty" yaml:"cert_sha256,omitempty"`
+	SPKIPin    string `json:"spki_pin,omitempty" yaml:"spki_pin,omitempty"`
+
+	// Regex compiled once by CheckerConfig.Validate
+	Pattern *regexp.Regexp `json:"-" yaml:"-"`
+}
+
+func NewTestingSites(urls []string) []TestingSite {
+	var testingSites []TestingSite
+	for _, url := range urls {
+		testingSites = append(testingSites, TestingSite{URL: url})
+	}
+	return testingSites
+}
+
+func (t *TestingSite) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &t.URL); err == nil {
+		return nil
+	}
+
+	type Alias TestingSite
+	return json.Unmarshal(data, (*Alias)(t))
+}
+
+func (t *TestingSite) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&t.URL)
+	}
+
+	type Alias TestingSite
+	return value.Decode((*Alias)(t))
+}
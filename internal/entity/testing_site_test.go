@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestNewTestingSites(t *testing.T) {
+	want := []TestingSite{{URL: "http://a.example.com"}, {URL: "http://b.example.com"}}
+	if got := NewTestingSites([]string{"http://a.example.com", "http://b.example.com"}); !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "NewTestingSites()", want, got)
+	}
+}
+
+func TestUnmarshalTestingSites(t *testing.T) {
+	want := []TestingSite{
+		{URL: "http://a.example.com"},
+		{URL: "http://b.example.com", Regex: "^ok$", Header: "Server: judge"},
+	}
+
+	tests := []struct {
+		name      string
+		unmarshal func(data []byte, v interface{}) error
+		data      string
+	}{
+		{
+			name:      "JSON",
+			unmarshal: json.Unmarshal,
+			data:      `["http://a.example.com", {"url": "http://b.example.com", "regex": "^ok$", "header": "Server: judge"}]`,
+		},
+		{
+			name:      "YAML",
+			unmarshal: yaml.Unmarshal,
+			data:      "- http://a.example.com\n- url: http://b.example.com\n  regex: ^ok$\n  header: 'Server: judge'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []TestingSite
+			if err := tt.unmarshal([]byte(tt.data), &got); err != nil {
+				t.Errorf(expectedErrorButGotMessage, "unmarshal", nil, err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf(expectedButGotMessage, "testing sites", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+	"github.com/fyvri/fresh-proxy-list/internal/usecase"
+)
+
+type APIHandler struct {
+	QueryUsecase usecase.QueryUsecaseInterface
+	Mux          *http.ServeMux
+}
+
+// Formats in the order they are picked from the Accept header, the first media type is sent as Content-Type
+var apiFormats = []struct {
+	Format     string
+	MediaTypes []string
+}{
+	{Format: "json", MediaTypes: []string{"application/json"}},
+	{Format: "jsonl", MediaTypes: []string{"application/x-ndjson", "application/jsonl"}},
+	{Format: "txt", MediaTypes: []string{"text/plain; charset=utf-8", "text/plain"}},
+	{Format: "csv", MediaTypes: []string{"text/csv; charset=utf-8", "text/csv"}},
+	{Format: "xml", MediaTypes: []string{"application/xml", "text/xml"}},
+	{Format: "yaml", MediaTypes: []string{"application/yaml", "application/x-yaml", "text/yaml"}},
+	{Format: "pac", MediaTypes: []string{"application/x-ns-proxy-autoconfig"}},
+}
+
+func NewAPIHandler(queryUsecase usecase.QueryUsecaseInterface) http.Handler {
+	h := &APIHandler{
+		QueryUsecase: queryUsecase,
+		Mux:          http.NewServeMux(),
+	}
+	h.Mux.HandleFunc("GET /proxies", h.getProxies)
+	h.Mux.HandleFunc("GET /proxies/random", h.getRandomProxy)
+	h.Mux.HandleFunc("GET /stats", h.getStats)
+	return h
+}
+
+func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	h.Mux.ServeHTTP(w, r)
+}
+
+func (h *APIHandler) getProxies(w http.ResponseWriter, r *http.Request) {
+	query, format, err := parseProxyQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if format == "jsonl" {
+		h.streamProxies(w, query)
+		return
+	}
+
+	classic, advanced, err := h.QueryUsecase.Query(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.writeProxies(w, classic, advanced, format)
+}
+
+func (h *APIHandler) streamProxies(w http.ResponseWriter, query entity.ProxyQuery) {
+	stream, err := h.QueryUsecase.Stream(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Lines are sent as they are encoded, an error past the first line can only cut the response short
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	h.QueryUsecase.Encode(w, nil, stream, "jsonl")
+}
+
+func (h *APIHandler) getRandomProxy(w http.ResponseWriter, r *http.Request) {
+	query, format, err := parseProxyQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	classic, advanced, err := h.QueryUsecase.Random(query)
+	if errors.Is(err, usecase.ErrProxyNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.writeProxies(w, classic, advanced, format)
+}
+
+func (h *APIHandler) getStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.QueryUsecase.Stats())
+}
+
+func (h *APIHandler) writeProxies(w http.ResponseWriter, classic []string, advanced interface{}, format string) {
+	var buffer bytes.Buffer
+	if err := h.QueryUsecase.Encode(&buffer, classic, advanced, format); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, apiFormat := range apiFormats {
+		if apiFormat.Format == format {
+			w.Header().Set("Content-Type", apiFormat.MediaTypes[0])
+		}
+	}
+	w.Write(buffer.Bytes())
+}
+
+func parseProxyQuery(r *http.Request) (entity.ProxyQuery, string, error) {
+	var (
+		values = r.URL.Query()
+		query  = entity.ProxyQuery{
+			Category:  strings.ToUpper(values.Get("category")),
+			Anonymity: strings.ToLower(values.Get("anonymity")),
+		}
+	)
+
+	if value := values.Get("max_latency"); value != "" {
+		maxLatency, err := strconv.ParseFloat(value, 64)
+		if err != nil || maxLatency < 0 {
+			return query, "", fmt.Errorf("invalid max_latency: %s", value)
+		}
+		query.MaxLatency = maxLatency
+	}
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			return query, "", fmt.Errorf("invalid limit: %s", value)
+		}
+		query.Limit = limit
+	}
+
+	format, err := negotiateFormat(values.Get("format"), r.Header.Get("Accept"))
+	return query, format, err
+}
+
+func negotiateFormat(format string, accept string) (string, error) {
+	if format != "" {
+		format = strings.ToLower(format)
+		for _, apiFormat := range apiFormats {
+			if apiFormat.Format == format {
+				return format, nil
+			}
+		}
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+
+	for _, accepted := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		for _, apiFormat := range apiFormats {
+			for _, apiMediaType := range apiFormat.MediaTypes {
+				if mediaType == apiMediaType {
+					return apiFormat.Format, nil
+				}
+			}
+		}
+	}
+	return apiFormats[0].Format, nil
+}
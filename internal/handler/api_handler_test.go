@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+	"github.com/fyvri/fresh-proxy-list/internal/infrastructure/repository"
+	"github.com/fyvri/fresh-proxy-list/internal/usecase"
+)
+
+func newTestAPIHandler(queries chan<- entity.ProxyQuery) http.Handler {
+	return NewAPIHandler(&mockQueryUsecase{
+		QueryFunc: func(query entity.ProxyQuery) ([]string, interface{}, error) {
+			queries <- query
+			if query.Category == "FTP" {
+				return nil, nil, errors.New("proxy category not found: FTP")
+			}
+			return []string{"13.37.0.1:1337"}, []entity.Proxy{{Proxy: "13.37.0.1:1337"}}, nil
+		},
+		StreamFunc: func(query entity.ProxyQuery) (interface{}, error) {
+			queries <- query
+			if query.Category == "FTP" {
+				return nil, errors.New("proxy category not found: FTP")
+			}
+			return "stream", nil
+		},
+		RandomFunc: func(query entity.ProxyQuery) ([]string, interface{}, error) {
+			queries <- query
+			if query.MaxLatency > 0 {
+				return nil, nil, usecase.ErrProxyNotFound
+			}
+			return []string{"13.37.0.2:1337"}, []entity.Proxy{{Proxy: "13.37.0.2:1337"}}, nil
+		},
+		StatsFunc: func() entity.ProxyStats {
+			return entity.ProxyStats{Total: 2, Categories: map[string]int{"HTTP": 2}}
+		},
+		EncodeFunc: func(writer io.Writer, classic []string, advanced interface{}, format string) error {
+			_, err := fmt.Fprintf(writer, "%s %v %v", format, classic, advanced)
+			return err
+		},
+	})
+}
+
+func TestNewAPIHandler(t *testing.T) {
+	if NewAPIHandler(&mockQueryUsecase{}) == nil {
+		t.Errorf(expectedReturnNonNil, "NewAPIHandler", "http.Handler")
+	}
+}
+
+func TestAPIHandlerProxies(t *testing.T) {
+	tests := []struct {
+		name            string
+		target          string
+		accept          string
+		wantStatus      int
+		wantQuery       entity.ProxyQuery
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "Filters",
+			target:          "/proxies?category=socks5&max_latency=2&anonymity=Elite&limit=50&format=csv",
+			wantStatus:      http.StatusOK,
+			wantQuery:       entity.ProxyQuery{Category: "SOCKS5", Anonymity: "elite", MaxLatency: 2, Limit: 50},
+			wantContentType: "text/csv; charset=utf-8",
+			wantBody:        "csv [13.37.0.1:1337] [{ 13.37.0.1:1337",
+		},
+		{
+			name:            "DefaultsToJSON",
+			target:          "/proxies",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        "json ",
+		},
+		{
+			name:            "AcceptHeader",
+			target:          "/proxies",
+			accept:          "text/html, application/x-yaml;q=0.9",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/yaml",
+			wantBody:        "yaml ",
+		},
+		{
+			name:            "FormatOverridesAcceptHeader",
+			target:          "/proxies?format=TXT",
+			accept:          "application/xml",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/plain; charset=utf-8",
+			wantBody:        "txt ",
+		},
+		{
+			name:            "Stream",
+			target:          "/proxies?format=jsonl&limit=5",
+			wantStatus:      http.StatusOK,
+			wantQuery:       entity.ProxyQuery{Limit: 5},
+			wantContentType: "application/x-ndjson",
+			wantBody:        "jsonl [] stream",
+		},
+		{
+			name:       "StreamUnknownCategory",
+			target:     "/proxies?category=ftp",
+			accept:     "application/jsonl",
+			wantStatus: http.StatusBadRequest,
+			wantQuery:  entity.ProxyQuery{Category: "FTP"},
+			wantBody:   "proxy category not found: FTP",
+		},
+		{
+			name:            "Random",
+			target:          "/proxies/random?category=http",
+			wantStatus:      http.StatusOK,
+			wantQuery:       entity.ProxyQuery{Category: "HTTP"},
+			wantContentType: "application/json",
+			wantBody:        "json [13.37.0.2:1337]",
+		},
+		{
+			name:       "RandomNotFound",
+			target:     "/proxies/random?max_latency=0.1",
+			wantStatus: http.StatusNotFound,
+			wantQuery:  entity.ProxyQuery{MaxLatency: 0.1},
+			wantBody:   usecase.ErrProxyNotFound.Error(),
+		},
+		{
+			name:       "UnknownCategory",
+			target:     "/proxies?category=ftp",
+			wantStatus: http.StatusBadRequest,
+			wantQuery:  entity.ProxyQuery{Category: "FTP"},
+			wantBody:   "proxy category not found: FTP",
+		},
+		{
+			name:       "InvalidMaxLatency",
+			target:     "/proxies?max_latency=fast",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid max_latency: fast",
+		},
+		{
+			name:       "InvalidLimit",
+			target:     "/proxies?limit=-1",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid limit: -1",
+		},
+		{
+			name:       "UnsupportedFormat",
+			target:     "/proxies?format=html",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "unsupported format: html",
+		},
+		{
+			name:       "MethodNotAllowed",
+			target:     "/proxies",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := make(chan entity.ProxyQuery, 1)
+			method := http.MethodGet
+			if tt.wantStatus == http.StatusMethodNotAllowed {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			newTestAPIHandler(queries).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf(expectedButGotMessage, "StatusCode", tt.wantStatus, rec.Code)
+			}
+			if tt.wantContentType != "" && rec.Header().Get("Content-Type") != tt.wantContentType {
+				t.Errorf(expectedButGotMessage, "Content-Type", tt.wantContentType, rec.Header().Get("Content-Type"))
+			}
+			if !strings.HasPrefix(rec.Body.String(), tt.wantBody) {
+				t.Errorf(expectedButGotMessage, "body", tt.wantBody, rec.Body.String())
+			}
+
+			select {
+			case query := <-queries:
+				if !reflect.DeepEqual(query, tt.wantQuery) {
+					t.Errorf(expectedButGotMessage, "query", tt.wantQuery, query)
+				}
+			default:
+			}
+		})
+	}
+}
+
+func TestAPIHandlerStats(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestAPIHandler(nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf(expectedButGotMessage, "StatusCode", http.StatusOK, rec.Code)
+	}
+
+	got := entity.ProxyStats{}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	want := entity.ProxyStats{Total: 2, Categories: map[string]int{"HTTP": 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "stats", want, got)
+	}
+}
+
+func TestAPIHandlerExcludesMITM(t *testing.T) {
+	categories := []string{"HTTP", "HTTPS"}
+	proxyRepository := repository.NewProxyRepository(categories)
+	proxyRepository.Store(&entity.Proxy{Category: "HTTPS", Proxy: "13.37.0.1:1337", IP: "13.37.0.1", Port: "1337", TimeTaken: 1})
+	proxyRepository.Store(&entity.Proxy{Category: "HTTPS", Proxy: "13.37.0.2:1337", IP: "13.37.0.2", Port: "1337", TimeTaken: 1, IsMITM: true})
+	apiHandler := NewAPIHandler(usecase.NewQueryUsecase(proxyRepository, &repository.FileRepository{}, categories, false, false))
+
+	rec := httptest.NewRecorder()
+	apiHandler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/proxies?format=json", nil))
+	proxies := []entity.AdvancedProxy{}
+	if err := json.NewDecoder(rec.Body).Decode(&proxies); err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	if len(proxies) != 1 || proxies[0].Proxy != "13.37.0.1:1337" {
+		t.Errorf(expectedButGotMessage, "proxies", "[13.37.0.1:1337]", proxies)
+	}
+
+	rec = httptest.NewRecorder()
+	apiHandler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	stats := entity.ProxyStats{}
+	if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	if stats.Total != 1 || stats.Categories["HTTPS"] != 1 {
+		t.Errorf(expectedButGotMessage, "stats", "1 HTTPS proxy", stats)
+	}
+}
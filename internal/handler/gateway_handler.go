@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/usecase"
+)
+
+type GatewayHandler struct {
+	GatewayUsecase usecase.GatewayUsecaseInterface
+	Username       string
+	Password       string
+}
+
+type GatewayHandlerInterface interface {
+	http.Handler
+	ServeSOCKS5(ctx context.Context, listener net.Listener) error
+}
+
+const socksHandshakeTimeout = 30 * time.Second
+
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+func NewGatewayHandler(gatewayUsecase usecase.GatewayUsecaseInterface, username string, password string) GatewayHandlerInterface {
+	return &GatewayHandler{
+		GatewayUsecase: gatewayUsecase,
+		Username:       username,
+		Password:       password,
+	}
+}
+
+func (h *GatewayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r.Header.Get("Proxy-Authorization")) {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="fresh-proxy-list"`)
+		http.Error(w, "proxy authentication required", http.StatusProxyAuthRequired)
+		return
+	}
+
+	session := clientIP(r.RemoteAddr)
+	if r.Method == http.MethodConnect {
+		h.serveConnect(w, r, session)
+		return
+	}
+
+	if !r.URL.IsAbs() {
+		http.Error(w, "requests to the gateway must use an absolute URL", http.StatusBadRequest)
+		return
+	}
+
+	req := r.Clone(r.Context())
+	req.RequestURI = ""
+	for _, header := range hopHeaders {
+		req.Header.Del(header)
+	}
+
+	resp, err := h.GatewayUsecase.RoundTrip(req, session)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	for _, header := range hopHeaders {
+		resp.Header.Del(header)
+	}
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+func (h *GatewayHandler) serveConnect(w http.ResponseWriter, r *http.Request, session string) {
+	upstreamConn, err := h.GatewayUsecase.Dial(r.Context(), "tcp", r.Host, session)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstreamConn.Close()
+		http.Error(w, "connection cannot be hijacked", http.StatusInternalServerError)
+		return
+	}
+	clientConn, buffer, err := hijacker.Hijack()
+	if err != nil {
+		upstreamConn.Close()
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := clientConn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		clientConn.Close()
+		upstreamConn.Close()
+		return
+	}
+	// Clients may send the first bytes of the tunnel along with the CONNECT request
+	if n := buffer.Reader.Buffered(); n > 0 {
+		data, _ := buffer.Reader.Peek(n)
+		upstreamConn.Write(data)
+	}
+	pipe(clientConn, upstreamConn)
+}
+
+// ServeSOCKS5 accepts SOCKS5 clients until the listener is closed, upstream dials are canceled along with ctx
+func (h *GatewayHandler) ServeSOCKS5(ctx context.Context, listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		go h.serveSOCKS5Conn(ctx, conn)
+	}
+}
+
+func (h *GatewayHandler) serveSOCKS5Conn(ctx context.Context, conn net.Conn) {
+	reply := func(code byte) {
+		conn.Write([]byte{5, code, 0, 1, 0, 0, 0, 0, 0, 0})
+	}
+
+	conn.SetDeadline(time.Now().Add(socksHandshakeTimeout))
+	addr, err := h.readSOCKS5Request(conn)
+	if err != nil {
+		conn.Close()
+		return
+	} else if addr == "" {
+		reply(7) // command not supported
+		conn.Close()
+		return
+	}
+
+	upstreamConn, err := h.GatewayUsecase.Dial(ctx, "tcp", addr, clientIP(conn.RemoteAddr().String()))
+	if err != nil {
+		reply(1) // general failure
+		conn.Close()
+		return
+	}
+
+	reply(0)
+	conn.SetDeadline(time.Time{})
+	pipe(conn, upstreamConn)
+}
+
+// readSOCKS5Request negotiates the authentication method and returns the CONNECT target, or "" for other commands
+func (h *GatewayHandler) readSOCKS5Request(conn net.Conn) (string, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	} else if header[0] != 5 {
+		return "", errors.New("unsupported SOCKS version")
+	}
+
+	// Username/password authentication (RFC 1929) is required once the gateway has credentials
+	method := byte(0)
+	if h.Username != "" {
+		method = 2
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", err
+	} else if !slices.Contains(methods, method) {
+		conn.Write([]byte{5, 0xff})
+		return "", errors.New("no acceptable SOCKS authentication method")
+	}
+	if _, err := conn.Write([]byte{5, method}); err != nil {
+		return "", err
+	}
+	if method == 2 {
+		if err := h.readSOCKS5Credentials(conn); err != nil {
+			return "", err
+		}
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return "", err
+	}
+
+	var host string
+	switch request[3] {
+	case 1, 4:
+		ip := make([]byte, net.IPv4len)
+		if request[3] == 4 {
+			ip = make([]byte, net.IPv6len)
+		}
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case 3:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return "", err
+		}
+		name := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return "", err
+		}
+		host = string(name)
+	default:
+		return "", errors.New("unsupported SOCKS address type")
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+
+	if request[1] != 1 {
+		return "", nil
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+func (h *GatewayHandler) readSOCKS5Credentials(conn net.Conn) error {
+	version := make([]byte, 2)
+	if _, err := io.ReadFull(conn, version); err != nil {
+		return err
+	}
+	username := make([]byte, version[1])
+	if _, err := io.ReadFull(conn, username); err != nil {
+		return err
+	}
+	length := make([]byte, 1)
+	if _, err := io.ReadFull(conn, length); err != nil {
+		return err
+	}
+	password := make([]byte, length[0])
+	if _, err := io.ReadFull(conn, password); err != nil {
+		return err
+	}
+
+	if version[0] != 1 || !h.matches(string(username), string(password)) {
+		conn.Write([]byte{1, 1})
+		return errors.New("invalid SOCKS credentials")
+	}
+	_, err := conn.Write([]byte{1, 0})
+	return err
+}
+
+func (h *GatewayHandler) authorized(header string) bool {
+	if h.Username == "" {
+		return true
+	}
+
+	encoded, found := strings.CutPrefix(header, "Basic ")
+	if !found {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false
+	}
+	username, password, _ := strings.Cut(string(decoded), ":")
+	return h.matches(username, password)
+}
+
+func (h *GatewayHandler) matches(username string, password string) bool {
+	usernameMatch := subtle.ConstantTimeCompare([]byte(username), []byte(h.Username))
+	passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(h.Password))
+	return usernameMatch&passwordMatch == 1
+}
+
+func pipe(a net.Conn, b net.Conn) {
+	defer a.Close()
+	defer b.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(a, b)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(b, a)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+func clientIP(remoteAddr string) string {
+	ip, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return ip
+}
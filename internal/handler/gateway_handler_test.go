@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"h12.io/socks"
+)
+
+func serveEcho(t *testing.T) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener
+}
+
+func dialDirect(sessions chan<- string) *mockGatewayUsecase {
+	return &mockGatewayUsecase{
+		DialFunc: func(ctx context.Context, network string, addr string, session string) (net.Conn, error) {
+			sessions <- session
+			return net.Dial(network, addr)
+		},
+		RoundTripFunc: func(req *http.Request, session string) (*http.Response, error) {
+			sessions <- session
+			return (&http.Transport{DisableKeepAlives: true}).RoundTrip(req)
+		},
+	}
+}
+
+func TestNewGatewayHandler(t *testing.T) {
+	if NewGatewayHandler(&mockGatewayUsecase{}, "", "") == nil {
+		t.Errorf(expectedReturnNonNil, "NewGatewayHandler", "GatewayHandlerInterface")
+	}
+}
+
+func TestGatewayHandlerConnect(t *testing.T) {
+	echo := serveEcho(t)
+	sessions := make(chan string, 1)
+	server := httptest.NewServer(NewGatewayHandler(dialDirect(sessions), "", ""))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("CONNECT " + echo.Addr().String() + " HTTP/1.1\r\nHost: " + echo.Addr().String() + "\r\n\r\nping"))
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf(expectedButGotMessage, "StatusCode", http.StatusOK, resp.StatusCode)
+	}
+
+	got := make([]byte, 4)
+	if _, err := io.ReadFull(reader, got); err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	if string(got) != "ping" {
+		t.Errorf(expectedButGotMessage, "tunnel echo", "ping", string(got))
+	}
+	if session := <-sessions; session != "127.0.0.1" {
+		t.Errorf(expectedButGotMessage, "session", "127.0.0.1", session)
+	}
+}
+
+func TestGatewayHandlerForward(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "" {
+			t.Errorf(expectedButGotMessage, "Proxy-Authorization", "", r.Header.Get("Proxy-Authorization"))
+		}
+		w.Header().Set("X-Target", "ok")
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer target.Close()
+
+	sessions := make(chan string, 1)
+	server := httptest.NewServer(NewGatewayHandler(dialDirect(sessions), "", ""))
+	defer server.Close()
+
+	proxyURL, _ := url.Parse(server.URL)
+	proxyURL.User = url.UserPassword("user", "pass")
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+	resp, err := client.Get(target.URL + "/path")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "/path" || resp.Header.Get("X-Target") != "ok" {
+		t.Errorf(expectedButGotMessage, "response", "/path", string(body))
+	}
+}
+
+func TestGatewayHandlerErrors(t *testing.T) {
+	h := NewGatewayHandler(&mockGatewayUsecase{
+		DialFunc: func(ctx context.Context, network string, addr string, session string) (net.Conn, error) {
+			return nil, errors.New("no upstream available")
+		},
+		RoundTripFunc: func(req *http.Request, session string) (*http.Response, error) {
+			return nil, errors.New("no upstream available")
+		},
+	}, "", "")
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{
+			name:   "ConnectDialError",
+			method: http.MethodConnect,
+			target: "example.com:443",
+			want:   http.StatusBadGateway,
+		},
+		{
+			name:   "ForwardDialError",
+			method: http.MethodGet,
+			target: "http://example.com/",
+			want:   http.StatusBadGateway,
+		},
+		{
+			name:   "RelativeURL",
+			method: http.MethodGet,
+			target: "/",
+			want:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.method == http.MethodConnect {
+				req.URL = &url.URL{Host: tt.target}
+				req.Host = tt.target
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf(expectedButGotMessage, "StatusCode", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestGatewayHandlerSOCKS5(t *testing.T) {
+	echo := serveEcho(t)
+	sessions := make(chan string, 1)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+
+	h := NewGatewayHandler(dialDirect(sessions), "", "")
+	served := make(chan error, 1)
+	go func() {
+		served <- h.ServeSOCKS5(context.Background(), listener)
+	}()
+
+	conn, err := socks.Dial("socks5://"+listener.Addr().String())("tcp", echo.Addr().String())
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("ping"))
+	got := make([]byte, 4)
+	if _, err := io.ReadFull(conn, got); err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	if string(got) != "ping" {
+		t.Errorf(expectedButGotMessage, "tunnel echo", "ping", string(got))
+	}
+	if session := <-sessions; session != "127.0.0.1" {
+		t.Errorf(expectedButGotMessage, "session", "127.0.0.1", session)
+	}
+
+	listener.Close()
+	if err := <-served; err != nil {
+		t.Errorf(expectedErrorButGotMessage, "ServeSOCKS5()", nil, err)
+	}
+}
+
+func TestGatewayHandlerSOCKS5Canceled(t *testing.T) {
+	dialing := make(chan struct{})
+	h := NewGatewayHandler(&mockGatewayUsecase{
+		DialFunc: func(ctx context.Context, network string, addr string, session string) (net.Conn, error) {
+			close(dialing)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}, "", "")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	defer listener.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	go h.ServeSOCKS5(ctx, listener)
+
+	go func() {
+		<-dialing
+		cancel()
+	}()
+	if conn, err := socks.Dial("socks5://"+listener.Addr().String())("tcp", "example.com:80"); err == nil {
+		conn.Close()
+		t.Errorf(expectedErrorButGotMessage, "socks.Dial()", "general failure", err)
+	}
+}
+
+func TestGatewayHandlerAuth(t *testing.T) {
+	echo := serveEcho(t)
+	sessions := make(chan string, 2)
+	h := NewGatewayHandler(dialDirect(sessions), "user", "pass")
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{
+			name:          "Missing",
+			authorization: "",
+			want:          http.StatusProxyAuthRequired,
+		},
+		{
+			name:          "WrongPassword",
+			authorization: "Basic dXNlcjp3cm9uZw==",
+			want:          http.StatusProxyAuthRequired,
+		},
+		{
+			name:          "Valid",
+			authorization: "Basic dXNlcjpwYXNz",
+			want:          http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Proxy-Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf(expectedButGotMessage, "StatusCode", tt.want, rec.Code)
+			}
+		})
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	defer listener.Close()
+	go h.ServeSOCKS5(context.Background(), listener)
+
+	if conn, err := socks.Dial("socks5://"+listener.Addr().String())("tcp", echo.Addr().String()); err == nil {
+		conn.Close()
+		t.Errorf(expectedErrorButGotMessage, "socks.Dial()", "no acceptable SOCKS authentication method", err)
+	}
+	if conn, err := socks.Dial("socks5://user:wrong@"+listener.Addr().String())("tcp", echo.Addr().String()); err == nil {
+		conn.Close()
+		t.Errorf(expectedErrorButGotMessage, "socks.Dial()", "invalid SOCKS credentials", err)
+	}
+	conn, err := socks.Dial("socks5://user:pass@"+listener.Addr().String())("tcp", echo.Addr().String())
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+var (
+	unexpectedMessage          = "Unexpected %v: %v"
+	expectedButGotMessage      = "Expected %v = %v, but got = %v"
+	expectedErrorButGotMessage = "Expected %v error = %v, but got = %v"
+	expectedReturnNonNil       = "Expected %v to return a non-nil %v"
+)
+
+type mockGatewayUsecase struct {
+	LoadFunc      func(categories []string) int
+	PickFunc      func(session string) (*entity.Upstream, error)
+	EvictFunc     func(proxy string)
+	DialFunc      func(ctx context.Context, network string, addr string, session string) (net.Conn, error)
+	RoundTripFunc func(req *http.Request, session string) (*http.Response, error)
+	RecheckFunc   func(ctx context.Context) int
+}
+
+func (m *mockGatewayUsecase) Load(categories []string) int {
+	if m.LoadFunc != nil {
+		return m.LoadFunc(categories)
+	}
+	return 0
+}
+
+func (m *mockGatewayUsecase) Pick(session string) (*entity.Upstream, error) {
+	if m.PickFunc != nil {
+		return m.PickFunc(session)
+	}
+	return nil, nil
+}
+
+func (m *mockGatewayUsecase) Evict(proxy string) {
+	if m.EvictFunc != nil {
+		m.EvictFunc(proxy)
+	}
+}
+
+func (m *mockGatewayUsecase) Dial(ctx context.Context, network string, addr string, session string) (net.Conn, error) {
+	if m.DialFunc != nil {
+		return m.DialFunc(ctx, network, addr, session)
+	}
+	return nil, nil
+}
+
+func (m *mockGatewayUsecase) RoundTrip(req *http.Request, session string) (*http.Response, error) {
+	if m.RoundTripFunc != nil {
+		return m.RoundTripFunc(req, session)
+	}
+	return nil, nil
+}
+
+func (m *mockGatewayUsecase) Recheck(ctx context.Context) int {
+	if m.RecheckFunc != nil {
+		return m.RecheckFunc(ctx)
+	}
+	return 0
+}
+
+type mockQueryUsecase struct {
+	QueryFunc  func(query entity.ProxyQuery) ([]string, interface{}, error)
+	StreamFunc func(query entity.ProxyQuery) (interface{}, error)
+	RandomFunc func(query entity.ProxyQuery) ([]string, interface{}, error)
+	StatsFunc  func() entity.ProxyStats
+	EncodeFunc func(writer io.Writer, classic []string, advanced interface{}, format string) error
+}
+
+func (m *mockQueryUsecase) Query(query entity.ProxyQuery) ([]string, interface{}, error) {
+	if m.QueryFunc != nil {
+		return m.QueryFunc(query)
+	}
+	return nil, nil, nil
+}
+
+func (m *mockQueryUsecase) Stream(query entity.ProxyQuery) (interface{}, error) {
+	if m.StreamFunc != nil {
+		return m.StreamFunc(query)
+	}
+	return nil, nil
+}
+
+func (m *mockQueryUsecase) Random(query entity.ProxyQuery) ([]string, interface{}, error) {
+	if m.RandomFunc != nil {
+		return m.RandomFunc(query)
+	}
+	return nil, nil, nil
+}
+
+func (m *mockQueryUsecase) Stats() entity.ProxyStats {
+	if m.StatsFunc != nil {
+		return m.StatsFunc()
+	}
+	return entity.ProxyStats{}
+}
+
+func (m *mockQueryUsecase) Encode(writer io.Writer, classic []string, advanced interface{}, format string) error {
+	if m.EncodeFunc != nil {
+		return m.EncodeFunc(writer, classic, advanced, format)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+type JudgeHandler struct{}
+
+func NewJudgeHandler() http.Handler {
+	return &JudgeHandler{}
+}
+
+func (h *JudgeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(entity.JudgeResponse{
+		IP:      clientIP(r.RemoteAddr),
+		Headers: r.Header,
+	})
+}
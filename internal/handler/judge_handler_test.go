@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+func TestNewJudgeHandler(t *testing.T) {
+	if NewJudgeHandler() == nil {
+		t.Errorf(expectedReturnNonNil, "NewJudgeHandler", "http.Handler")
+	}
+}
+
+func TestJudgeHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       entity.JudgeResponse
+	}{
+		{
+			name:       "IPv4",
+			remoteAddr: "13.37.0.1:54321",
+			headers: map[string]string{
+				"Via": "1.1 proxy",
+			},
+			want: entity.JudgeResponse{
+				IP: "13.37.0.1",
+				Headers: map[string][]string{
+					"Via": {"1.1 proxy"},
+				},
+			},
+		},
+		{
+			name:       "IPv6",
+			remoteAddr: "[2606:4700::1111]:54321",
+			headers:    map[string]string{},
+			want: entity.JudgeResponse{
+				IP:      "2606:4700::1111",
+				Headers: map[string][]string{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			recorder := httptest.NewRecorder()
+			NewJudgeHandler().ServeHTTP(recorder, req)
+
+			if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf(expectedButGotMessage, "Content-Type", "application/json", contentType)
+			}
+
+			got := entity.JudgeResponse{}
+			if err := json.NewDecoder(recorder.Body).Decode(&got); err != nil {
+				t.Errorf(expectedErrorButGotMessage, "decode", nil, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "JudgeHandler", tt.want, got)
+			}
+		})
+	}
+}
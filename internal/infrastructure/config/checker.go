@@ -0,0 +1,8 @@
+package config
+
+import "time"
+
+var (
+	CheckerConcurrency = 500
+	CheckerTimeout     = 60 * time.Second
+)
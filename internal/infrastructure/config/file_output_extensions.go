@@ -3,6 +3,17 @@ package config
 var FileOutputExtensions = []string{
 	"csv",
 	"json",
+	"jsonl",
 	"xml",
 	"yaml",
+	"pac",
+	"clash.yaml",
+	"proxychains.conf",
+	"sing-box.json",
+}
+
+// Compressed variants written next to every output
+var FileCompressions = []string{
+	"gz",
+	"zst",
 }
@@ -0,0 +1,18 @@
+package config
+
+var GatewayStrategies = []string{
+	"round-robin",
+	"random",
+	"least-latency",
+	"sticky",
+}
+
+// Categories able to tunnel a connection, most capable first
+var GatewayCategories = []string{
+	"SOCKS5H",
+	"SOCKS5",
+	"SOCKS4A",
+	"SOCKS4",
+	"HTTPS",
+	"HTTP",
+}
@@ -0,0 +1,21 @@
+package config
+
+var AnonymityLevels = []string{
+	"anonymous",
+	"elite",
+	"transparent",
+}
+
+var ProxyHeaders = []string{
+	"Client-Ip",
+	"Forwarded",
+	"Forwarded-For",
+	"Proxy-Connection",
+	"True-Client-Ip",
+	"Via",
+	"X-Client-Ip",
+	"X-Forwarded",
+	"X-Forwarded-For",
+	"X-Proxy-Id",
+	"X-Real-Ip",
+}
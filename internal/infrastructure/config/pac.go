@@ -0,0 +1,4 @@
+package config
+
+// Number of the fastest proxies that a PAC file fails over between
+var PACMax = 10
@@ -4,5 +4,7 @@ var ProxyCategories = []string{
 	"HTTP",
 	"HTTPS",
 	"SOCKS4",
+	"SOCKS4A",
 	"SOCKS5",
+	"SOCKS5H",
 }
@@ -0,0 +1,4 @@
+package config
+
+// Number of sources downloaded at the same time
+var SourceConcurrency = 10
@@ -4,4 +4,6 @@ var SpecialIPs = []string{
 	"0.0.0.0",
 	"127.0.0.1",
 	"255.255.255.255",
+	"::",
+	"::1",
 }
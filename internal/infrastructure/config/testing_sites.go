@@ -1,18 +1,23 @@
 package config
 
-var HTTPTestingSites = []string{
-	"http://ifconfig.me/ip",
-	"http://api.ipaddress.com/myip",
-	"http://checkip.amazonaws.com",
+import "github.com/fyvri/fresh-proxy-list/internal/entity"
+
+// IP echo sites must answer with a bare IP address, anything else is a captive portal or injected page
+const ipEchoRegex = `^\s*[0-9A-Fa-f.:]+\s*$`
+
+var HTTPTestingSites = []entity.TestingSite{
+	{URL: "http://ifconfig.me/ip", Regex: ipEchoRegex},
+	{URL: "http://api.ipaddress.com/myip", Regex: ipEchoRegex},
+	{URL: "http://checkip.amazonaws.com", Regex: ipEchoRegex},
 }
 
-var HTTPSTestingSites = []string{
-	"https://ifconfig.me/ip",
-	"https://api.ipaddress.com/myip",
-	"https://checkip.amazonaws.com",
-	"https://google.com",
-	"https://bing.com",
-	"https://yahoo.com",
-	"https://api.ipify.org",
-	"https://ipinfo.io/ip",
+var HTTPSTestingSites = []entity.TestingSite{
+	{URL: "https://ifconfig.me/ip", Regex: ipEchoRegex},
+	{URL: "https://api.ipaddress.com/myip", Regex: ipEchoRegex},
+	{URL: "https://checkip.amazonaws.com", Regex: ipEchoRegex},
+	{URL: "https://google.com"},
+	{URL: "https://bing.com"},
+	{URL: "https://yahoo.com"},
+	{URL: "https://api.ipify.org", Regex: ipEchoRegex},
+	{URL: "https://ipinfo.io/ip", Regex: ipEchoRegex},
 }
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+
+	"gopkg.in/yaml.v3"
+)
+
+type ConfigRepository struct {
+	Path     string
+	Defaults entity.Config
+	ReadFile func(name string) ([]byte, error)
+	Getenv   func(key string) string
+}
+
+type ConfigRepositoryInterface interface {
+	LoadConfig() (*entity.Config, error)
+}
+
+type GetenvFunc func(key string) string
+
+func NewConfigRepository(path string, defaults entity.Config, readFile ReadFileFunc, getenv GetenvFunc) ConfigRepositoryInterface {
+	return &ConfigRepository{
+		Path:     path,
+		Defaults: defaults,
+		ReadFile: readFile,
+		Getenv:   getenv,
+	}
+}
+
+func (r *ConfigRepository) LoadConfig() (*entity.Config, error) {
+	config := r.Defaults
+	if r.Path != "" {
+		data, err := r.ReadFile(r.Path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading config %s: %v", r.Path, err)
+		}
+
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("error parsing config %s: %v", r.Path, err)
+		}
+	}
+
+	if value := r.Getenv("CHECKER_CONCURRENCY"); value != "" {
+		concurrency, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing CHECKER_CONCURRENCY: %v", err)
+		}
+		config.Checker.Concurrency = concurrency
+	}
+
+	if value := r.Getenv("CHECKER_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing CHECKER_TIMEOUT: %v", err)
+		}
+		config.Checker.Timeout = timeout
+	}
+
+	if value := r.Getenv("HTTP_TESTING_SITES"); value != "" {
+		config.Checker.HTTPTestingSites = entity.NewTestingSites(splitList(value))
+	}
+
+	if value := r.Getenv("HTTPS_TESTING_SITES"); value != "" {
+		config.Checker.HTTPSTestingSites = entity.NewTestingSites(splitList(value))
+	}
+
+	if value := r.Getenv("CHECKER_JUDGE_URL"); value != "" {
+		config.Checker.JudgeURL = value
+	}
+
+	if value := r.Getenv("CHECKER_VERIFY_TLS"); value != "" {
+		verifyTLS, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing CHECKER_VERIFY_TLS: %v", err)
+		}
+		config.Checker.VerifyTLS = verifyTLS
+	}
+
+	if value := r.Getenv("CHECKER_CONNECT_TARGETS"); value != "" {
+		config.Checker.ConnectTargets = splitList(value)
+	}
+
+	if value := r.Getenv("PAC_INCLUDE"); value != "" {
+		config.PAC.Include = splitList(value)
+	}
+
+	if value := r.Getenv("PAC_EXCLUDE"); value != "" {
+		config.PAC.Exclude = splitList(value)
+	}
+
+	if value := r.Getenv("PAC_MAX"); value != "" {
+		max, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing PAC_MAX: %v", err)
+		}
+		config.PAC.Max = max
+	}
+
+	return &config, nil
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
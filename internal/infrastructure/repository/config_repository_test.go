@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+var (
+	testDefaultConfig = entity.Config{
+		Checker: entity.CheckerConfig{
+			Concurrency:       500,
+			Timeout:           60 * time.Second,
+			HTTPTestingSites:  []entity.TestingSite{{URL: "http://example.com/ip"}},
+			HTTPSTestingSites: []entity.TestingSite{{URL: "https://example.com/ip"}},
+		},
+	}
+)
+
+func TestNewConfigRepository(t *testing.T) {
+	configRepository := NewConfigRepository("config.yaml", testDefaultConfig, nil, nil)
+	if configRepository == nil {
+		t.Errorf(expectedReturnNonNil, "NewConfigRepository", "ConfigRepositoryInterface")
+	}
+
+	r, ok := configRepository.(*ConfigRepository)
+	if !ok {
+		t.Errorf(expectedTypeAssertionErrorMessage, "*ConfigRepository")
+	}
+
+	if !reflect.DeepEqual(r.Defaults, testDefaultConfig) {
+		t.Errorf(expectedButGotMessage, "Defaults", testDefaultConfig, r.Defaults)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		file      string
+		env       map[string]string
+		want      *entity.Config
+		wantError error
+	}{
+		{
+			name:      "Defaults",
+			want:      &testDefaultConfig,
+			wantError: nil,
+		},
+		{
+			name: "File",
+			path: "config.yaml",
+			file: "# small CI runners\nchecker:\n  concurrency: 100\n  timeout: 10s\n  http_testing_sites:\n    - http://judge.example.com\n    - url: http://ping.example.com\n      body: pong\n",
+			want: &entity.Config{
+				Checker: entity.CheckerConfig{
+					Concurrency:       100,
+					Timeout:           10 * time.Second,
+					HTTPTestingSites:  []entity.TestingSite{{URL: "http://judge.example.com"}, {URL: "http://ping.example.com", Body: "pong"}},
+					HTTPSTestingSites: testDefaultConfig.Checker.HTTPSTestingSites,
+				},
+			},
+			wantError: nil,
+		},
+		{
+			name: "EnvironmentOverridesFile",
+			path: "config.yaml",
+			file: "checker:\n  concurrency: 100\n",
+			env: map[string]string{
+				"CHECKER_CONCURRENCY":     "200",
+				"CHECKER_TIMEOUT":         "15s",
+				"HTTPS_TESTING_SITES":     "https://a.example.com, https://b.example.com",
+				"CHECKER_JUDGE_URL":       "http://judge.example.com",
+				"CHECKER_VERIFY_TLS":      "true",
+				"CHECKER_CONNECT_TARGETS": "example.com:443, example.com:22",
+				"PAC_INCLUDE":             "example.com, *.example.org",
+				"PAC_EXCLUDE":             "localhost",
+				"PAC_MAX":                 "5",
+			},
+			want: &entity.Config{
+				Checker: entity.CheckerConfig{
+					Concurrency:       200,
+					Timeout:           15 * time.Second,
+					HTTPTestingSites:  testDefaultConfig.Checker.HTTPTestingSites,
+					HTTPSTestingSites: []entity.TestingSite{{URL: "https://a.example.com"}, {URL: "https://b.example.com"}},
+					JudgeURL:          "http://judge.example.com",
+					VerifyTLS:         true,
+					ConnectTargets:    []string{"example.com:443", "example.com:22"},
+				},
+				PAC: entity.PACConfig{
+					Include: []string{"example.com", "*.example.org"},
+					Exclude: []string{"localhost"},
+					Max:     5,
+				},
+			},
+			wantError: nil,
+		},
+		{
+			name:      "ReadFileError",
+			path:      "missing.yaml",
+			want:      nil,
+			wantError: errors.New("error reading config missing.yaml: file does not exist"),
+		},
+		{
+			name:      "ParseFileError",
+			path:      "config.yaml",
+			file:      "checker:\n  timeout: soon\n",
+			want:      nil,
+			wantError: errors.New("error parsing config config.yaml: yaml: unmarshal errors:\n  line 2: cannot unmarshal !!str `soon` into time.Duration"),
+		},
+		{
+			name: "InvalidConcurrency",
+			env: map[string]string{
+				"CHECKER_CONCURRENCY": "many",
+			},
+			want:      nil,
+			wantError: errors.New("error parsing CHECKER_CONCURRENCY: strconv.Atoi: parsing \"many\": invalid syntax"),
+		},
+		{
+			name: "InvalidTimeout",
+			env: map[string]string{
+				"CHECKER_TIMEOUT": "soon",
+			},
+			want:      nil,
+			wantError: errors.New("error parsing CHECKER_TIMEOUT: time: invalid duration \"soon\""),
+		},
+		{
+			name: "InvalidVerifyTLS",
+			env: map[string]string{
+				"CHECKER_VERIFY_TLS": "maybe",
+			},
+			want:      nil,
+			wantError: errors.New("error parsing CHECKER_VERIFY_TLS: strconv.ParseBool: parsing \"maybe\": invalid syntax"),
+		},
+		{
+			name: "InvalidPACMax",
+			env: map[string]string{
+				"PAC_MAX": "few",
+			},
+			want:      nil,
+			wantError: errors.New("error parsing PAC_MAX: strconv.Atoi: parsing \"few\": invalid syntax"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &ConfigRepository{
+				Path:     tt.path,
+				Defaults: testDefaultConfig,
+				ReadFile: func(name string) ([]byte, error) {
+					if tt.file == "" {
+						return nil, errors.New("file does not exist")
+					}
+					return []byte(tt.file), nil
+				},
+				Getenv: func(key string) string {
+					return tt.env[key]
+				},
+			}
+			got, err := r.LoadConfig()
+
+			if (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) ||
+				(err == nil && tt.wantError != nil) ||
+				(err != nil && tt.wantError == nil) {
+				t.Errorf(expectedErrorButGotMessage, "LoadConfig()", tt.wantError, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "LoadConfig()", tt.want, got)
+			}
+		})
+	}
+}
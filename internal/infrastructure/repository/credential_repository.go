@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+type CredentialRepository struct {
+	Path        string
+	Mutex       sync.RWMutex
+	Credentials map[string]entity.Credential
+	ReadFile    func(name string) ([]byte, error)
+	WriteFile   func(name string, data []byte) error
+}
+
+type CredentialRepositoryInterface interface {
+	Load() error
+	Restore(proxy *entity.AdvancedProxy)
+	Save(proxies []entity.AdvancedProxy) error
+}
+
+func NewCredentialRepository(path string, readFile ReadFileFunc, writeFile WriteFileFunc) CredentialRepositoryInterface {
+	return &CredentialRepository{
+		Path:        path,
+		Mutex:       sync.RWMutex{},
+		Credentials: map[string]entity.Credential{},
+		ReadFile:    readFile,
+		WriteFile:   writeFile,
+	}
+}
+
+func (r *CredentialRepository) Load() error {
+	data, err := r.ReadFile(r.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error reading credentials %s: %v", r.Path, err)
+	}
+
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	if err := json.Unmarshal(data, &r.Credentials); err != nil {
+		return fmt.Errorf("error parsing credentials %s: %v", r.Path, err)
+	}
+	return nil
+}
+
+// Restore fills in the credentials of a proxy read back from a redacted output
+func (r *CredentialRepository) Restore(proxy *entity.AdvancedProxy) {
+	if proxy.Username != "" {
+		return
+	}
+
+	r.Mutex.RLock()
+	defer r.Mutex.RUnlock()
+
+	if credential, found := r.Credentials[proxy.Proxy]; found {
+		proxy.Username, proxy.Password = credential.Username, credential.Password
+	}
+}
+
+// Save replaces the stored credentials with those of the given proxies, nothing is written while there are none to keep
+func (r *CredentialRepository) Save(proxies []entity.AdvancedProxy) error {
+	credentials := map[string]entity.Credential{}
+	for _, proxy := range proxies {
+		if proxy.Username != "" {
+			credentials[proxy.Proxy] = entity.Credential{Username: proxy.Username, Password: proxy.Password}
+		}
+	}
+
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	if len(credentials) == 0 && len(r.Credentials) == 0 {
+		return nil
+	}
+	r.Credentials = credentials
+
+	data, err := json.MarshalIndent(r.Credentials, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding credentials: %v", err)
+	}
+
+	if err := r.WriteFile(r.Path, data); err != nil {
+		return fmt.Errorf("error writing credentials %s: %v", r.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+var testCredentialsPath = ".state/credentials.json"
+
+func TestNewCredentialRepository(t *testing.T) {
+	credentialRepository := NewCredentialRepository(testCredentialsPath, nil, nil)
+	if credentialRepository == nil {
+		t.Errorf(expectedReturnNonNil, "NewCredentialRepository", "CredentialRepositoryInterface")
+	}
+
+	r, ok := credentialRepository.(*CredentialRepository)
+	if !ok {
+		t.Errorf(expectedTypeAssertionErrorMessage, "*CredentialRepository")
+	}
+
+	if r.Path != testCredentialsPath {
+		t.Errorf(expectedButGotMessage, "Path", testCredentialsPath, r.Path)
+	}
+}
+
+func TestCredentialRepository(t *testing.T) {
+	var (
+		written []byte
+		writes  int
+	)
+	writeFile := func(name string, data []byte) error {
+		written = data
+		writes++
+		return nil
+	}
+	readFile := func(name string) ([]byte, error) {
+		if written == nil {
+			return nil, fs.ErrNotExist
+		}
+		return written, nil
+	}
+
+	r := NewCredentialRepository(testCredentialsPath, readFile, writeFile)
+	if err := r.Load(); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "Load()", nil, err)
+	}
+
+	if err := r.Save([]entity.AdvancedProxy{{Proxy: "127.0.0.1:8080"}}); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "Save()", nil, err)
+	}
+	if writes != 0 {
+		t.Errorf(expectedButGotMessage, "writes", 0, writes)
+	}
+
+	if err := r.Save([]entity.AdvancedProxy{{Proxy: "127.0.0.1:8080", Username: "user", Password: "pass"}, {Proxy: "127.0.0.1:8081"}}); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "Save()", nil, err)
+	}
+
+	r = NewCredentialRepository(testCredentialsPath, readFile, writeFile)
+	if err := r.Load(); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "Load()", nil, err)
+	}
+
+	proxy := entity.AdvancedProxy{Proxy: "127.0.0.1:8080"}
+	r.Restore(&proxy)
+	if proxy.Username != "user" || proxy.Password != "pass" {
+		t.Errorf(expectedButGotMessage, "Restore()", "user:pass", proxy.Username+":"+proxy.Password)
+	}
+
+	proxy = entity.AdvancedProxy{Proxy: "127.0.0.1:8080", Username: "other", Password: "secret"}
+	r.Restore(&proxy)
+	if proxy.Username != "other" || proxy.Password != "secret" {
+		t.Errorf(expectedButGotMessage, "Restore()", "other:secret", proxy.Username+":"+proxy.Password)
+	}
+
+	proxy = entity.AdvancedProxy{Proxy: "127.0.0.1:8081"}
+	r.Restore(&proxy)
+	if proxy.Username != "" {
+		t.Errorf(expectedButGotMessage, "Restore()", "", proxy.Username)
+	}
+
+	// Credentials of proxies that left the pool are dropped on the next save
+	if err := r.Save([]entity.AdvancedProxy{{Proxy: "127.0.0.1:8081"}}); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "Save()", nil, err)
+	}
+	if writes != 2 || string(written) != "{}" {
+		t.Errorf(expectedButGotMessage, "written", "{}", string(written))
+	}
+}
+
+func TestCredentialRepositoryErrors(t *testing.T) {
+	readFile := func(name string) ([]byte, error) {
+		return nil, errors.New("permission denied")
+	}
+	if err := NewCredentialRepository(testCredentialsPath, readFile, nil).Load(); err == nil {
+		t.Errorf(expectedErrorButGotMessage, "Load()", "error reading credentials", err)
+	}
+
+	readFile = func(name string) ([]byte, error) {
+		return []byte("{"), nil
+	}
+	if err := NewCredentialRepository(testCredentialsPath, readFile, nil).Load(); err == nil {
+		t.Errorf(expectedErrorButGotMessage, "Load()", "error parsing credentials", err)
+	}
+
+	writeFile := func(name string, data []byte) error {
+		return errors.New("disk full")
+	}
+	if err := NewCredentialRepository(testCredentialsPath, nil, writeFile).Save([]entity.AdvancedProxy{{Proxy: "127.0.0.1:8080", Username: "user"}}); err == nil {
+		t.Errorf(expectedErrorButGotMessage, "Save()", "error writing credentials", err)
+	}
+}
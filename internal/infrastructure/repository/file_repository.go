@@ -1,58 +1,123 @@
 package repository
 
 import (
+	"cmp"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"io/fs"
+	"iter"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/fyvri/fresh-proxy-list/internal/entity"
 	"github.com/fyvri/fresh-proxy-list/pkg/utils"
 
+	"github.com/klauspost/compress/zstd"
 	"gopkg.in/yaml.v3"
 )
 
 type FileRepository struct {
 	MkdirAll  func(path string, perm os.FileMode) error
 	Create    func(name string) (io.Writer, error)
+	Open      func(name string) (io.Reader, error)
+	Rename    func(oldPath string, newPath string) error
+	Remove    func(name string) error
 	CSVWriter utils.CSVWriterUtilInterface
+	PAC       entity.PACConfig
 }
 
 type FileRepositoryInterface interface {
 	SaveFile(filePath string, data interface{}, format string) error
+	LoadFile(filePath string, data interface{}, format string) error
 	CreateDirectory(filePath string) error
+	Checksum(filePath string) (int64, string, error)
+	Encode(writer io.Writer, data interface{}, format string) error
 	WriteTxt(writer io.Writer, data interface{}) error
 	EncodeCSV(writer io.Writer, data interface{}) error
 	WriteCSV(writer io.Writer, header []string, rows [][]string) error
 	EncodeJSON(writer io.Writer, data interface{}) error
+	EncodeJSONL(writer io.Writer, data interface{}) error
 	EncodeXML(writer io.Writer, data interface{}) error
 	EncodeYAML(writer io.Writer, data interface{}) error
+	EncodePAC(writer io.Writer, data interface{}) error
+	EncodeClash(writer io.Writer, data interface{}) error
+	EncodeProxychains(writer io.Writer, data interface{}) error
+	EncodeSingBox(writer io.Writer, data interface{}) error
+	EncodeGzip(writer io.Writer, data interface{}, format string) error
+	EncodeZstd(writer io.Writer, data interface{}, format string) error
 }
 
 type MkdirAllFunc func(path string, perm os.FileMode) error
 type CreateFunc func(name string) (io.Writer, error)
+type OpenFunc func(name string) (io.Reader, error)
+type RenameFunc func(oldPath string, newPath string) error
+type RemoveFunc func(name string) error
 
-func NewFileRepository(mkdirAll MkdirAllFunc, create CreateFunc, csvWriter utils.CSVWriterUtilInterface) FileRepositoryInterface {
+func NewFileRepository(mkdirAll MkdirAllFunc, create CreateFunc, open OpenFunc, rename RenameFunc, remove RemoveFunc, csvWriter utils.CSVWriterUtilInterface, pac entity.PACConfig) FileRepositoryInterface {
 	return &FileRepository{
 		MkdirAll:  mkdirAll,
 		Create:    create,
+		Open:      open,
+		Rename:    rename,
+		Remove:    remove,
 		CSVWriter: csvWriter,
+		PAC:       pac,
 	}
 }
 
-func (r *FileRepository) SaveFile(filePath string, data interface{}, format string) error {
+func (r *FileRepository) SaveFile(filePath string, data interface{}, format string) (err error) {
 	if err := r.CreateDirectory(filePath); err != nil {
 		return err
 	}
 
-	file, err := r.Create(filePath)
+	// The file is written next to its final path and renamed into place so that readers never see it half written
+	tempPath := filepath.Join(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp")
+	file, err := r.Create(tempPath)
 	if err != nil {
 		return fmt.Errorf("error creating file %s: %v", filePath, err)
 	}
+	defer func() {
+		if err != nil {
+			r.Remove(tempPath)
+		}
+	}()
+
+	err = r.Encode(file, data, format)
+	// Flushed to disk before the rename, otherwise a crash can leave an empty file behind the new name
+	if f, ok := file.(interface{ Sync() error }); ok && err == nil {
+		if syncErr := f.Sync(); syncErr != nil {
+			err = fmt.Errorf("error writing file %s: %v", filePath, syncErr)
+		}
+	}
+	if f, ok := file.(io.Closer); ok {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("error writing file %s: %v", filePath, closeErr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := r.Rename(tempPath, filePath); err != nil {
+		return fmt.Errorf("error renaming file %s: %v", filePath, err)
+	}
+	return nil
+}
+
+func (r *FileRepository) LoadFile(filePath string, data interface{}, format string) error {
+	file, err := r.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("error opening file %s: %w", filePath, err)
+	}
 	defer func() {
 		if f, ok := file.(io.Closer); ok {
 			f.Close()
@@ -60,19 +125,20 @@ func (r *FileRepository) SaveFile(filePath string, data interface{}, format stri
 	}()
 
 	switch format {
-	case "txt":
-		return r.WriteTxt(file, data)
 	case "json":
-		return r.EncodeJSON(file, data)
-	case "csv":
-		return r.EncodeCSV(file, data)
-	case "xml":
-		return r.EncodeXML(file, data)
+		err = json.NewDecoder(file).Decode(data)
+	case "jsonl":
+		err = decodeJSONL(file, data)
 	case "yaml":
-		return r.EncodeYAML(file, data)
+		err = yaml.NewDecoder(file).Decode(data)
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
+
+	if err != nil {
+		return fmt.Errorf("error decoding %s: %v", strings.ToUpper(format), err)
+	}
+	return nil
 }
 
 func (r *FileRepository) CreateDirectory(filePath string) error {
@@ -83,6 +149,59 @@ func (r *FileRepository) CreateDirectory(filePath string) error {
 	return nil
 }
 
+func (r *FileRepository) Checksum(filePath string) (int64, string, error) {
+	file, err := r.Open(filePath)
+	if err != nil {
+		return 0, "", fmt.Errorf("error opening file %s: %w", filePath, err)
+	}
+	defer func() {
+		if f, ok := file.(io.Closer); ok {
+			f.Close()
+		}
+	}()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", fmt.Errorf("error reading file %s: %v", filePath, err)
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (r *FileRepository) Encode(writer io.Writer, data interface{}, format string) error {
+	if inner, found := strings.CutSuffix(format, ".gz"); found {
+		return r.EncodeGzip(writer, data, inner)
+	}
+	if inner, found := strings.CutSuffix(format, ".zst"); found {
+		return r.EncodeZstd(writer, data, inner)
+	}
+
+	switch format {
+	case "txt":
+		return r.WriteTxt(writer, data)
+	case "json":
+		return r.EncodeJSON(writer, data)
+	case "jsonl":
+		return r.EncodeJSONL(writer, data)
+	case "csv":
+		return r.EncodeCSV(writer, data)
+	case "xml":
+		return r.EncodeXML(writer, data)
+	case "yaml":
+		return r.EncodeYAML(writer, data)
+	case "pac":
+		return r.EncodePAC(writer, data)
+	case "clash.yaml":
+		return r.EncodeClash(writer, data)
+	case "proxychains.conf":
+		return r.EncodeProxychains(writer, data)
+	case "sing-box.json":
+		return r.EncodeSingBox(writer, data)
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
 func (r *FileRepository) WriteTxt(writer io.Writer, data interface{}) error {
 	var dataString string
 	if stringData, ok := data.([]string); ok {
@@ -105,17 +224,35 @@ func (r *FileRepository) EncodeCSV(writer io.Writer, data interface{}) error {
 		}
 		return r.WriteCSV(writer, nil, rows)
 	case []entity.Proxy:
-		header := []string{"Proxy", "IP", "Port", "TimeTaken", "CheckedAt"}
+		withCredentials := slices.ContainsFunc(proxyData, func(proxy entity.Proxy) bool {
+			return proxy.Username != "" || proxy.Password != ""
+		})
+		header := []string{"Proxy", "IP", "Port", "TimeTaken", "CheckedAt", "Anonymity", "ExitIP", "IsRotating", "IsMITM", "RemoteDNS", "ConnectPorts"}
+		if withCredentials {
+			header = append(header, "Username", "Password")
+		}
 		rows := make([][]string, len(proxyData))
 		for i, proxy := range proxyData {
-			rows[i] = []string{proxy.Proxy, proxy.IP, proxy.Port, fmt.Sprintf("%v", proxy.TimeTaken), proxy.CheckedAt}
+			rows[i] = []string{proxy.Proxy, proxy.IP, proxy.Port, fmt.Sprintf("%v", proxy.TimeTaken), proxy.CheckedAt, proxy.Anonymity, proxy.ExitIP, strconv.FormatBool(proxy.IsRotating), strconv.FormatBool(proxy.IsMITM), strconv.FormatBool(proxy.RemoteDNS), joinPorts(proxy.ConnectPorts)}
+			if withCredentials {
+				rows[i] = append(rows[i], proxy.Username, proxy.Password)
+			}
 		}
 		return r.WriteCSV(writer, header, rows)
 	case []entity.AdvancedProxy:
-		header := []string{"Proxy", "IP", "Port", "Categories", "TimeTaken", "CheckedAt"}
+		withCredentials := slices.ContainsFunc(proxyData, func(proxy entity.AdvancedProxy) bool {
+			return proxy.Username != "" || proxy.Password != ""
+		})
+		header := []string{"Proxy", "IP", "Port", "Categories", "TimeTaken", "CheckedAt", "Anonymity", "ExitIP", "IsRotating", "IsMITM", "RemoteDNS", "ConnectPorts", "Uptime", "FirstSeen", "LastSeen", "ConsecutiveFailures", "AverageLatency"}
+		if withCredentials {
+			header = append(header, "Username", "Password")
+		}
 		rows := make([][]string, len(proxyData))
 		for i, proxy := range proxyData {
-			rows[i] = []string{proxy.Proxy, proxy.IP, proxy.Port, strings.Join(proxy.Categories, ","), fmt.Sprintf("%v", proxy.TimeTaken), proxy.CheckedAt}
+			rows[i] = []string{proxy.Proxy, proxy.IP, proxy.Port, strings.Join(proxy.Categories, ","), fmt.Sprintf("%v", proxy.TimeTaken), proxy.CheckedAt, proxy.Anonymity, proxy.ExitIP, strconv.FormatBool(proxy.IsRotating), strconv.FormatBool(proxy.IsMITM), strconv.FormatBool(proxy.RemoteDNS), joinPorts(proxy.ConnectPorts), fmt.Sprintf("%v", proxy.Uptime), proxy.FirstSeen, proxy.LastSeen, strconv.Itoa(proxy.ConsecutiveFailures), fmt.Sprintf("%v", proxy.AverageLatency)}
+			if withCredentials {
+				rows[i] = append(rows[i], proxy.Username, proxy.Password)
+			}
 		}
 		return r.WriteCSV(writer, header, rows)
 	default:
@@ -150,6 +287,63 @@ func (r *FileRepository) EncodeJSON(writer io.Writer, data interface{}) error {
 	return nil
 }
 
+func (r *FileRepository) EncodeJSONL(writer io.Writer, data interface{}) error {
+	// Every proxy is encoded and written on its own, so the whole list is never held in memory as JSON
+	var (
+		encoder = json.NewEncoder(writer)
+		err     error
+	)
+	encode := func(value interface{}) bool {
+		err = encoder.Encode(value)
+		return err == nil
+	}
+
+	switch proxyData := data.(type) {
+	case []string:
+		encodeEach(slices.Values(proxyData), encode)
+	case []entity.Proxy:
+		encodeEach(slices.Values(proxyData), encode)
+	case []entity.AdvancedProxy:
+		encodeEach(slices.Values(proxyData), encode)
+	case iter.Seq[entity.Proxy]:
+		encodeEach(proxyData, encode)
+	case iter.Seq[entity.AdvancedProxy]:
+		encodeEach(proxyData, encode)
+	default:
+		return fmt.Errorf("invalid data type for JSONL encoding")
+	}
+
+	if err != nil {
+		return fmt.Errorf("error encoding JSONL: %v", err)
+	}
+	return nil
+}
+
+func encodeEach[T any](values iter.Seq[T], encode func(value interface{}) bool) {
+	for value := range values {
+		if !encode(value) {
+			return
+		}
+	}
+}
+
+func decodeJSONL(reader io.Reader, data interface{}) error {
+	proxies, ok := data.(*[]entity.AdvancedProxy)
+	if !ok {
+		return fmt.Errorf("invalid data type for JSONL decoding")
+	}
+
+	decoder := json.NewDecoder(reader)
+	for decoder.More() {
+		proxy := entity.AdvancedProxy{}
+		if err := decoder.Decode(&proxy); err != nil {
+			return err
+		}
+		*proxies = append(*proxies, proxy)
+	}
+	return nil
+}
+
 func (r *FileRepository) EncodeXML(writer io.Writer, data interface{}) error {
 	var err error
 	switch proxyData := data.(type) {
@@ -216,3 +410,337 @@ func (r *FileRepository) EncodeYAML(writer io.Writer, data interface{}) error {
 	}
 	return nil
 }
+
+// PAC directives of the proxy categories, the first one a proxy passed is used
+var pacDirectives = []struct {
+	Category  string
+	Directive string
+}{
+	{Category: "SOCKS5H", Directive: "SOCKS5"},
+	{Category: "SOCKS5", Directive: "SOCKS5"},
+	{Category: "HTTPS", Directive: "HTTPS"},
+	{Category: "HTTP", Directive: "PROXY"},
+	{Category: "SOCKS4A", Directive: "SOCKS"},
+	{Category: "SOCKS4", Directive: "SOCKS"},
+}
+
+const pacScript = `// Generated by fresh-proxy-list, proxies are tried from the fastest to the slowest
+var proxies = %s;
+var include = %s;
+var exclude = %s;
+
+function matches(host, rules) {
+  for (var i = 0; i < rules.length; i++) {
+    var rule = rules[i];
+    if (/[*?]/.test(rule) ? shExpMatch(host, rule) : host === rule || dnsDomainIs(host, "." + rule)) {
+      return true;
+    }
+  }
+  return false;
+}
+
+function FindProxyForURL(url, host) {
+  if (isPlainHostName(host) || matches(host, exclude)) {
+    return "DIRECT";
+  }
+  if (include.length > 0 && !matches(host, include)) {
+    return "DIRECT";
+  }
+  return proxies;
+}
+`
+
+func (r *FileRepository) EncodePAC(writer io.Writer, data interface{}) error {
+	type pacProxy struct {
+		Directive string
+		Proxy     string
+		TimeTaken float64
+	}
+
+	var proxies []pacProxy
+	directive := func(categories ...string) string {
+		for _, pacDirective := range pacDirectives {
+			if slices.Contains(categories, pacDirective.Category) {
+				return pacDirective.Directive
+			}
+		}
+		return ""
+	}
+	switch proxyData := data.(type) {
+	case []entity.Proxy:
+		for _, proxy := range proxyData {
+			proxies = append(proxies, pacProxy{Directive: directive(proxy.Category), Proxy: proxy.Proxy, TimeTaken: proxy.TimeTaken})
+		}
+	case []entity.AdvancedProxy:
+		for _, proxy := range proxyData {
+			proxies = append(proxies, pacProxy{Directive: directive(proxy.Categories...), Proxy: proxy.Proxy, TimeTaken: proxy.TimeTaken})
+		}
+	default:
+		return fmt.Errorf("invalid data type for PAC encoding")
+	}
+
+	slices.SortStableFunc(proxies, func(a, b pacProxy) int {
+		return cmp.Compare(a.TimeTaken, b.TimeTaken)
+	})
+	var directives []string
+	for _, proxy := range proxies {
+		if proxy.Directive != "" {
+			directives = append(directives, proxy.Directive+" "+proxy.Proxy)
+		}
+	}
+	// Clients try the proxies one after another, so only the fastest ones are worth listing
+	if r.PAC.Max > 0 && len(directives) > r.PAC.Max {
+		directives = directives[:r.PAC.Max]
+	}
+	if len(directives) == 0 {
+		directives = []string{"DIRECT"}
+	}
+
+	// Strings and string lists always marshal, and their JSON is a valid JavaScript literal
+	literal := func(value interface{}) string {
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	}
+	include, exclude := append([]string{}, r.PAC.Include...), append([]string{}, r.PAC.Exclude...)
+	if _, err := fmt.Fprintf(writer, pacScript, literal(strings.Join(directives, "; ")), literal(include), literal(exclude)); err != nil {
+		return fmt.Errorf("error writing PAC: %v", err)
+	}
+	return nil
+}
+
+// URL and interval the client configurations test their proxies with to pick the fastest one
+const (
+	clientTestURL      = "http://www.gstatic.com/generate_204"
+	clientTestInterval = 300
+)
+
+type clientProxy struct {
+	Name     string
+	Category string
+	IP       string
+	Port     int
+	Username string
+	Password string
+}
+
+func clientProxies(data interface{}, format string) ([]clientProxy, error) {
+	var proxies []clientProxy
+	add := func(category string, proxy string, ip string, port string, username string, password string) {
+		portNumber, _ := strconv.Atoi(port)
+		proxies = append(proxies, clientProxy{
+			Name:     category + " " + proxy,
+			Category: category,
+			IP:       ip,
+			Port:     portNumber,
+			Username: username,
+			Password: password,
+		})
+	}
+
+	switch proxyData := data.(type) {
+	case []entity.Proxy:
+		for _, proxy := range proxyData {
+			add(proxy.Category, proxy.Proxy, proxy.IP, proxy.Port, proxy.Username, proxy.Password)
+		}
+	case []entity.AdvancedProxy:
+		for _, proxy := range proxyData {
+			for _, category := range proxy.Categories {
+				add(category, proxy.Proxy, proxy.IP, proxy.Port, proxy.Username, proxy.Password)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid data type for %s encoding", format)
+	}
+	return proxies, nil
+}
+
+func (r *FileRepository) EncodeClash(writer io.Writer, data interface{}) error {
+	proxies, err := clientProxies(data, "Clash")
+	if err != nil {
+		return err
+	}
+
+	var (
+		config = entity.ClashConfig{Proxies: []entity.ClashProxy{}, ProxyGroups: []entity.ClashProxyGroup{}}
+		groups = map[string][]string{}
+		seen   = map[string]bool{}
+	)
+	for _, proxy := range proxies {
+		clashProxy := entity.ClashProxy{
+			Name:     proxy.Name,
+			Server:   proxy.IP,
+			Port:     proxy.Port,
+			Username: proxy.Username,
+			Password: proxy.Password,
+		}
+		switch proxy.Category {
+		case "HTTP":
+			clashProxy.Type = "http"
+		case "HTTPS":
+			clashProxy.Type, clashProxy.TLS = "http", true
+		case "SOCKS5", "SOCKS5H":
+			clashProxy.Type = "socks5"
+		default:
+			// Clash has no SOCKS4 support
+			continue
+		}
+
+		key := fmt.Sprintf("%s %s %d %v", clashProxy.Type, clashProxy.Server, clashProxy.Port, clashProxy.TLS)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		config.Proxies = append(config.Proxies, clashProxy)
+		groups[proxy.Category] = append(groups[proxy.Category], proxy.Name)
+	}
+
+	if len(config.Proxies) > 0 {
+		names := make([]string, len(config.Proxies))
+		for i, proxy := range config.Proxies {
+			names[i] = proxy.Name
+		}
+		config.ProxyGroups = append(config.ProxyGroups, entity.ClashProxyGroup{Name: "auto", Type: "url-test", Proxies: names, URL: clientTestURL, Interval: clientTestInterval})
+	}
+	if len(groups) > 1 {
+		for _, category := range slices.Sorted(maps.Keys(groups)) {
+			config.ProxyGroups = append(config.ProxyGroups, entity.ClashProxyGroup{Name: category, Type: "url-test", Proxies: groups[category], URL: clientTestURL, Interval: clientTestInterval})
+		}
+	}
+
+	if err := yaml.NewEncoder(writer).Encode(config); err != nil {
+		return fmt.Errorf("error encoding Clash: %v", err)
+	}
+	return nil
+}
+
+func (r *FileRepository) EncodeProxychains(writer io.Writer, data interface{}) error {
+	proxies, err := clientProxies(data, "proxychains")
+	if err != nil {
+		return err
+	}
+
+	// Every connection goes through a single random proxy of the list instead of chaining all of them
+	var (
+		builder strings.Builder
+		seen    = map[string]bool{}
+	)
+	builder.WriteString("# Generated by fresh-proxy-list\nrandom_chain\nchain_len = 1\nproxy_dns\ntcp_read_time_out 15000\ntcp_connect_time_out 8000\n\n[ProxyList]\n")
+	for _, proxy := range proxies {
+		var proxyType string
+		switch proxy.Category {
+		case "HTTP":
+			proxyType = "http"
+		case "SOCKS4", "SOCKS4A":
+			proxyType = "socks4"
+		case "SOCKS5", "SOCKS5H":
+			proxyType = "socks5"
+		default:
+			// proxychains cannot speak TLS to the proxy
+			continue
+		}
+
+		line := fmt.Sprintf("%s %s %d", proxyType, proxy.IP, proxy.Port)
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+		if proxy.Username != "" || proxy.Password != "" {
+			line += " " + proxy.Username + " " + proxy.Password
+		}
+		builder.WriteString(line + "\n")
+	}
+
+	if _, err := io.WriteString(writer, builder.String()); err != nil {
+		return fmt.Errorf("error writing proxychains: %v", err)
+	}
+	return nil
+}
+
+func (r *FileRepository) EncodeSingBox(writer io.Writer, data interface{}) error {
+	proxies, err := clientProxies(data, "sing-box")
+	if err != nil {
+		return err
+	}
+
+	var (
+		config = entity.SingBoxConfig{Outbounds: []entity.SingBoxOutbound{}}
+		tags   []string
+		seen   = map[string]bool{}
+	)
+	for _, proxy := range proxies {
+		outbound := entity.SingBoxOutbound{
+			Tag:        proxy.Name,
+			Server:     proxy.IP,
+			ServerPort: proxy.Port,
+			Username:   proxy.Username,
+			Password:   proxy.Password,
+		}
+		switch proxy.Category {
+		case "HTTP":
+			outbound.Type = "http"
+		case "HTTPS":
+			outbound.Type, outbound.TLS = "http", &entity.SingBoxTLS{Enabled: true}
+		case "SOCKS4":
+			outbound.Type, outbound.Version = "socks", "4"
+		case "SOCKS4A":
+			outbound.Type, outbound.Version = "socks", "4a"
+		case "SOCKS5", "SOCKS5H":
+			outbound.Type, outbound.Version = "socks", "5"
+		default:
+			continue
+		}
+
+		key := fmt.Sprintf("%s %s %s %d %v", outbound.Type, outbound.Version, outbound.Server, outbound.ServerPort, outbound.TLS != nil)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		config.Outbounds = append(config.Outbounds, outbound)
+		tags = append(tags, outbound.Tag)
+	}
+
+	if len(tags) > 0 {
+		config.Outbounds = append(config.Outbounds, entity.SingBoxOutbound{Type: "urltest", Tag: "auto", Outbounds: tags, URL: clientTestURL, Interval: fmt.Sprintf("%ds", clientTestInterval)})
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config); err != nil {
+		return fmt.Errorf("error encoding sing-box: %v", err)
+	}
+	return nil
+}
+
+func joinPorts(ports []int) string {
+	values := make([]string, len(ports))
+	for i, port := range ports {
+		values[i] = strconv.Itoa(port)
+	}
+	return strings.Join(values, ",")
+}
+
+func (r *FileRepository) EncodeGzip(writer io.Writer, data interface{}, format string) error {
+	gzipWriter := gzip.NewWriter(writer)
+	if err := r.Encode(gzipWriter, data, format); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("error compressing %s: %v", strings.ToUpper(format), err)
+	}
+	return nil
+}
+
+func (r *FileRepository) EncodeZstd(writer io.Writer, data interface{}, format string) error {
+	zstdWriter, err := zstd.NewWriter(writer)
+	if err != nil {
+		return fmt.Errorf("error compressing %s: %v", strings.ToUpper(format), err)
+	}
+	if err := r.Encode(zstdWriter, data, format); err != nil {
+		zstdWriter.Close()
+		return err
+	}
+	if err := zstdWriter.Close(); err != nil {
+		return fmt.Errorf("error compressing %s: %v", strings.ToUpper(format), err)
+	}
+	return nil
+}
@@ -2,15 +2,23 @@ package repository
 
 import (
 	"bytes"
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"iter"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
 	"github.com/fyvri/fresh-proxy-list/pkg/utils"
+
+	"github.com/klauspost/compress/zstd"
 )
 
 var (
@@ -35,8 +43,20 @@ func TestNewFileRepository(t *testing.T) {
 		}
 		return &bytes.Buffer{}, nil
 	}
+	mockOpen := func(name string) (io.Reader, error) {
+		if name == "" {
+			return nil, errors.New("file name cannot be empty")
+		}
+		return &bytes.Buffer{}, nil
+	}
 	mockCSVWriterUtil := &mockCSVWriterUtil{}
-	fileRepository := NewFileRepository(mockMkdirAll, mockCreate, mockCSVWriterUtil)
+	mockRename := func(oldPath string, newPath string) error {
+		return nil
+	}
+	mockRemove := func(name string) error {
+		return nil
+	}
+	fileRepository := NewFileRepository(mockMkdirAll, mockCreate, mockOpen, mockRename, mockRemove, mockCSVWriterUtil, entity.PACConfig{})
 
 	if fileRepository == nil {
 		t.Errorf(expectedReturnNonNil, "NewFileRepository", "FileRepositoryInterface")
@@ -54,6 +74,18 @@ func TestNewFileRepository(t *testing.T) {
 	if r.Create == nil {
 		t.Errorf("expected create to be set")
 	}
+
+	if r.Open == nil {
+		t.Errorf("expected open to be set")
+	}
+
+	if r.Rename == nil {
+		t.Errorf("expected rename to be set")
+	}
+
+	if r.Remove == nil {
+		t.Errorf("expected remove to be set")
+	}
 }
 
 func TestSaveFile(t *testing.T) {
@@ -435,8 +467,14 @@ func TestSaveFile(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := &FileRepository{
-				MkdirAll:  tt.fields.mkdirAll,
-				Create:    tt.fields.create,
+				MkdirAll: tt.fields.mkdirAll,
+				Create:   tt.fields.create,
+				Rename: func(oldPath string, newPath string) error {
+					return nil
+				},
+				Remove: func(name string) error {
+					return nil
+				},
 				CSVWriter: tt.fields.csvWriter,
 			}
 			err := r.SaveFile(tt.args.path, tt.args.data, tt.args.format)
@@ -449,6 +487,652 @@ func TestSaveFile(t *testing.T) {
 	}
 }
 
+func TestLoadFile(t *testing.T) {
+	type args struct {
+		path   string
+		format string
+	}
+
+	tests := []struct {
+		name      string
+		open      func(name string) (io.Reader, error)
+		args      args
+		want      []entity.AdvancedProxy
+		wantError error
+	}{
+		{
+			name: "OpenFileError",
+			open: func(name string) (io.Reader, error) {
+				return nil, errors.New("error opening file")
+			},
+			args: args{
+				path:   testAdvancedFilePath + "." + testJSONExtension,
+				format: testJSONExtension,
+			},
+			want:      nil,
+			wantError: fmt.Errorf("error opening file %v: %v", testAdvancedFilePath+"."+testJSONExtension, "error opening file"),
+		},
+		{
+			name: "UnsupportedFormat",
+			open: func(name string) (io.Reader, error) {
+				return &bytes.Buffer{}, nil
+			},
+			args: args{
+				path:   testAdvancedFilePath + "." + testCSVExtension,
+				format: testCSVExtension,
+			},
+			want:      nil,
+			wantError: fmt.Errorf("unsupported format: %v", testCSVExtension),
+		},
+		{
+			name: "DecodeJSON",
+			open: func(name string) (io.Reader, error) {
+				return bytes.NewReader(testAdvancedProxiesToString), nil
+			},
+			args: args{
+				path:   testAdvancedFilePath + "." + testJSONExtension,
+				format: testJSONExtension,
+			},
+			want:      testAdvancedProxies,
+			wantError: nil,
+		},
+		{
+			name: "DecodeJSONError",
+			open: func(name string) (io.Reader, error) {
+				return strings.NewReader("{"), nil
+			},
+			args: args{
+				path:   testAdvancedFilePath + "." + testJSONExtension,
+				format: testJSONExtension,
+			},
+			want:      nil,
+			wantError: fmt.Errorf(testErrorDecode, "JSON", "unexpected EOF"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &FileRepository{
+				Open: tt.open,
+			}
+
+			var got []entity.AdvancedProxy
+			err := r.LoadFile(tt.args.path, &got, tt.args.format)
+			if (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) ||
+				(err == nil && tt.wantError != nil) ||
+				(err != nil && tt.wantError == nil) {
+				t.Errorf(expectedErrorButGotMessage, "LoadFile()", tt.wantError, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "LoadFile()", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSaveFileIPv6(t *testing.T) {
+	var (
+		ip      = "2606:4700::1111"
+		proxy   = "[" + ip + "]:1337"
+		proxies = []entity.AdvancedProxy{
+			{
+				Proxy:      proxy,
+				IP:         ip,
+				Port:       "1337",
+				TimeTaken:  testTimeTaken,
+				CheckedAt:  testCheckedAt,
+				Categories: []string{testHTTPCategory},
+			},
+		}
+	)
+
+	for _, format := range []string{testTXTExtension, testCSVExtension, testJSONExtension, testXMLExtension, testYAMLExtension} {
+		t.Run(format, func(t *testing.T) {
+			var (
+				buffer bytes.Buffer
+				data   interface{} = proxies
+			)
+			if format == testTXTExtension {
+				data = []string{proxy}
+			}
+
+			r := &FileRepository{
+				MkdirAll: func(path string, perm os.FileMode) error {
+					return nil
+				},
+				Create: func(name string) (io.Writer, error) {
+					return &buffer, nil
+				},
+				Rename: func(oldPath string, newPath string) error {
+					return nil
+				},
+				CSVWriter: utils.NewCSVWriter(),
+			}
+			if err := r.SaveFile(testAdvancedFilePath+"."+format, data, format); err != nil {
+				t.Errorf(expectedErrorButGotMessage, "SaveFile()", nil, err)
+			}
+
+			if !strings.Contains(buffer.String(), proxy) {
+				t.Errorf(expectedButGotMessage, "SaveFile()", proxy, buffer.String())
+			}
+		})
+	}
+}
+
+func TestSaveFileRenamesTempFile(t *testing.T) {
+	var (
+		filePath = testClassicFilePath + "." + testTXTExtension
+		tempPath = filepath.Join(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp")
+	)
+
+	tests := []struct {
+		name        string
+		format      string
+		sync        error
+		rename      error
+		wantRenamed bool
+		wantRemoved bool
+		wantError   error
+	}{
+		{
+			name:        "Success",
+			format:      testTXTExtension,
+			wantRenamed: true,
+			wantRemoved: false,
+			wantError:   nil,
+		},
+		{
+			name:        "EncodeError",
+			format:      "unsupported-format",
+			wantRenamed: false,
+			wantRemoved: true,
+			wantError:   fmt.Errorf("unsupported format: %v", "unsupported-format"),
+		},
+		{
+			name:        "SyncError",
+			format:      testTXTExtension,
+			sync:        errors.New("input/output error"),
+			wantRenamed: false,
+			wantRemoved: true,
+			wantError:   fmt.Errorf("error writing file %v: %v", filePath, "input/output error"),
+		},
+		{
+			name:        "RenameError",
+			format:      testTXTExtension,
+			rename:      errors.New("permission denied"),
+			wantRenamed: true,
+			wantRemoved: true,
+			wantError:   fmt.Errorf("error renaming file %v: %v", filePath, "permission denied"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				created string
+				renamed bool
+				removed bool
+				writer  = &mockWriter{errSync: tt.sync}
+			)
+			r := &FileRepository{
+				MkdirAll: func(path string, perm os.FileMode) error {
+					return nil
+				},
+				Create: func(name string) (io.Writer, error) {
+					created = name
+					return writer, nil
+				},
+				Rename: func(oldPath string, newPath string) error {
+					renamed = oldPath == tempPath && newPath == filePath && writer.synced
+					return tt.rename
+				},
+				Remove: func(name string) error {
+					removed = name == tempPath
+					return nil
+				},
+			}
+
+			err := r.SaveFile(filePath, testIPs, tt.format)
+			if (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) ||
+				(err == nil && tt.wantError != nil) ||
+				(err != nil && tt.wantError == nil) {
+				t.Errorf(expectedErrorButGotMessage, "SaveFile()", tt.wantError, err)
+			}
+
+			if created != tempPath {
+				t.Errorf(expectedButGotMessage, "created file", tempPath, created)
+			}
+			if renamed != tt.wantRenamed {
+				t.Errorf(expectedButGotMessage, "renamed", tt.wantRenamed, renamed)
+			}
+			if removed != tt.wantRemoved {
+				t.Errorf(expectedButGotMessage, "removed", tt.wantRemoved, removed)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	r := &FileRepository{CSVWriter: utils.NewCSVWriter()}
+
+	tests := []struct {
+		format    string
+		want      string
+		wantError error
+	}{
+		{format: testTXTExtension, want: strings.Join(testIPs, "\n")},
+		{format: testJSONExtension, want: string(testIPsToString) + "\n"},
+		{format: "html", wantError: errors.New("unsupported format: html")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buffer bytes.Buffer
+			err := r.Encode(&buffer, testIPs, tt.format)
+			if (err != nil && tt.wantError == nil) || (err == nil && tt.wantError != nil) || (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) {
+				t.Fatalf(expectedErrorButGotMessage, "Encode()", tt.wantError, err)
+			}
+			if buffer.String() != tt.want {
+				t.Errorf(expectedButGotMessage, "Encode()", tt.want, buffer.String())
+			}
+		})
+	}
+}
+
+func TestEncodeGzip(t *testing.T) {
+	var buffer bytes.Buffer
+	r := &FileRepository{}
+	if err := r.Encode(&buffer, []string{testProxy1, testProxy2}, "txt.gz"); err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "Encode()", nil, err)
+	}
+
+	reader, err := gzip.NewReader(&buffer)
+	if err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "gzip.NewReader()", nil, err)
+	}
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "io.ReadAll()", nil, err)
+	}
+	want := testProxy1 + "\n" + testProxy2
+	if string(got) != want {
+		t.Errorf(expectedButGotMessage, "Encode()", want, string(got))
+	}
+
+	wantError := errors.New("unsupported format: bz2")
+	if err := r.Encode(&buffer, []string{testProxy1}, "bz2.gz"); err == nil || err.Error() != wantError.Error() {
+		t.Errorf(expectedErrorButGotMessage, "Encode()", wantError, err)
+	}
+}
+
+func TestEncodeZstd(t *testing.T) {
+	var buffer bytes.Buffer
+	r := &FileRepository{}
+	if err := r.Encode(&buffer, []string{testProxy1, testProxy2}, "txt.zst"); err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "Encode()", nil, err)
+	}
+
+	decoder, err := zstd.NewReader(&buffer)
+	if err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "zstd.NewReader()", nil, err)
+	}
+	defer decoder.Close()
+	got, err := io.ReadAll(decoder)
+	if err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "io.ReadAll()", nil, err)
+	}
+	want := testProxy1 + "\n" + testProxy2
+	if string(got) != want {
+		t.Errorf(expectedButGotMessage, "Encode()", want, string(got))
+	}
+
+	wantError := errors.New("unsupported format: bz2")
+	if err := r.Encode(&buffer, []string{testProxy1}, "bz2.zst"); err == nil || err.Error() != wantError.Error() {
+		t.Errorf(expectedErrorButGotMessage, "Encode()", wantError, err)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	tests := []struct {
+		name       string
+		open       func(name string) (io.Reader, error)
+		wantSize   int64
+		wantSHA256 string
+		wantError  error
+	}{
+		{
+			name: "Success",
+			open: func(name string) (io.Reader, error) {
+				return strings.NewReader("hello"), nil
+			},
+			wantSize:   5,
+			wantSHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		},
+		{
+			name: "OpenError",
+			open: func(name string) (io.Reader, error) {
+				return nil, errors.New("not found")
+			},
+			wantError: fmt.Errorf("error opening file %s: %w", testAdvancedFilePath, errors.New("not found")),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &FileRepository{Open: tt.open}
+			size, checksum, err := r.Checksum(testAdvancedFilePath)
+			if (err != nil && tt.wantError == nil) || (err == nil && tt.wantError != nil) || (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) {
+				t.Fatalf(expectedErrorButGotMessage, "Checksum()", tt.wantError, err)
+			}
+			if size != tt.wantSize || checksum != tt.wantSHA256 {
+				t.Errorf(expectedButGotMessage, "Checksum()", fmt.Sprint(tt.wantSize, " ", tt.wantSHA256), fmt.Sprint(size, " ", checksum))
+			}
+		})
+	}
+}
+
+func TestEncodeJSONL(t *testing.T) {
+	proxies := []entity.AdvancedProxy{
+		{Proxy: testProxy1, IP: testIP1, Port: testPort1, Categories: []string{testHTTPCategory}},
+		{Proxy: testProxy2, IP: testIP2, Port: testPort2, Categories: []string{"SOCKS5"}},
+	}
+	want := `{"proxy":"` + testProxy1 + `","ip":"` + testIP1 + `","port":"` + testPort1 + `","time_taken":0,"checked_at":"","categories":["HTTP"]}` + "\n" +
+		`{"proxy":"` + testProxy2 + `","ip":"` + testIP2 + `","port":"` + testPort2 + `","time_taken":0,"checked_at":"","categories":["SOCKS5"]}` + "\n"
+
+	tests := []struct {
+		name      string
+		data      interface{}
+		want      string
+		wantError error
+	}{
+		{
+			name: "Slice",
+			data: proxies,
+			want: want,
+		},
+		{
+			name: "Stream",
+			data: iter.Seq[entity.AdvancedProxy](slices.Values(proxies)),
+			want: want,
+		},
+		{
+			name: "Classic",
+			data: []string{testProxy1},
+			want: `"` + testProxy1 + `"` + "\n",
+		},
+		{
+			name:      "InvalidDataType",
+			data:      "invalid",
+			wantError: errors.New("invalid data type for JSONL encoding"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			r := &FileRepository{}
+			err := r.EncodeJSONL(&buffer, tt.data)
+			if (err != nil && tt.wantError == nil) || (err == nil && tt.wantError != nil) || (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) {
+				t.Fatalf(expectedErrorButGotMessage, "EncodeJSONL()", tt.wantError, err)
+			}
+			if buffer.String() != tt.want {
+				t.Errorf(expectedButGotMessage, "EncodeJSONL()", tt.want, buffer.String())
+			}
+
+			if tt.wantError == nil && tt.name != "Classic" {
+				var got []entity.AdvancedProxy
+				r.Open = func(name string) (io.Reader, error) {
+					return strings.NewReader(buffer.String()), nil
+				}
+				if err := r.LoadFile(testAdvancedFilePath+".jsonl", &got, "jsonl"); err != nil {
+					t.Errorf(expectedErrorButGotMessage, "LoadFile()", nil, err)
+				}
+				if !reflect.DeepEqual(got, proxies) {
+					t.Errorf(expectedButGotMessage, "LoadFile()", proxies, got)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodePAC(t *testing.T) {
+	tests := []struct {
+		name      string
+		pac       entity.PACConfig
+		data      interface{}
+		want      []string
+		wantError error
+	}{
+		{
+			name: "Advanced",
+			data: []entity.Proxy{
+				{Category: testHTTPCategory, Proxy: testProxy1, TimeTaken: 2},
+				{Category: "HTTPS", Proxy: testProxy2, TimeTaken: 1},
+			},
+			want: []string{
+				`var proxies = "HTTPS ` + testProxy2 + `; PROXY ` + testProxy1 + `";`,
+				`var include = [];`,
+				`var exclude = [];`,
+				`function FindProxyForURL(url, host) {`,
+			},
+		},
+		{
+			name: "AllAdvancedWithRules",
+			pac:  entity.PACConfig{Include: []string{"*.example.com"}, Exclude: []string{"localhost"}},
+			data: []entity.AdvancedProxy{
+				{Proxy: testProxy1, Categories: []string{testHTTPCategory, "SOCKS5"}, TimeTaken: 3},
+				{Proxy: testProxy2, Categories: []string{"SOCKS4"}, TimeTaken: 1},
+			},
+			want: []string{
+				`var proxies = "SOCKS ` + testProxy2 + `; SOCKS5 ` + testProxy1 + `";`,
+				`var include = ["*.example.com"];`,
+				`var exclude = ["localhost"];`,
+			},
+		},
+		{
+			name: "Max",
+			pac:  entity.PACConfig{Max: 2},
+			data: []entity.Proxy{
+				{Category: testHTTPCategory, Proxy: testProxy1, TimeTaken: 3},
+				{Category: "HTTPS", Proxy: testProxy2, TimeTaken: 1},
+				{Category: "SOCKS5", Proxy: testProxy3, TimeTaken: 2},
+			},
+			want: []string{`var proxies = "HTTPS ` + testProxy2 + `; SOCKS5 ` + testProxy3 + `";`},
+		},
+		{
+			name: "Empty",
+			data: []entity.Proxy{},
+			want: []string{`var proxies = "DIRECT";`},
+		},
+		{
+			name:      "Classic",
+			data:      testIPs,
+			wantError: errors.New("invalid data type for PAC encoding"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			r := &FileRepository{PAC: tt.pac}
+			err := r.EncodePAC(&buffer, tt.data)
+			if (err != nil && tt.wantError == nil) || (err == nil && tt.wantError != nil) || (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) {
+				t.Fatalf(expectedErrorButGotMessage, "EncodePAC()", tt.wantError, err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(buffer.String(), want) {
+					t.Errorf(expectedButGotMessage, "EncodePAC()", want, buffer.String())
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeClientConfigs(t *testing.T) {
+	data := []entity.AdvancedProxy{
+		{Proxy: testProxy1, IP: testIP1, Port: testPort1, Username: "user", Password: "pass", Categories: []string{testHTTPCategory, "SOCKS5", "SOCKS5H"}},
+		{Proxy: testProxy2, IP: testIP2, Port: testPort2, Categories: []string{"HTTPS", "SOCKS4"}},
+	}
+
+	tests := []struct {
+		format string
+		data   interface{}
+		want   string
+	}{
+		{
+			format: "clash.yaml",
+			data:   data,
+			want: `proxies:
+    - name: HTTP ` + testProxy1 + `
+      type: http
+      server: ` + testIP1 + `
+      port: ` + testPort1 + `
+      username: user
+      password: pass
+    - name: SOCKS5 ` + testProxy1 + `
+      type: socks5
+      server: ` + testIP1 + `
+      port: ` + testPort1 + `
+      username: user
+      password: pass
+    - name: HTTPS ` + testProxy2 + `
+      type: http
+      server: ` + testIP2 + `
+      port: ` + testPort2 + `
+      tls: true
+proxy-groups:
+    - name: auto
+      type: url-test
+      proxies:
+        - HTTP ` + testProxy1 + `
+        - SOCKS5 ` + testProxy1 + `
+        - HTTPS ` + testProxy2 + `
+      url: http://www.gstatic.com/generate_204
+      interval: 300
+    - name: HTTP
+      type: url-test
+      proxies:
+        - HTTP ` + testProxy1 + `
+      url: http://www.gstatic.com/generate_204
+      interval: 300
+    - name: HTTPS
+      type: url-test
+      proxies:
+        - HTTPS ` + testProxy2 + `
+      url: http://www.gstatic.com/generate_204
+      interval: 300
+    - name: SOCKS5
+      type: url-test
+      proxies:
+        - SOCKS5 ` + testProxy1 + `
+      url: http://www.gstatic.com/generate_204
+      interval: 300
+`,
+		},
+		{
+			format: "clash.yaml",
+			data:   []entity.Proxy{},
+			want:   "proxies: []\nproxy-groups: []\n",
+		},
+		{
+			format: "proxychains.conf",
+			data:   data,
+			want: "# Generated by fresh-proxy-list\nrandom_chain\nchain_len = 1\nproxy_dns\ntcp_read_time_out 15000\ntcp_connect_time_out 8000\n\n[ProxyList]\n" +
+				"http " + testIP1 + " " + testPort1 + " user pass\n" +
+				"socks5 " + testIP1 + " " + testPort1 + " user pass\n" +
+				"socks4 " + testIP2 + " " + testPort2 + "\n",
+		},
+		{
+			format: "sing-box.json",
+			data:   []entity.Proxy{{Category: "SOCKS4A", Proxy: testProxy2, IP: testIP2, Port: testPort2}},
+			want: `{
+  "outbounds": [
+    {
+      "type": "socks",
+      "tag": "SOCKS4A ` + testProxy2 + `",
+      "server": "` + testIP2 + `",
+      "server_port": ` + testPort2 + `,
+      "version": "4a"
+    },
+    {
+      "type": "urltest",
+      "tag": "auto",
+      "outbounds": [
+        "SOCKS4A ` + testProxy2 + `"
+      ],
+      "url": "http://www.gstatic.com/generate_204",
+      "interval": "300s"
+    }
+  ]
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buffer bytes.Buffer
+			r := &FileRepository{}
+			if err := r.Encode(&buffer, tt.data, tt.format); err != nil {
+				t.Fatalf(expectedErrorButGotMessage, "Encode()", nil, err)
+			}
+
+			if buffer.String() != tt.want {
+				t.Errorf(expectedButGotMessage, "Encode()", tt.want, buffer.String())
+			}
+		})
+	}
+
+	for _, format := range []string{"clash.yaml", "proxychains.conf", "sing-box.json"} {
+		r := &FileRepository{}
+		if err := r.Encode(&bytes.Buffer{}, testIPs, format); err == nil {
+			t.Errorf(expectedErrorButGotMessage, "Encode()", "invalid data type", err)
+		}
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{
+			name: "Advanced",
+			data: []entity.Proxy{{Proxy: testProxy1, IP: testIP1, Port: testPort1, Anonymity: "elite", Username: "user", Password: "pass"}},
+			want: "Proxy,IP,Port,TimeTaken,CheckedAt,Anonymity,ExitIP,IsRotating,IsMITM,RemoteDNS,ConnectPorts,Username,Password\n" + testProxy1 + "," + testIP1 + "," + testPort1 + ",0,,elite,,false,false,false,,user,pass\n",
+		},
+		{
+			name: "AllAdvanced",
+			data: []entity.AdvancedProxy{{Proxy: testProxy1, IP: testIP1, Port: testPort1, Categories: []string{testHTTPCategory}, Username: "user", Password: "pass", Uptime: 87.5, FirstSeen: "2024-01-01T00:00:00Z", LastSeen: "2024-01-08T00:00:00Z", ConsecutiveFailures: 1, AverageLatency: 1.25}},
+			want: "Proxy,IP,Port,Categories,TimeTaken,CheckedAt,Anonymity,ExitIP,IsRotating,IsMITM,RemoteDNS,ConnectPorts,Uptime,FirstSeen,LastSeen,ConsecutiveFailures,AverageLatency,Username,Password\n" + testProxy1 + "," + testIP1 + "," + testPort1 + "," + testHTTPCategory + ",0,,,,false,false,false,,87.5,2024-01-01T00:00:00Z,2024-01-08T00:00:00Z,1,1.25,user,pass\n",
+		},
+		{
+			name: "WithoutCredentials",
+			data: []entity.Proxy{{Proxy: testProxy1, IP: testIP1, Port: testPort1}},
+			want: "Proxy,IP,Port,TimeTaken,CheckedAt,Anonymity,ExitIP,IsRotating,IsMITM,RemoteDNS,ConnectPorts\n" + testProxy1 + "," + testIP1 + "," + testPort1 + ",0,,,,false,false,false,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			r := &FileRepository{
+				CSVWriter: utils.NewCSVWriter(),
+			}
+			if err := r.EncodeCSV(&buffer, tt.data); err != nil {
+				t.Errorf(expectedErrorButGotMessage, "EncodeCSV()", nil, err)
+			}
+
+			if buffer.String() != tt.want {
+				t.Errorf(expectedButGotMessage, "EncodeCSV()", tt.want, buffer.String())
+			}
+		})
+	}
+}
+
 func TestWriteCSV(t *testing.T) {
 	type fields struct {
 		csvWriter utils.CSVWriterUtilInterface
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/netip"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type GeoIPRepository struct {
+	Path     string
+	ReadFile func(name string) ([]byte, error)
+	Ranges   []GeoIPRange
+}
+
+type GeoIPRange struct {
+	Start   netip.Addr
+	End     netip.Addr
+	Country string
+}
+
+type GeoIPRepositoryInterface interface {
+	Load() error
+	Country(ip string) string
+}
+
+func NewGeoIPRepository(path string, readFile ReadFileFunc) GeoIPRepositoryInterface {
+	return &GeoIPRepository{
+		Path:     path,
+		ReadFile: readFile,
+	}
+}
+
+// Load reads a start,end,country CSV of IP ranges such as the free DB-IP or IPtoASN country databases
+func (r *GeoIPRepository) Load() error {
+	data, err := r.ReadFile(r.Path)
+	if err != nil {
+		return fmt.Errorf("error reading GeoIP database %s: %v", r.Path, err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("error parsing GeoIP database %s: %v", r.Path, err)
+	}
+
+	var ranges []GeoIPRange
+	for i, record := range records {
+		var start, end netip.Addr
+		if len(record) >= 3 {
+			start, _ = netip.ParseAddr(strings.TrimSpace(record[0]))
+			end, _ = netip.ParseAddr(strings.TrimSpace(record[1]))
+		}
+		start, end = start.Unmap(), end.Unmap()
+		if !start.IsValid() || !end.IsValid() || start.Is4() != end.Is4() || end.Less(start) {
+			if i == 0 {
+				continue
+			}
+			return fmt.Errorf("error parsing GeoIP database %s: invalid range on line %d", r.Path, i+1)
+		}
+		ranges = append(ranges, GeoIPRange{Start: start, End: end, Country: strings.ToUpper(strings.TrimSpace(record[2]))})
+	}
+	slices.SortFunc(ranges, func(a, b GeoIPRange) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	r.Ranges = ranges
+	return nil
+}
+
+func (r *GeoIPRepository) Country(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+
+	i := sort.Search(len(r.Ranges), func(i int) bool {
+		return addr.Less(r.Ranges[i].Start)
+	}) - 1
+	if i < 0 || r.Ranges[i].End.Less(addr) {
+		return ""
+	}
+	return r.Ranges[i].Country
+}
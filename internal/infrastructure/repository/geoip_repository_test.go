@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+)
+
+var testGeoIPPath = "storage/geoip.csv"
+
+func TestNewGeoIPRepository(t *testing.T) {
+	geoIPRepository := NewGeoIPRepository(testGeoIPPath, nil)
+	if geoIPRepository == nil {
+		t.Errorf(expectedReturnNonNil, "NewGeoIPRepository", "GeoIPRepositoryInterface")
+	}
+
+	r, ok := geoIPRepository.(*GeoIPRepository)
+	if !ok {
+		t.Errorf(expectedTypeAssertionErrorMessage, "*GeoIPRepository")
+	}
+
+	if r.Path != testGeoIPPath {
+		t.Errorf(expectedButGotMessage, "Path", testGeoIPPath, r.Path)
+	}
+}
+
+func TestGeoIPRepositoryCountry(t *testing.T) {
+	database := "ip_start,ip_end,country\n" +
+		"13.37.0.0,13.37.0.255,us\n" +
+		"1.0.0.0,1.0.0.255,AU\n" +
+		"2001:db8::,2001:db8::ffff,JP\n"
+	r := NewGeoIPRepository(testGeoIPPath, func(name string) ([]byte, error) {
+		return []byte(database), nil
+	})
+	if err := r.Load(); err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "Load()", nil, err)
+	}
+
+	tests := map[string]string{
+		testIP1:          "US",
+		"1.0.0.255":      "AU",
+		"1.0.1.0":        "",
+		"0.255.255.255":  "",
+		"2001:db8::1":    "JP",
+		"::ffff:1.0.0.1": "AU",
+		"invalid":        "",
+	}
+	for ip, want := range tests {
+		if got := r.Country(ip); got != want {
+			t.Errorf(expectedButGotMessage, "Country("+ip+")", want, got)
+		}
+	}
+}
+
+func TestGeoIPRepositoryLoadError(t *testing.T) {
+	tests := []struct {
+		name      string
+		readFile  func(name string) ([]byte, error)
+		wantError error
+	}{
+		{
+			name: "ReadError",
+			readFile: func(name string) ([]byte, error) {
+				return nil, errors.New("permission denied")
+			},
+			wantError: errors.New("error reading GeoIP database " + testGeoIPPath + ": permission denied"),
+		},
+		{
+			name: "InvalidRange",
+			readFile: func(name string) ([]byte, error) {
+				return []byte("1.0.0.0,1.0.0.255,AU\n1.0.1.255,1.0.1.0,AU\n"), nil
+			},
+			wantError: errors.New("error parsing GeoIP database " + testGeoIPPath + ": invalid range on line 2"),
+		},
+		{
+			name: "MixedFamilies",
+			readFile: func(name string) ([]byte, error) {
+				return []byte("1.0.0.0,1.0.0.255,AU\n1.0.0.0,2001:db8::,AU\n"), nil
+			},
+			wantError: errors.New("error parsing GeoIP database " + testGeoIPPath + ": invalid range on line 2"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewGeoIPRepository(testGeoIPPath, tt.readFile).Load()
+			if err == nil || err.Error() != tt.wantError.Error() {
+				t.Errorf(expectedErrorButGotMessage, "Load()", tt.wantError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+type HistoryRepository struct {
+	Path       string
+	Retention  time.Duration
+	Mutex      sync.RWMutex
+	Records    map[string][]entity.CheckRecord
+	Pending    []entity.CheckRecord
+	Expired    int
+	ReadFile   func(name string) ([]byte, error)
+	AppendFile func(name string, data []byte) error
+	WriteFile  func(name string, data []byte) error
+}
+
+type HistoryRepositoryInterface interface {
+	Load() error
+	Record(record entity.CheckRecord)
+	Save() error
+	GetHistory(proxy string, categories []string) *entity.ProxyHistory
+}
+
+type AppendFileFunc func(name string, data []byte) error
+
+func NewHistoryRepository(path string, retention time.Duration, readFile ReadFileFunc, appendFile AppendFileFunc, writeFile WriteFileFunc) HistoryRepositoryInterface {
+	return &HistoryRepository{
+		Path:       path,
+		Retention:  retention,
+		Mutex:      sync.RWMutex{},
+		Records:    map[string][]entity.CheckRecord{},
+		ReadFile:   readFile,
+		AppendFile: appendFile,
+		WriteFile:  writeFile,
+	}
+}
+
+func (r *HistoryRepository) Load() error {
+	data, err := r.ReadFile(r.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error reading history %s: %v", r.Path, err)
+	}
+
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	cutoff := r.cutoff()
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		record := entity.CheckRecord{}
+		if err := json.Unmarshal(line, &record); err != nil {
+			// A run killed while appending leaves a truncated last line behind
+			if i == len(lines)-1 {
+				break
+			}
+			return fmt.Errorf("%s:%d: %v", r.Path, i+1, err)
+		}
+		// Dropped records count as expired, so that the next save compacts them out of the file
+		if expired(record, cutoff) || !r.tracked(record) {
+			r.Expired++
+			continue
+		}
+		r.Records[record.Proxy] = append(r.Records[record.Proxy], record)
+	}
+
+	return nil
+}
+
+// Record keeps the results of the proxies that passed a check at least once, the failures of the candidates that never
+// worked would otherwise make the history grow with every run
+func (r *HistoryRepository) Record(record entity.CheckRecord) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	if !r.tracked(record) {
+		return
+	}
+	r.Records[record.Proxy] = append(r.Records[record.Proxy], record)
+	r.Pending = append(r.Pending, record)
+}
+
+func (r *HistoryRepository) tracked(record entity.CheckRecord) bool {
+	return record.OK || slices.ContainsFunc(r.Records[record.Proxy], func(record entity.CheckRecord) bool {
+		return record.OK
+	})
+}
+
+// Save appends the pending records, or rewrites the whole file without the records that fell out of the retention window
+func (r *HistoryRepository) Save() error {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	var (
+		cutoff   = r.cutoff()
+		retained = 0
+	)
+	for proxy, records := range r.Records {
+		total := len(records)
+		records = slices.DeleteFunc(records, func(record entity.CheckRecord) bool {
+			return expired(record, cutoff)
+		})
+		r.Expired += total - len(records)
+		retained += len(records)
+		if len(records) == 0 {
+			delete(r.Records, proxy)
+		} else {
+			r.Records[proxy] = records
+		}
+	}
+	// The file is only rewritten once enough of it has expired, so that frequent saves keep appending
+	if r.Expired > 0 && r.Expired*4 >= retained {
+		return r.compact()
+	}
+
+	if len(r.Pending) == 0 {
+		return nil
+	}
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	for _, record := range r.Pending {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("error encoding history record: %v", err)
+		}
+	}
+
+	if err := r.AppendFile(r.Path, buffer.Bytes()); err != nil {
+		return fmt.Errorf("error writing history %s: %v", r.Path, err)
+	}
+	r.Pending = nil
+
+	return nil
+}
+
+func (r *HistoryRepository) compact() error {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	for _, proxy := range slices.Sorted(maps.Keys(r.Records)) {
+		for _, record := range r.Records[proxy] {
+			if err := encoder.Encode(record); err != nil {
+				return fmt.Errorf("error encoding history record: %v", err)
+			}
+		}
+	}
+
+	if err := r.WriteFile(r.Path, buffer.Bytes()); err != nil {
+		return fmt.Errorf("error writing history %s: %v", r.Path, err)
+	}
+	r.Pending, r.Expired = nil, 0
+
+	return nil
+}
+
+func (r *HistoryRepository) cutoff() time.Time {
+	if r.Retention <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(-r.Retention)
+}
+
+// Records whose time cannot be parsed are kept rather than silently dropped
+func expired(record entity.CheckRecord, cutoff time.Time) bool {
+	checkedAt, err := time.Parse(time.RFC3339, record.CheckedAt)
+	return err == nil && checkedAt.Before(cutoff)
+}
+
+func (r *HistoryRepository) GetHistory(proxy string, categories []string) *entity.ProxyHistory {
+	r.Mutex.RLock()
+	defer r.Mutex.RUnlock()
+
+	var records []entity.CheckRecord
+	for _, record := range r.Records[proxy] {
+		if slices.Contains(categories, record.Category) {
+			records = append(records, record)
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(records, func(a, b entity.CheckRecord) int {
+		return cmp.Compare(a.CheckedAt, b.CheckedAt)
+	})
+
+	var (
+		history   = &entity.ProxyHistory{}
+		succeeded = 0
+		latency   = 0.0
+	)
+	for _, record := range records {
+		if !record.OK {
+			history.ConsecutiveFailures++
+			continue
+		}
+
+		if history.FirstSeen == "" {
+			history.FirstSeen = record.CheckedAt
+		}
+		history.LastSeen = record.CheckedAt
+		history.ConsecutiveFailures = 0
+		succeeded++
+		latency += record.TimeTaken
+	}
+
+	history.Uptime = math.Round(float64(succeeded)/float64(len(records))*10000) / 100
+	if succeeded > 0 {
+		history.AverageLatency = latency / float64(succeeded)
+	}
+
+	return history
+}
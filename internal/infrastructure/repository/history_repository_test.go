@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+var (
+	testHistoryPath    = "storage/history.jsonl"
+	testHistoryRecords = "" +
+		`{"category":"HTTP","proxy":"` + testProxy1 + `","ok":true,"time_taken":1,"checked_at":"2024-01-01T00:00:00Z"}` + "\n" +
+		`{"category":"HTTP","proxy":"` + testProxy1 + `","ok":false,"checked_at":"2024-01-02T00:00:00Z"}` + "\n" +
+		`{"category":"SOCKS5","proxy":"` + testProxy1 + `","ok":false,"checked_at":"2024-01-02T00:00:00Z"}` + "\n" +
+		`{"category":"HTTP","proxy":"` + testProxy1 + `","ok":true,"time_taken":2,"checked_at":"2024-01-03T00:00:00Z"}` + "\n" +
+		`{"category":"HTTP","proxy":"` + testProxy1 + `","ok":false,"checked_at":"2024-01-04T00:00:00Z"}` + "\n"
+)
+
+func TestNewHistoryRepository(t *testing.T) {
+	historyRepository := NewHistoryRepository(testHistoryPath, 0, nil, nil, nil)
+	if historyRepository == nil {
+		t.Errorf(expectedReturnNonNil, "NewHistoryRepository", "HistoryRepositoryInterface")
+	}
+
+	r, ok := historyRepository.(*HistoryRepository)
+	if !ok {
+		t.Errorf(expectedTypeAssertionErrorMessage, "*HistoryRepository")
+	}
+
+	if r.Path != testHistoryPath {
+		t.Errorf(expectedButGotMessage, "Path", testHistoryPath, r.Path)
+	}
+}
+
+func TestHistoryRepositoryLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		readError   error
+		wantRecords int
+		wantError   error
+	}{
+		{
+			name:        "Records",
+			file:        testHistoryRecords,
+			wantRecords: 5,
+			wantError:   nil,
+		},
+		{
+			name:        "UntrackedFailures",
+			file:        `{"category":"HTTP","proxy":"` + testProxy1 + `","ok":false,"checked_at":"2023-12-31T00:00:00Z"}` + "\n" + testHistoryRecords,
+			wantRecords: 5,
+			wantError:   nil,
+		},
+		{
+			name:        "MissingFile",
+			readError:   fs.ErrNotExist,
+			wantRecords: 0,
+			wantError:   nil,
+		},
+		{
+			name:        "TruncatedLastLine",
+			file:        testHistoryRecords + `{"category":"HTTP","proxy":"` + testProxy1,
+			wantRecords: 5,
+			wantError:   nil,
+		},
+		{
+			name:        "MalformedLine",
+			file:        "{\n" + testHistoryRecords,
+			wantRecords: 0,
+			wantError:   errors.New(testHistoryPath + ":1: unexpected end of JSON input"),
+		},
+		{
+			name:        "ReadError",
+			readError:   fs.ErrPermission,
+			wantRecords: 0,
+			wantError:   errors.New("error reading history " + testHistoryPath + ": permission denied"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readFile := func(name string) ([]byte, error) {
+				return []byte(tt.file), tt.readError
+			}
+			r := NewHistoryRepository(testHistoryPath, 0, readFile, nil, nil).(*HistoryRepository)
+
+			err := r.Load()
+			if (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) || (err == nil && tt.wantError != nil) || (err != nil && tt.wantError == nil) {
+				t.Errorf(expectedErrorButGotMessage, "Load()", tt.wantError, err)
+			}
+
+			if got := len(r.Records[testProxy1]); err == nil && got != tt.wantRecords {
+				t.Errorf(expectedButGotMessage, "records", tt.wantRecords, got)
+			}
+		})
+	}
+}
+
+func TestHistoryRepositorySave(t *testing.T) {
+	var (
+		appended []string
+		record   = entity.CheckRecord{Category: testHTTPCategory, Proxy: testProxy1, OK: true, TimeTaken: 1, CheckedAt: "2024-01-01T00:00:00Z"}
+	)
+	appendFile := func(name string, data []byte) error {
+		if name != testHistoryPath {
+			t.Errorf(expectedButGotMessage, "name", testHistoryPath, name)
+		}
+		appended = append(appended, string(data))
+		return nil
+	}
+	r := NewHistoryRepository(testHistoryPath, 0, nil, appendFile, nil)
+
+	r.Record(record)
+	if err := r.Save(); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "Save()", nil, err)
+	}
+
+	if err := r.Save(); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "Save()", nil, err)
+	}
+
+	want := []string{`{"category":"HTTP","proxy":"` + testProxy1 + `","ok":true,"time_taken":1,"checked_at":"2024-01-01T00:00:00Z"}` + "\n"}
+	if !reflect.DeepEqual(appended, want) {
+		t.Errorf(expectedButGotMessage, "appended", want, appended)
+	}
+
+	wantError := errors.New("error writing history " + testHistoryPath + ": disk full")
+	r = NewHistoryRepository(testHistoryPath, 0, nil, func(name string, data []byte) error {
+		return errors.New("disk full")
+	}, nil)
+	r.Record(record)
+	if err := r.Save(); err == nil || err.Error() != wantError.Error() {
+		t.Errorf(expectedErrorButGotMessage, "Save()", wantError, err)
+	}
+}
+
+func TestHistoryRepositoryRecord(t *testing.T) {
+	r := NewHistoryRepository(testHistoryPath, 0, nil, nil, nil).(*HistoryRepository)
+	r.Record(entity.CheckRecord{Category: testHTTPCategory, Proxy: testProxy1, OK: false, CheckedAt: "2024-01-01T00:00:00Z"})
+	r.Record(entity.CheckRecord{Category: testHTTPCategory, Proxy: testProxy2, OK: true, TimeTaken: 1, CheckedAt: "2024-01-01T00:00:00Z"})
+	r.Record(entity.CheckRecord{Category: testHTTPCategory, Proxy: testProxy2, OK: false, CheckedAt: "2024-01-02T00:00:00Z"})
+
+	if got := len(r.Records[testProxy1]); got != 0 {
+		t.Errorf(expectedButGotMessage, "records", 0, got)
+	}
+
+	if got := len(r.Records[testProxy2]); got != 2 {
+		t.Errorf(expectedButGotMessage, "records", 2, got)
+	}
+
+	if got := len(r.Pending); got != 2 {
+		t.Errorf(expectedButGotMessage, "pending", 2, got)
+	}
+}
+
+func TestHistoryRepositoryRetention(t *testing.T) {
+	var (
+		recent   = time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+		file     = testHistoryRecords + `{"category":"HTTP","proxy":"` + testProxy2 + `","ok":true,"time_taken":1,"checked_at":"` + recent + `"}` + "\n"
+		appended []string
+		written  []string
+	)
+	readFile := func(name string) ([]byte, error) {
+		return []byte(file), nil
+	}
+	appendFile := func(name string, data []byte) error {
+		appended = append(appended, string(data))
+		return nil
+	}
+	writeFile := func(name string, data []byte) error {
+		if name != testHistoryPath {
+			t.Errorf(expectedButGotMessage, "name", testHistoryPath, name)
+		}
+		written = append(written, string(data))
+		return nil
+	}
+	r := NewHistoryRepository(testHistoryPath, 24*time.Hour, readFile, appendFile, writeFile).(*HistoryRepository)
+
+	if err := r.Load(); err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "Load()", nil, err)
+	}
+	if got := len(r.Records[testProxy1]); got != 0 {
+		t.Errorf(expectedButGotMessage, "expired records", 0, got)
+	}
+	if got := len(r.Records[testProxy2]); got != 1 {
+		t.Errorf(expectedButGotMessage, "records", 1, got)
+	}
+
+	r.Record(entity.CheckRecord{Category: testHTTPCategory, Proxy: testProxy2, OK: false, CheckedAt: recent})
+	if err := r.Save(); err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "Save()", nil, err)
+	}
+	want := []string{
+		`{"category":"HTTP","proxy":"` + testProxy2 + `","ok":true,"time_taken":1,"checked_at":"` + recent + `"}` + "\n" +
+			`{"category":"HTTP","proxy":"` + testProxy2 + `","ok":false,"checked_at":"` + recent + `"}` + "\n",
+	}
+	if !reflect.DeepEqual(written, want) || appended != nil {
+		t.Errorf(expectedButGotMessage, "written", want, written)
+	}
+
+	// Once compacted, new records are appended again
+	r.Record(entity.CheckRecord{Category: testHTTPCategory, Proxy: testProxy2, OK: true, CheckedAt: recent})
+	if err := r.Save(); err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "Save()", nil, err)
+	}
+	if len(written) != 1 || len(appended) != 1 {
+		t.Errorf(expectedButGotMessage, "writes", "1 rewrite and 1 append", fmt.Sprintf("%d rewrites and %d appends", len(written), len(appended)))
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	readFile := func(name string) ([]byte, error) {
+		return []byte(testHistoryRecords), nil
+	}
+	r := NewHistoryRepository(testHistoryPath, 0, readFile, nil, nil)
+	if err := r.Load(); err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "Load()", nil, err)
+	}
+
+	tests := []struct {
+		name       string
+		proxy      string
+		categories []string
+		want       *entity.ProxyHistory
+	}{
+		{
+			name:       "HTTP",
+			proxy:      testProxy1,
+			categories: []string{testHTTPCategory},
+			want:       &entity.ProxyHistory{Uptime: 50, FirstSeen: "2024-01-01T00:00:00Z", LastSeen: "2024-01-03T00:00:00Z", ConsecutiveFailures: 1, AverageLatency: 1.5},
+		},
+		{
+			name:       "AllCategories",
+			proxy:      testProxy1,
+			categories: []string{testHTTPCategory, testSOCKS5Category},
+			want:       &entity.ProxyHistory{Uptime: 40, FirstSeen: "2024-01-01T00:00:00Z", LastSeen: "2024-01-03T00:00:00Z", ConsecutiveFailures: 1, AverageLatency: 1.5},
+		},
+		{
+			name:       "NeverWorked",
+			proxy:      testProxy1,
+			categories: []string{testSOCKS5Category},
+			want:       &entity.ProxyHistory{Uptime: 0, ConsecutiveFailures: 1},
+		},
+		{
+			name:       "Unknown",
+			proxy:      testProxy2,
+			categories: []string{testHTTPCategory},
+			want:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.GetHistory(tt.proxy, tt.categories); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "GetHistory()", tt.want, got)
+			}
+		})
+	}
+}
@@ -9,37 +9,39 @@ import (
 )
 
 type ProxyRepository struct {
-	Mutex              sync.RWMutex
-	AllClassicView     []string
-	HTTPClassicView    []string
-	HTTPSClassicView   []string
-	SOCKS4ClassicView  []string
-	SOCKS5ClassicView  []string
-	AllAdvancedView    []entity.AdvancedProxy
-	HTTPAdvancedView   []entity.Proxy
-	HTTPSAdvancedView  []entity.Proxy
-	SOCKS4AdvancedView []entity.Proxy
-	SOCKS5AdvancedView []entity.Proxy
+	Mutex           sync.RWMutex
+	AllClassicView  []string
+	AllAdvancedView []entity.AdvancedProxy
+	ClassicViews    map[string][]string
+	AdvancedViews   map[string][]entity.Proxy
 }
 
 type ProxyRepositoryInterface interface {
 	Store(proxy *entity.Proxy)
 	GetAllClassicView() []string
-	GetHTTPClassicView() []string
-	GetHTTPSClassicView() []string
-	GetSOCKS4ClassicView() []string
-	GetSOCKS5ClassicView() []string
 	GetAllAdvancedView() []entity.AdvancedProxy
-	GetHTTPAdvancedView() []entity.Proxy
-	GetHTTPSAdvancedView() []entity.Proxy
-	GetSOCKS4AdvancedView() []entity.Proxy
-	GetSOCKS5AdvancedView() []entity.Proxy
+	GetClassicView(category string) []string
+	GetAdvancedView(category string) []entity.Proxy
+	GetAnonymityClassicView(anonymity string) []string
+	GetAnonymityAdvancedView(anonymity string) []entity.AdvancedProxy
+	GetRotatingClassicView() []string
+	GetRotatingAdvancedView() []entity.AdvancedProxy
+	SetHistory(proxy string, history *entity.ProxyHistory)
 }
 
-func NewProxyRepository() ProxyRepositoryInterface {
-	return &ProxyRepository{
-		Mutex: sync.RWMutex{},
+var anonymityLevels = []string{"transparent", "anonymous", "elite"}
+
+func NewProxyRepository(proxyCategories []string) ProxyRepositoryInterface {
+	r := &ProxyRepository{
+		Mutex:         sync.RWMutex{},
+		ClassicViews:  make(map[string][]string, len(proxyCategories)),
+		AdvancedViews: make(map[string][]entity.Proxy, len(proxyCategories)),
+	}
+	for _, category := range proxyCategories {
+		r.ClassicViews[category] = nil
+		r.AdvancedViews[category] = nil
 	}
+	return r
 }
 
 func (r *ProxyRepository) Store(proxy *entity.Proxy) {
@@ -55,17 +57,43 @@ func (r *ProxyRepository) Store(proxy *entity.Proxy) {
 				(*advancedList)[n].TimeTaken = proxy.TimeTaken
 			}
 
+			// Keep the least anonymous level seen across categories
+			if proxy.Anonymity != "" && ((*advancedList)[n].Anonymity == "" ||
+				slices.Index(anonymityLevels, proxy.Anonymity) < slices.Index(anonymityLevels, (*advancedList)[n].Anonymity)) {
+				(*advancedList)[n].Anonymity = proxy.Anonymity
+			}
+
+			if (*advancedList)[n].ExitIP == "" {
+				(*advancedList)[n].ExitIP = proxy.ExitIP
+			}
+			(*advancedList)[n].IsRotating = (*advancedList)[n].IsRotating || proxy.IsRotating
+			(*advancedList)[n].IsMITM = (*advancedList)[n].IsMITM || proxy.IsMITM
+			(*advancedList)[n].RemoteDNS = (*advancedList)[n].RemoteDNS || proxy.RemoteDNS
+			for _, port := range proxy.ConnectPorts {
+				if m, found := slices.BinarySearch((*advancedList)[n].ConnectPorts, port); !found {
+					(*advancedList)[n].ConnectPorts = slices.Insert((*advancedList)[n].ConnectPorts, m, port)
+				}
+			}
+
 			if m, found := slices.BinarySearch((*advancedList)[n].Categories, proxy.Category); !found {
 				(*advancedList)[n].Categories = slices.Insert((*advancedList)[n].Categories, m, proxy.Category)
 			}
 		} else {
-			*classicList = append(*classicList, proxy.Proxy)
+			*classicList = append(*classicList, proxy.WithCredentials())
 			*advancedList = slices.Insert(*advancedList, n, entity.AdvancedProxy{
-				Proxy:     proxy.Proxy,
-				IP:        proxy.IP,
-				Port:      proxy.Port,
-				TimeTaken: proxy.TimeTaken,
-				CheckedAt: proxy.CheckedAt,
+				Proxy:        proxy.Proxy,
+				IP:           proxy.IP,
+				Port:         proxy.Port,
+				Username:     proxy.Username,
+				Password:     proxy.Password,
+				TimeTaken:    proxy.TimeTaken,
+				CheckedAt:    proxy.CheckedAt,
+				Anonymity:    proxy.Anonymity,
+				ExitIP:       proxy.ExitIP,
+				IsRotating:   proxy.IsRotating,
+				IsMITM:       proxy.IsMITM,
+				RemoteDNS:    proxy.RemoteDNS,
+				ConnectPorts: slices.Clone(proxy.ConnectPorts),
 				Categories: []string{
 					proxy.Category,
 				},
@@ -73,19 +101,9 @@ func (r *ProxyRepository) Store(proxy *entity.Proxy) {
 		}
 	}
 
-	switch proxy.Category {
-	case "HTTP":
-		r.HTTPClassicView = append(r.HTTPClassicView, proxy.Proxy)
-		r.HTTPAdvancedView = append(r.HTTPAdvancedView, *proxy)
-	case "HTTPS":
-		r.HTTPSClassicView = append(r.HTTPSClassicView, proxy.Proxy)
-		r.HTTPSAdvancedView = append(r.HTTPSAdvancedView, *proxy)
-	case "SOCKS4":
-		r.SOCKS4ClassicView = append(r.SOCKS4ClassicView, proxy.Proxy)
-		r.SOCKS4AdvancedView = append(r.SOCKS4AdvancedView, *proxy)
-	case "SOCKS5":
-		r.SOCKS5ClassicView = append(r.SOCKS5ClassicView, proxy.Proxy)
-		r.SOCKS5AdvancedView = append(r.SOCKS5AdvancedView, *proxy)
+	if classicView, found := r.ClassicViews[proxy.Category]; found {
+		r.ClassicViews[proxy.Category] = append(classicView, proxy.WithCredentials())
+		r.AdvancedViews[proxy.Category] = append(r.AdvancedViews[proxy.Category], *proxy)
 	}
 
 	updateProxyAll(proxy, &r.AllClassicView, &r.AllAdvancedView)
@@ -95,38 +113,68 @@ func (r *ProxyRepository) GetAllClassicView() []string {
 	return r.AllClassicView
 }
 
-func (r *ProxyRepository) GetHTTPClassicView() []string {
-	return r.HTTPClassicView
+func (r *ProxyRepository) GetAllAdvancedView() []entity.AdvancedProxy {
+	return r.AllAdvancedView
 }
 
-func (r *ProxyRepository) GetHTTPSClassicView() []string {
-	return r.HTTPSClassicView
+func (r *ProxyRepository) GetClassicView(category string) []string {
+	return r.ClassicViews[category]
 }
 
-func (r *ProxyRepository) GetSOCKS4ClassicView() []string {
-	return r.SOCKS4ClassicView
+func (r *ProxyRepository) GetAdvancedView(category string) []entity.Proxy {
+	return r.AdvancedViews[category]
 }
 
-func (r *ProxyRepository) GetSOCKS5ClassicView() []string {
-	return r.SOCKS5ClassicView
+func (r *ProxyRepository) GetAnonymityClassicView(anonymity string) []string {
+	var view []string
+	for _, proxy := range r.GetAnonymityAdvancedView(anonymity) {
+		view = append(view, proxy.WithCredentials())
+	}
+	return view
 }
 
-func (r *ProxyRepository) GetAllAdvancedView() []entity.AdvancedProxy {
-	return r.AllAdvancedView
+func (r *ProxyRepository) GetAnonymityAdvancedView(anonymity string) []entity.AdvancedProxy {
+	var view []entity.AdvancedProxy
+	for _, proxy := range r.AllAdvancedView {
+		if proxy.Anonymity == anonymity {
+			view = append(view, proxy)
+		}
+	}
+	return view
 }
 
-func (r *ProxyRepository) GetHTTPAdvancedView() []entity.Proxy {
-	return r.HTTPAdvancedView
+func (r *ProxyRepository) GetRotatingClassicView() []string {
+	var view []string
+	for _, proxy := range r.GetRotatingAdvancedView() {
+		view = append(view, proxy.WithCredentials())
+	}
+	return view
 }
 
-func (r *ProxyRepository) GetHTTPSAdvancedView() []entity.Proxy {
-	return r.HTTPSAdvancedView
+func (r *ProxyRepository) GetRotatingAdvancedView() []entity.AdvancedProxy {
+	var view []entity.AdvancedProxy
+	for _, proxy := range r.AllAdvancedView {
+		if proxy.IsRotating {
+			view = append(view, proxy)
+		}
+	}
+	return view
 }
 
-func (r *ProxyRepository) GetSOCKS4AdvancedView() []entity.Proxy {
-	return r.SOCKS4AdvancedView
-}
+func (r *ProxyRepository) SetHistory(proxy string, history *entity.ProxyHistory) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	n, found := slices.BinarySearchFunc(r.AllAdvancedView, entity.AdvancedProxy{Proxy: proxy}, func(a, b entity.AdvancedProxy) int {
+		return cmp.Compare(a.Proxy, b.Proxy)
+	})
+	if !found || history == nil {
+		return
+	}
 
-func (r *ProxyRepository) GetSOCKS5AdvancedView() []entity.Proxy {
-	return r.SOCKS5AdvancedView
+	r.AllAdvancedView[n].Uptime = history.Uptime
+	r.AllAdvancedView[n].FirstSeen = history.FirstSeen
+	r.AllAdvancedView[n].LastSeen = history.LastSeen
+	r.AllAdvancedView[n].ConsecutiveFailures = history.ConsecutiveFailures
+	r.AllAdvancedView[n].AverageLatency = history.AverageLatency
 }
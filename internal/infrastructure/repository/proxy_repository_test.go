@@ -14,13 +14,16 @@ func TestNewProxyRepository(t *testing.T) {
 	}{
 		{
 			name: "Success",
-			want: &ProxyRepository{},
+			want: &ProxyRepository{
+				ClassicViews:  map[string][]string{testHTTPCategory: nil, testHTTPSCategory: nil, testSOCKS4Category: nil, testSOCKS5Category: nil},
+				AdvancedViews: map[string][]entity.Proxy{testHTTPCategory: nil, testHTTPSCategory: nil, testSOCKS4Category: nil, testSOCKS5Category: nil},
+			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			proxyRepository := NewProxyRepository()
+			proxyRepository := NewProxyRepository(testProxyCategories)
 
 			if proxyRepository == nil {
 				t.Errorf(expectedReturnNonNil, "NewProxyRepository", "ProxyRepositoryInterface")
@@ -40,22 +43,25 @@ func TestNewProxyRepository(t *testing.T) {
 
 func TestProxyRepository(t *testing.T) {
 	type fields struct {
-		allClassicView     []string
-		httpClassicView    []string
-		httpsClassicView   []string
-		socks4ClassicView  []string
-		socks5ClassicView  []string
-		allAdvancedView    []entity.AdvancedProxy
-		httpAdvancedView   []entity.Proxy
-		httpsAdvancedView  []entity.Proxy
-		socks4AdvancedView []entity.Proxy
-		socks5AdvancedView []entity.Proxy
+		allClassicView  []string
+		allAdvancedView []entity.AdvancedProxy
+		classicViews    map[string][]string
+		advancedViews   map[string][]entity.Proxy
 	}
 
 	type args struct {
 		proxy entity.Proxy
 	}
 
+	emptyFields := func() fields {
+		return fields{
+			allClassicView:  []string{},
+			allAdvancedView: []entity.AdvancedProxy{},
+			classicViews:    map[string][]string{testHTTPCategory: {}, testHTTPSCategory: {}, testSOCKS4Category: {}, testSOCKS5Category: {}},
+			advancedViews:   map[string][]entity.Proxy{testHTTPCategory: {}, testHTTPSCategory: {}, testSOCKS4Category: {}, testSOCKS5Category: {}},
+		}
+	}
+
 	tests := []struct {
 		name    string
 		fields  fields
@@ -64,158 +70,88 @@ func TestProxyRepository(t *testing.T) {
 		wantErr error
 	}{
 		{
-			name: "StoreHTTPProxy",
-			fields: fields{
-				allClassicView:     []string{},
-				httpClassicView:    []string{},
-				httpsClassicView:   []string{},
-				socks4ClassicView:  []string{},
-				socks5ClassicView:  []string{},
-				allAdvancedView:    []entity.AdvancedProxy{},
-				httpAdvancedView:   []entity.Proxy{},
-				httpsAdvancedView:  []entity.Proxy{},
-				socks4AdvancedView: []entity.Proxy{},
-				socks5AdvancedView: []entity.Proxy{},
-			},
+			name:   "StoreHTTPProxy",
+			fields: emptyFields(),
 			args: args{
 				proxy: testProxyEntity1,
 			},
 			want: fields{
-				allClassicView: []string{
-					testProxy1,
-				},
-				httpClassicView: []string{
-					testProxy1,
-				},
-				httpsClassicView:  []string{},
-				socks4ClassicView: []string{},
-				socks5ClassicView: []string{},
-				allAdvancedView: []entity.AdvancedProxy{
-					testAdvancedProxyEntity1,
-				},
-				httpAdvancedView: []entity.Proxy{
-					testProxyEntity1,
-				},
-				httpsAdvancedView:  []entity.Proxy{},
-				socks4AdvancedView: []entity.Proxy{},
-				socks5AdvancedView: []entity.Proxy{},
+				allClassicView:  []string{testProxy1},
+				allAdvancedView: []entity.AdvancedProxy{testAdvancedProxyEntity1},
+				classicViews:    map[string][]string{testHTTPCategory: {testProxy1}, testHTTPSCategory: {}, testSOCKS4Category: {}, testSOCKS5Category: {}},
+				advancedViews:   map[string][]entity.Proxy{testHTTPCategory: {testProxyEntity1}, testHTTPSCategory: {}, testSOCKS4Category: {}, testSOCKS5Category: {}},
 			},
 			wantErr: nil,
 		},
 		{
-			name: "StoreHTTPSProxy",
-			fields: fields{
-				allClassicView:     []string{},
-				httpClassicView:    []string{},
-				httpsClassicView:   []string{},
-				socks4ClassicView:  []string{},
-				socks5ClassicView:  []string{},
-				allAdvancedView:    []entity.AdvancedProxy{},
-				httpAdvancedView:   []entity.Proxy{},
-				httpsAdvancedView:  []entity.Proxy{},
-				socks4AdvancedView: []entity.Proxy{},
-				socks5AdvancedView: []entity.Proxy{},
-			},
+			name:   "StoreHTTPSProxy",
+			fields: emptyFields(),
 			args: args{
 				proxy: testProxyEntity2,
 			},
 			want: fields{
-				allClassicView: []string{
-					testProxy2,
-				},
-				httpClassicView: []string{},
-				httpsClassicView: []string{
-					testProxy2,
-				},
-				socks4ClassicView: []string{},
-				socks5ClassicView: []string{},
-				allAdvancedView: []entity.AdvancedProxy{
-					testAdvancedProxyEntity2,
-				},
-				httpAdvancedView: []entity.Proxy{},
-				httpsAdvancedView: []entity.Proxy{
-					testProxyEntity2,
-				},
-				socks4AdvancedView: []entity.Proxy{},
-				socks5AdvancedView: []entity.Proxy{},
+				allClassicView:  []string{testProxy2},
+				allAdvancedView: []entity.AdvancedProxy{testAdvancedProxyEntity2},
+				classicViews:    map[string][]string{testHTTPCategory: {}, testHTTPSCategory: {testProxy2}, testSOCKS4Category: {}, testSOCKS5Category: {}},
+				advancedViews:   map[string][]entity.Proxy{testHTTPCategory: {}, testHTTPSCategory: {testProxyEntity2}, testSOCKS4Category: {}, testSOCKS5Category: {}},
 			},
 			wantErr: nil,
 		},
 		{
-			name: "StoreSOCKS4Proxy",
-			fields: fields{
-				allClassicView:     []string{},
-				httpClassicView:    []string{},
-				httpsClassicView:   []string{},
-				socks4ClassicView:  []string{},
-				socks5ClassicView:  []string{},
-				allAdvancedView:    []entity.AdvancedProxy{},
-				httpAdvancedView:   []entity.Proxy{},
-				httpsAdvancedView:  []entity.Proxy{},
-				socks4AdvancedView: []entity.Proxy{},
-				socks5AdvancedView: []entity.Proxy{},
-			},
+			name:   "StoreSOCKS4Proxy",
+			fields: emptyFields(),
 			args: args{
 				proxy: testProxyEntity3,
 			},
 			want: fields{
-				allClassicView: []string{
-					testProxy3,
-				},
-				httpClassicView:  []string{},
-				httpsClassicView: []string{},
-				socks4ClassicView: []string{
-					testProxy3,
-				},
-				socks5ClassicView: []string{},
-				allAdvancedView: []entity.AdvancedProxy{
-					testAdvancedProxyEntity3,
-				},
-				httpAdvancedView:  []entity.Proxy{},
-				httpsAdvancedView: []entity.Proxy{},
-				socks4AdvancedView: []entity.Proxy{
-					testProxyEntity3,
-				},
-				socks5AdvancedView: []entity.Proxy{},
+				allClassicView:  []string{testProxy3},
+				allAdvancedView: []entity.AdvancedProxy{testAdvancedProxyEntity3},
+				classicViews:    map[string][]string{testHTTPCategory: {}, testHTTPSCategory: {}, testSOCKS4Category: {testProxy3}, testSOCKS5Category: {}},
+				advancedViews:   map[string][]entity.Proxy{testHTTPCategory: {}, testHTTPSCategory: {}, testSOCKS4Category: {testProxyEntity3}, testSOCKS5Category: {}},
 			},
 			wantErr: nil,
 		},
 		{
-			name: "StoreSOCKS5Proxy",
-			fields: fields{
-				allClassicView:     []string{},
-				httpClassicView:    []string{},
-				httpsClassicView:   []string{},
-				socks4ClassicView:  []string{},
-				socks5ClassicView:  []string{},
-				allAdvancedView:    []entity.AdvancedProxy{},
-				httpAdvancedView:   []entity.Proxy{},
-				httpsAdvancedView:  []entity.Proxy{},
-				socks4AdvancedView: []entity.Proxy{},
-				socks5AdvancedView: []entity.Proxy{},
-			},
+			name:   "StoreSOCKS5Proxy",
+			fields: emptyFields(),
 			args: args{
 				proxy: testProxyEntity4,
 			},
 			want: fields{
-				allClassicView: []string{
-					testProxy4,
-				},
-				httpClassicView:   []string{},
-				httpsClassicView:  []string{},
-				socks4ClassicView: []string{},
-				socks5ClassicView: []string{
-					testProxy4,
+				allClassicView:  []string{testProxy4},
+				allAdvancedView: []entity.AdvancedProxy{testAdvancedProxyEntity4},
+				classicViews:    map[string][]string{testHTTPCategory: {}, testHTTPSCategory: {}, testSOCKS4Category: {}, testSOCKS5Category: {testProxy4}},
+				advancedViews:   map[string][]entity.Proxy{testHTTPCategory: {}, testHTTPSCategory: {}, testSOCKS4Category: {}, testSOCKS5Category: {testProxyEntity4}},
+			},
+			wantErr: nil,
+		},
+		{
+			name:   "StoreUnregisteredCategory",
+			fields: emptyFields(),
+			args: args{
+				proxy: entity.Proxy{
+					Category:  "SOCKS6",
+					IP:        testIP1,
+					Port:      testPort1,
+					Proxy:     testProxy1,
+					TimeTaken: testTimeTaken,
+					CheckedAt: testCheckedAt,
 				},
+			},
+			want: fields{
+				allClassicView: []string{testProxy1},
 				allAdvancedView: []entity.AdvancedProxy{
-					testAdvancedProxyEntity4,
-				},
-				httpAdvancedView:   []entity.Proxy{},
-				httpsAdvancedView:  []entity.Proxy{},
-				socks4AdvancedView: []entity.Proxy{},
-				socks5AdvancedView: []entity.Proxy{
-					testProxyEntity4,
+					{
+						Proxy:      testProxy1,
+						IP:         testIP1,
+						Port:       testPort1,
+						TimeTaken:  testTimeTaken,
+						CheckedAt:  testCheckedAt,
+						Categories: []string{"SOCKS6"},
+					},
 				},
+				classicViews:  map[string][]string{testHTTPCategory: {}, testHTTPSCategory: {}, testSOCKS4Category: {}, testSOCKS5Category: {}},
+				advancedViews: map[string][]entity.Proxy{testHTTPCategory: {}, testHTTPSCategory: {}, testSOCKS4Category: {}, testSOCKS5Category: {}},
 			},
 			wantErr: nil,
 		},
@@ -226,26 +162,12 @@ func TestProxyRepository(t *testing.T) {
 					testProxy3,
 					testProxy4,
 				},
-				httpClassicView:  []string{},
-				httpsClassicView: []string{},
-				socks4ClassicView: []string{
-					testProxy3,
-				},
-				socks5ClassicView: []string{
-					testProxy4,
-				},
 				allAdvancedView: []entity.AdvancedProxy{
 					testAdvancedProxyEntity3,
 					testAdvancedProxyEntity4,
 				},
-				httpAdvancedView:  []entity.Proxy{},
-				httpsAdvancedView: []entity.Proxy{},
-				socks4AdvancedView: []entity.Proxy{
-					testProxyEntity3,
-				},
-				socks5AdvancedView: []entity.Proxy{
-					testProxyEntity4,
-				},
+				classicViews:  map[string][]string{testHTTPCategory: {}, testHTTPSCategory: {}, testSOCKS4Category: {testProxy3}, testSOCKS5Category: {testProxy4}},
+				advancedViews: map[string][]entity.Proxy{testHTTPCategory: {}, testHTTPSCategory: {}, testSOCKS4Category: {testProxyEntity3}, testSOCKS5Category: {testProxyEntity4}},
 			},
 			args: args{
 				proxy: entity.Proxy{
@@ -262,16 +184,6 @@ func TestProxyRepository(t *testing.T) {
 					testProxy3,
 					testProxy4,
 				},
-				httpClassicView: []string{
-					testProxy4,
-				},
-				httpsClassicView: []string{},
-				socks4ClassicView: []string{
-					testProxy3,
-				},
-				socks5ClassicView: []string{
-					testProxy4,
-				},
 				allAdvancedView: []entity.AdvancedProxy{
 					testAdvancedProxyEntity3,
 					{
@@ -286,22 +198,21 @@ func TestProxyRepository(t *testing.T) {
 						},
 					},
 				},
-				httpAdvancedView: []entity.Proxy{
-					{
-						Category:  testHTTPCategory,
-						IP:        testIP4,
-						Port:      testPort4,
-						Proxy:     testProxy4,
-						TimeTaken: testTimeTaken,
-						CheckedAt: testCheckedAt,
+				classicViews: map[string][]string{testHTTPCategory: {testProxy4}, testHTTPSCategory: {}, testSOCKS4Category: {testProxy3}, testSOCKS5Category: {testProxy4}},
+				advancedViews: map[string][]entity.Proxy{
+					testHTTPCategory: {
+						{
+							Category:  testHTTPCategory,
+							IP:        testIP4,
+							Port:      testPort4,
+							Proxy:     testProxy4,
+							TimeTaken: testTimeTaken,
+							CheckedAt: testCheckedAt,
+						},
 					},
-				},
-				httpsAdvancedView: []entity.Proxy{},
-				socks4AdvancedView: []entity.Proxy{
-					testProxyEntity3,
-				},
-				socks5AdvancedView: []entity.Proxy{
-					testProxyEntity4,
+					testHTTPSCategory:  {},
+					testSOCKS4Category: {testProxyEntity3},
+					testSOCKS5Category: {testProxyEntity4},
 				},
 			},
 			wantErr: nil,
@@ -311,16 +222,10 @@ func TestProxyRepository(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := &ProxyRepository{
-				AllClassicView:     tt.fields.allClassicView,
-				HTTPClassicView:    tt.fields.httpClassicView,
-				HTTPSClassicView:   tt.fields.httpsClassicView,
-				SOCKS4ClassicView:  tt.fields.socks4ClassicView,
-				SOCKS5ClassicView:  tt.fields.socks5ClassicView,
-				AllAdvancedView:    tt.fields.allAdvancedView,
-				HTTPAdvancedView:   tt.fields.httpAdvancedView,
-				HTTPSAdvancedView:  tt.fields.httpsAdvancedView,
-				SOCKS4AdvancedView: tt.fields.socks4AdvancedView,
-				SOCKS5AdvancedView: tt.fields.socks5AdvancedView,
+				AllClassicView:  tt.fields.allClassicView,
+				AllAdvancedView: tt.fields.allAdvancedView,
+				ClassicViews:    tt.fields.classicViews,
+				AdvancedViews:   tt.fields.advancedViews,
 			}
 			r.Store(&tt.args.proxy)
 
@@ -328,16 +233,10 @@ func TestProxyRepository(t *testing.T) {
 				got  interface{}
 				want interface{}
 			}{
-				"GetAllClassicView()":     {r.AllClassicView, tt.want.allClassicView},
-				"GetHTTPClassicView()":    {r.HTTPClassicView, tt.want.httpClassicView},
-				"GetHTTPSClassicView()":   {r.HTTPSClassicView, tt.want.httpsClassicView},
-				"GetSOCKS4ClassicView()":  {r.SOCKS4ClassicView, tt.want.socks4ClassicView},
-				"GetSOCKS5ClassicView()":  {r.SOCKS5ClassicView, tt.want.socks5ClassicView},
-				"GetAllAdvancedView()":    {r.AllAdvancedView, tt.want.allAdvancedView},
-				"GetHTTPAdvancedView()":   {r.HTTPAdvancedView, tt.want.httpAdvancedView},
-				"GetHTTPSAdvancedView()":  {r.HTTPSAdvancedView, tt.want.httpsAdvancedView},
-				"GetSOCKS4AdvancedView()": {r.SOCKS4AdvancedView, tt.want.socks4AdvancedView},
-				"GetSOCKS5AdvancedView()": {r.SOCKS5AdvancedView, tt.want.socks5AdvancedView},
+				"GetAllClassicView()":  {r.AllClassicView, tt.want.allClassicView},
+				"GetAllAdvancedView()": {r.AllAdvancedView, tt.want.allAdvancedView},
+				"ClassicViews":         {r.ClassicViews, tt.want.classicViews},
+				"AdvancedViews":        {r.AdvancedViews, tt.want.advancedViews},
 			}
 			for name, v := range views {
 				if !reflect.DeepEqual(v.got, v.want) {
@@ -348,201 +247,158 @@ func TestProxyRepository(t *testing.T) {
 	}
 }
 
-func TestGetAllClassicView(t *testing.T) {
-	tests := []struct {
-		name  string
-		setup func() *ProxyRepository
-		want  []string
-	}{
-		{
-			name: "EmptyAllProxies",
-			setup: func() *ProxyRepository {
-				return &ProxyRepository{
-					AllClassicView: []string{},
-				}
-			},
-			want: []string{},
-		},
-		{
-			name: "WithAllProxies",
-			setup: func() *ProxyRepository {
-				r := &ProxyRepository{}
-				r.AllClassicView = []string{
-					testProxy1,
-					testProxy2,
-					testProxy3,
-					testProxy4,
-				}
-				return r
-			},
-			want: []string{
-				testProxy1,
-				testProxy2,
-				testProxy3,
-				testProxy4,
-			},
-		},
+func TestStoreWithCredentials(t *testing.T) {
+	r := NewProxyRepository(testProxyCategories)
+	r.Store(&entity.Proxy{
+		Category: testHTTPCategory,
+		Proxy:    testProxy1,
+		IP:       testIP1,
+		Port:     testPort1,
+		Username: "user",
+		Password: "pass",
+	})
+
+	want := []string{"user:pass@" + testProxy1}
+	if !reflect.DeepEqual(r.GetClassicView(testHTTPCategory), want) {
+		t.Errorf(expectedButGotMessage, "GetClassicView()", want, r.GetClassicView(testHTTPCategory))
+	}
+	if !reflect.DeepEqual(r.GetAllClassicView(), want) {
+		t.Errorf(expectedButGotMessage, "GetAllClassicView()", want, r.GetAllClassicView())
+	}
+	if got := r.GetAllAdvancedView()[0]; got.Proxy != testProxy1 || got.Username != "user" || got.Password != "pass" {
+		t.Errorf(expectedButGotMessage, "GetAllAdvancedView()", testProxy1, got)
 	}
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			r := tt.setup()
-			got := r.GetAllClassicView()
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf(expectedButGotMessage, "GetAllClassicView()", tt.want, got)
-			}
-		})
+func TestStoreMergesConnectPorts(t *testing.T) {
+	r := NewProxyRepository(testProxyCategories)
+	r.Store(&entity.Proxy{Category: testHTTPCategory, Proxy: testProxy1, IP: testIP1, Port: testPort1, ConnectPorts: []int{443}})
+	r.Store(&entity.Proxy{Category: testHTTPSCategory, Proxy: testProxy1, IP: testIP1, Port: testPort1, ConnectPorts: []int{22, 443}})
+
+	want := []int{22, 443}
+	if got := r.GetAllAdvancedView()[0].ConnectPorts; !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "ConnectPorts", want, got)
+	}
+	if got := r.GetAdvancedView(testHTTPCategory)[0].ConnectPorts; !reflect.DeepEqual(got, []int{443}) {
+		t.Errorf(expectedButGotMessage, "GetAdvancedView() ConnectPorts", []int{443}, got)
 	}
 }
 
-func TestGetHTTPClassicView(t *testing.T) {
+func TestGetAnonymityView(t *testing.T) {
+	r := &ProxyRepository{}
+	r.Store(&entity.Proxy{Category: testHTTPCategory, Proxy: testProxy1, IP: testIP1, Port: testPort1, Anonymity: "elite"})
+	r.Store(&entity.Proxy{Category: testHTTPSCategory, Proxy: testProxy1, IP: testIP1, Port: testPort1, Anonymity: "anonymous"})
+	r.Store(&entity.Proxy{Category: testHTTPCategory, Proxy: testProxy2, IP: testIP2, Port: testPort2, Anonymity: "elite"})
+	r.Store(&entity.Proxy{Category: testSOCKS5Category, Proxy: testProxy2, IP: testIP2, Port: testPort2})
+
 	tests := []struct {
-		name  string
-		setup func() *ProxyRepository
-		want  []string
+		name      string
+		anonymity string
+		want      []string
 	}{
 		{
-			name: "EmptyHTTPProxies",
-			setup: func() *ProxyRepository {
-				return &ProxyRepository{
-					HTTPClassicView: []string{},
-				}
-			},
-			want: []string{},
+			name:      "Elite",
+			anonymity: "elite",
+			want:      []string{testProxy2},
 		},
 		{
-			name: "WithHTTPProxies",
-			setup: func() *ProxyRepository {
-				r := &ProxyRepository{}
-				r.HTTPClassicView = []string{
-					testProxy1,
-				}
-				return r
-			},
-			want: []string{
-				testProxy1,
-			},
+			name:      "Anonymous",
+			anonymity: "anonymous",
+			want:      []string{testProxy1},
+		},
+		{
+			name:      "Transparent",
+			anonymity: "transparent",
+			want:      nil,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := tt.setup()
-			got := r.GetHTTPClassicView()
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf(expectedButGotMessage, "GetHTTPClassicView()", tt.want, got)
+			if got := r.GetAnonymityClassicView(tt.anonymity); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "GetAnonymityClassicView()", tt.want, got)
+			}
+
+			if got := r.GetAnonymityAdvancedView(tt.anonymity); len(got) != len(tt.want) {
+				t.Errorf(expectedButGotMessage, "GetAnonymityAdvancedView()", len(tt.want), len(got))
 			}
 		})
 	}
 }
 
-func TestGetHTTPSClassicView(t *testing.T) {
-	tests := []struct {
-		name  string
-		setup func() *ProxyRepository
-		want  []string
-	}{
-		{
-			name: "EmptyHTTPSProxies",
-			setup: func() *ProxyRepository {
-				return &ProxyRepository{
-					HTTPSClassicView: []string{},
-				}
-			},
-			want: []string{},
-		},
-		{
-			name: "WithHTTPSProxies",
-			setup: func() *ProxyRepository {
-				r := &ProxyRepository{}
-				r.HTTPSClassicView = []string{
-					testProxy2,
-				}
-				return r
-			},
-			want: []string{
-				testProxy2,
-			},
-		},
+func TestGetRotatingView(t *testing.T) {
+	r := &ProxyRepository{}
+	r.Store(&entity.Proxy{Category: testHTTPCategory, Proxy: testProxy1, IP: testIP1, Port: testPort1, ExitIP: testIP1})
+	r.Store(&entity.Proxy{Category: testHTTPCategory, Proxy: testProxy2, IP: testIP2, Port: testPort2, ExitIP: testIP3})
+	r.Store(&entity.Proxy{Category: testSOCKS5Category, Proxy: testProxy2, IP: testIP2, Port: testPort2, ExitIP: testIP4, IsRotating: true})
+	r.Store(&entity.Proxy{Category: testHTTPSCategory, Proxy: testProxy3, IP: testIP3, Port: testPort3, ExitIP: testIP1, IsRotating: true})
+
+	want := []string{testProxy2, testProxy3}
+	if got := r.GetRotatingClassicView(); !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "GetRotatingClassicView()", want, got)
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			r := tt.setup()
-			got := r.GetHTTPSClassicView()
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf(expectedButGotMessage, "GetHTTPSClassicView()", tt.want, got)
-			}
-		})
+	got := r.GetRotatingAdvancedView()
+	if len(got) != len(want) || got[0].ExitIP != testIP3 {
+		t.Errorf(expectedButGotMessage, "GetRotatingAdvancedView()", want, got)
 	}
 }
 
-func TestGetSOCKS4ClassicView(t *testing.T) {
-	tests := []struct {
-		name  string
-		setup func() *ProxyRepository
-		want  []string
-	}{
-		{
-			name: "EmptySOCKS4Proxies",
-			setup: func() *ProxyRepository {
-				r := &ProxyRepository{}
-				r.SOCKS4ClassicView = []string{}
-				return r
-			},
-			want: []string{},
-		},
-		{
-			name: "WithSOCKS4Proxies",
-			setup: func() *ProxyRepository {
-				return &ProxyRepository{
-					SOCKS4ClassicView: []string{
-						testProxy3,
-					},
-				}
-			},
-			want: []string{
-				testProxy3,
-			},
-		},
+func TestSetHistory(t *testing.T) {
+	r := &ProxyRepository{}
+	r.Store(&entity.Proxy{Category: testHTTPCategory, Proxy: testProxy1, IP: testIP1, Port: testPort1})
+	r.Store(&entity.Proxy{Category: testHTTPCategory, Proxy: testProxy2, IP: testIP2, Port: testPort2})
+
+	history := &entity.ProxyHistory{Uptime: 75, FirstSeen: "2024-01-01T00:00:00Z", LastSeen: "2024-01-04T00:00:00Z", ConsecutiveFailures: 1, AverageLatency: 0.5}
+	r.SetHistory(testProxy2, history)
+	r.SetHistory(testProxy3, history)
+	r.SetHistory(testProxy1, nil)
+
+	got := r.GetAllAdvancedView()
+	if got[0].History() != nil {
+		t.Errorf(expectedButGotMessage, "History()", nil, got[0].History())
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			r := tt.setup()
-			got := r.GetSOCKS4ClassicView()
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf(expectedButGotMessage, "GetSOCKS4ClassicView()", tt.want, got)
-			}
-		})
+	if !reflect.DeepEqual(got[1].History(), history) {
+		t.Errorf(expectedButGotMessage, "History()", history, got[1].History())
+	}
+
+	if len(got) != 2 {
+		t.Errorf(expectedButGotMessage, "proxies", 2, len(got))
 	}
 }
 
-func TestGetSOCKS5ClassicView(t *testing.T) {
+func TestGetAllClassicView(t *testing.T) {
 	tests := []struct {
 		name  string
 		setup func() *ProxyRepository
 		want  []string
 	}{
 		{
-			name: "EmptySOCKS5Proxies",
+			name: "EmptyAllProxies",
 			setup: func() *ProxyRepository {
 				return &ProxyRepository{
-					SOCKS5ClassicView: []string{},
+					AllClassicView: []string{},
 				}
 			},
 			want: []string{},
 		},
 		{
-			name: "WithSOCKS5Proxies",
+			name: "WithAllProxies",
 			setup: func() *ProxyRepository {
 				r := &ProxyRepository{}
-				r.SOCKS5ClassicView = []string{
+				r.AllClassicView = []string{
+					testProxy1,
+					testProxy2,
+					testProxy3,
 					testProxy4,
 				}
 				return r
 			},
 			want: []string{
+				testProxy1,
+				testProxy2,
+				testProxy3,
 				testProxy4,
 			},
 		},
@@ -551,9 +407,9 @@ func TestGetSOCKS5ClassicView(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := tt.setup()
-			got := r.GetSOCKS5ClassicView()
+			got := r.GetAllClassicView()
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf(expectedButGotMessage, "GetSOCKS5ClassicView()", tt.want, got)
+				t.Errorf(expectedButGotMessage, "GetAllClassicView()", tt.want, got)
 			}
 		})
 	}
@@ -606,165 +462,83 @@ func TestGetAllAdvancedView(t *testing.T) {
 	}
 }
 
-func TestGetHTTPAdvancedView(t *testing.T) {
+func TestGetClassicView(t *testing.T) {
+	r := NewProxyRepository(testProxyCategories)
+	r.Store(&testProxyEntity1)
+	r.Store(&testProxyEntity4)
+
 	tests := []struct {
-		name  string
-		setup func() *ProxyRepository
-		want  []entity.Proxy
+		name     string
+		category string
+		want     []string
 	}{
 		{
-			name: "EmptyHTTPProxies",
-			setup: func() *ProxyRepository {
-				return &ProxyRepository{
-					HTTPAdvancedView: []entity.Proxy{},
-				}
-			},
-			want: []entity.Proxy{},
+			name:     "WithHTTPProxies",
+			category: testHTTPCategory,
+			want:     []string{testProxy1},
 		},
 		{
-			name: "WithHTTPProxies",
-			setup: func() *ProxyRepository {
-				r := &ProxyRepository{}
-				r.HTTPAdvancedView = []entity.Proxy{
-					testProxyEntity1,
-				}
-				return r
-			},
-			want: []entity.Proxy{
-				testProxyEntity1,
-			},
+			name:     "EmptyHTTPSProxies",
+			category: testHTTPSCategory,
+			want:     nil,
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			r := tt.setup()
-			got := r.GetHTTPAdvancedView()
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf(expectedButGotMessage, "GetHTTPAdvancedView()", tt.want, got)
-			}
-		})
-	}
-}
-
-func TestGetHTTPSAdvancedView(t *testing.T) {
-	tests := []struct {
-		name  string
-		setup func() *ProxyRepository
-		want  []entity.Proxy
-	}{
 		{
-			name: "EmptyHTTPSProxies",
-			setup: func() *ProxyRepository {
-				return &ProxyRepository{
-					HTTPSAdvancedView: []entity.Proxy{},
-				}
-			},
-			want: []entity.Proxy{},
+			name:     "WithSOCKS5Proxies",
+			category: testSOCKS5Category,
+			want:     []string{testProxy4},
 		},
 		{
-			name: "WithHTTPSProxies",
-			setup: func() *ProxyRepository {
-				r := &ProxyRepository{}
-				r.HTTPSAdvancedView = []entity.Proxy{
-					testProxyEntity2,
-				}
-				return r
-			},
-			want: []entity.Proxy{
-				testProxyEntity2,
-			},
+			name:     "UnregisteredCategory",
+			category: "SOCKS6",
+			want:     nil,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := tt.setup()
-			got := r.GetHTTPSAdvancedView()
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf(expectedButGotMessage, "GetHTTPSAdvancedView()", tt.want, got)
+			if got := r.GetClassicView(tt.category); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "GetClassicView()", tt.want, got)
 			}
 		})
 	}
 }
 
-func TestGetSOCKS4AdvancedView(t *testing.T) {
+func TestGetAdvancedView(t *testing.T) {
+	r := NewProxyRepository(testProxyCategories)
+	r.Store(&testProxyEntity2)
+	r.Store(&testProxyEntity3)
+
 	tests := []struct {
-		name  string
-		setup func() *ProxyRepository
-		want  []entity.Proxy
+		name     string
+		category string
+		want     []entity.Proxy
 	}{
 		{
-			name: "EmptySOCKS4Proxies",
-			setup: func() *ProxyRepository {
-				return &ProxyRepository{
-					SOCKS4AdvancedView: []entity.Proxy{},
-				}
-			},
-			want: []entity.Proxy{},
+			name:     "EmptyHTTPProxies",
+			category: testHTTPCategory,
+			want:     nil,
 		},
 		{
-			name: "WithSOCKSProxies",
-			setup: func() *ProxyRepository {
-				r := &ProxyRepository{}
-				r.SOCKS4AdvancedView = []entity.Proxy{
-					testProxyEntity3,
-				}
-				return r
-			},
-			want: []entity.Proxy{
-				testProxyEntity3,
-			},
+			name:     "WithHTTPSProxies",
+			category: testHTTPSCategory,
+			want:     []entity.Proxy{testProxyEntity2},
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			r := tt.setup()
-			got := r.GetSOCKS4AdvancedView()
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf(expectedButGotMessage, "GetSOCKS4AdvancedView()", tt.want, got)
-			}
-		})
-	}
-}
-
-func TestGetSOCKS5AdvancedView(t *testing.T) {
-	tests := []struct {
-		name  string
-		setup func() *ProxyRepository
-		want  []entity.Proxy
-	}{
 		{
-			name: "EmptySOCKS5Proxies",
-			setup: func() *ProxyRepository {
-				return &ProxyRepository{
-					SOCKS5AdvancedView: []entity.Proxy{},
-				}
-			},
-			want: []entity.Proxy{},
+			name:     "WithSOCKS4Proxies",
+			category: testSOCKS4Category,
+			want:     []entity.Proxy{testProxyEntity3},
 		},
 		{
-			name: "WithSOCKS5Proxies",
-			setup: func() *ProxyRepository {
-				r := &ProxyRepository{}
-				r.SOCKS5AdvancedView = []entity.Proxy{
-					testProxyEntity4,
-				}
-				return r
-			},
-			want: []entity.Proxy{
-				testProxyEntity4,
-			},
+			name:     "UnregisteredCategory",
+			category: "SOCKS6",
+			want:     nil,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := tt.setup()
-			got := r.GetSOCKS5AdvancedView()
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf(expectedButGotMessage, "GetSOCKS5AdvancedView()", tt.want, got)
+			if got := r.GetAdvancedView(tt.category); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "GetAdvancedView()", tt.want, got)
 			}
 		})
 	}
@@ -16,6 +16,7 @@ var (
 	expectedReturnNonNil              = "Expected %v to return a non-nil %v"
 	testErrorWriting                  = "error writing"
 	testErrorEncode                   = "error encoding %s: %s"
+	testErrorDecode                   = "error decoding %s: %s"
 	testStorageDir                    = "/tmp"
 	testClassicDir                    = "/classic"
 	testAdvancedDir                   = "/advanced"
@@ -30,6 +31,7 @@ var (
 	testHTTPSCategory                 = "HTTPS"
 	testSOCKS4Category                = "SOCKS4"
 	testSOCKS5Category                = "SOCKS5"
+	testProxyCategories               = []string{testHTTPCategory, testHTTPSCategory, testSOCKS4Category, testSOCKS5Category}
 	testTimeTaken                     = 1.2345
 	testCheckedAt                     = "2024-07-27T00:00:00Z"
 
@@ -150,7 +152,9 @@ var (
 
 type mockWriter struct {
 	errWrite error
+	errSync  error
 	errClose error
+	synced   bool
 }
 
 func (m *mockWriter) Write(p []byte) (int, error) {
@@ -160,6 +164,11 @@ func (m *mockWriter) Write(p []byte) (int, error) {
 	return len(p), nil
 }
 
+func (m *mockWriter) Sync() error {
+	m.synced = true
+	return m.errSync
+}
+
 func (m *mockWriter) Close() error {
 	if m.errClose != nil {
 		return m.errClose
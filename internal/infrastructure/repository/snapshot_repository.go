@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+)
+
+type SnapshotRepository struct {
+	Dir       string
+	Keep      int
+	Symlink   func(oldName string, newName string) error
+	Rename    func(oldPath string, newPath string) error
+	RemoveAll func(path string) error
+	ReadDir   func(name string) ([]fs.DirEntry, error)
+}
+
+type SnapshotRepositoryInterface interface {
+	Path(version string) string
+	Publish(version string) error
+	Discard(version string) error
+	Prune(version string) error
+}
+
+type SymlinkFunc func(oldName string, newName string) error
+type RemoveAllFunc func(path string) error
+
+func NewSnapshotRepository(dir string, keep int, symlink SymlinkFunc, rename RenameFunc, removeAll RemoveAllFunc, readDir ReadDirFunc) SnapshotRepositoryInterface {
+	return &SnapshotRepository{
+		Dir:       dir,
+		Keep:      keep,
+		Symlink:   symlink,
+		Rename:    rename,
+		RemoveAll: removeAll,
+		ReadDir:   readDir,
+	}
+}
+
+func (r *SnapshotRepository) Path(version string) string {
+	return filepath.Join(r.Dir, "snapshots", version)
+}
+
+func (r *SnapshotRepository) Publish(version string) error {
+	// Renaming a new link over the old one flips it in a single step, unlike removing and recreating it
+	link := filepath.Join(r.Dir, "current")
+	tempLink := link + ".tmp"
+	if err := r.RemoveAll(tempLink); err != nil {
+		return fmt.Errorf("error removing link %s: %v", tempLink, err)
+	}
+	if err := r.Symlink(filepath.Join("snapshots", version), tempLink); err != nil {
+		return fmt.Errorf("error creating link %s: %v", tempLink, err)
+	}
+	if err := r.Rename(tempLink, link); err != nil {
+		return fmt.Errorf("error publishing snapshot %s: %v", version, err)
+	}
+	return nil
+}
+
+func (r *SnapshotRepository) Discard(version string) error {
+	if err := r.RemoveAll(r.Path(version)); err != nil {
+		return fmt.Errorf("error removing snapshot %s: %v", version, err)
+	}
+	return nil
+}
+
+func (r *SnapshotRepository) Prune(version string) error {
+	if r.Keep <= 0 {
+		return nil
+	}
+
+	dir := filepath.Join(r.Dir, "snapshots")
+	entries, err := r.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("error reading directory %s: %v", dir, err)
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != version {
+			versions = append(versions, entry.Name())
+		}
+	}
+	slices.Sort(versions)
+
+	// The published version always counts as one of the kept snapshots
+	var errs []error
+	for len(versions) > r.Keep-1 {
+		if err := r.Discard(versions[0]); err != nil {
+			errs = append(errs, err)
+		}
+		versions = versions[1:]
+	}
+	return errors.Join(errs...)
+}
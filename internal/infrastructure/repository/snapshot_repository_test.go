@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+var testSnapshotDir = "storage"
+
+func TestNewSnapshotRepository(t *testing.T) {
+	snapshotRepository := NewSnapshotRepository(testSnapshotDir, 3, nil, nil, nil, nil)
+	if snapshotRepository == nil {
+		t.Errorf(expectedReturnNonNil, "NewSnapshotRepository", "SnapshotRepositoryInterface")
+	}
+
+	r, ok := snapshotRepository.(*SnapshotRepository)
+	if !ok {
+		t.Errorf(expectedTypeAssertionErrorMessage, "*SnapshotRepository")
+	}
+
+	if r.Dir != testSnapshotDir || r.Keep != 3 {
+		t.Errorf(expectedButGotMessage, "SnapshotRepository", testSnapshotDir, r.Dir)
+	}
+
+	if got, want := r.Path("v1"), filepath.Join(testSnapshotDir, "snapshots", "v1"); got != want {
+		t.Errorf(expectedButGotMessage, "Path()", want, got)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	var (
+		link     = filepath.Join(testSnapshotDir, "current")
+		tempLink = link + ".tmp"
+	)
+
+	tests := []struct {
+		name      string
+		symlink   error
+		rename    error
+		want      []string
+		wantError error
+	}{
+		{
+			name: "Success",
+			want: []string{
+				"remove " + tempLink,
+				"symlink " + filepath.Join("snapshots", "v2") + " " + tempLink,
+				"rename " + tempLink + " " + link,
+			},
+			wantError: nil,
+		},
+		{
+			name:    "SymlinkError",
+			symlink: errors.New("operation not permitted"),
+			want: []string{
+				"remove " + tempLink,
+				"symlink " + filepath.Join("snapshots", "v2") + " " + tempLink,
+			},
+			wantError: fmt.Errorf("error creating link %s: %v", tempLink, "operation not permitted"),
+		},
+		{
+			name:   "RenameError",
+			rename: errors.New("is a directory"),
+			want: []string{
+				"remove " + tempLink,
+				"symlink " + filepath.Join("snapshots", "v2") + " " + tempLink,
+				"rename " + tempLink + " " + link,
+			},
+			wantError: fmt.Errorf("error publishing snapshot %s: %v", "v2", "is a directory"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			r := NewSnapshotRepository(
+				testSnapshotDir,
+				3,
+				func(oldName string, newName string) error {
+					got = append(got, "symlink "+oldName+" "+newName)
+					return tt.symlink
+				},
+				func(oldPath string, newPath string) error {
+					got = append(got, "rename "+oldPath+" "+newPath)
+					return tt.rename
+				},
+				func(path string) error {
+					got = append(got, "remove "+path)
+					return nil
+				},
+				nil,
+			)
+
+			err := r.Publish("v2")
+			if (err != nil && tt.wantError == nil) ||
+				(err == nil && tt.wantError != nil) ||
+				(err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) {
+				t.Errorf(expectedErrorButGotMessage, "Publish()", tt.wantError, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "Publish()", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	snapshotsFS := fstest.MapFS{
+		"storage/snapshots/20240101T000000.000Z/all.json": {},
+		"storage/snapshots/20240101T010000.000Z/all.json": {},
+		"storage/snapshots/20240101T020000.000Z/all.json": {},
+		"storage/snapshots/20240101T030000.000Z/all.json": {},
+		"storage/snapshots/README.md":                     {},
+	}
+
+	tests := []struct {
+		name    string
+		keep    int
+		version string
+		want    []string
+	}{
+		{
+			name:    "KeepNewest",
+			keep:    2,
+			version: "20240101T030000.000Z",
+			want: []string{
+				filepath.Join(testSnapshotDir, "snapshots", "20240101T000000.000Z"),
+				filepath.Join(testSnapshotDir, "snapshots", "20240101T010000.000Z"),
+			},
+		},
+		{
+			// A clock that went back must not remove the snapshot that was just published
+			name:    "KeepPublished",
+			keep:    1,
+			version: "20240101T000000.000Z",
+			want: []string{
+				filepath.Join(testSnapshotDir, "snapshots", "20240101T010000.000Z"),
+				filepath.Join(testSnapshotDir, "snapshots", "20240101T020000.000Z"),
+				filepath.Join(testSnapshotDir, "snapshots", "20240101T030000.000Z"),
+			},
+		},
+		{
+			name:    "KeepAll",
+			keep:    0,
+			version: "20240101T030000.000Z",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			r := NewSnapshotRepository(testSnapshotDir, tt.keep, nil, nil, func(path string) error {
+				got = append(got, path)
+				return nil
+			}, snapshotsFS.ReadDir)
+
+			if err := r.Prune(tt.version); err != nil {
+				t.Errorf(expectedErrorButGotMessage, "Prune()", nil, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "Prune()", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+
+	"gopkg.in/yaml.v3"
+)
+
+type SourceFileRepository struct {
+	Path           string
+	ProxyResources string
+	Stat           func(name string) (fs.FileInfo, error)
+	ReadFile       func(name string) ([]byte, error)
+	ReadDir        func(name string) ([]fs.DirEntry, error)
+}
+
+type StatFunc func(name string) (fs.FileInfo, error)
+type ReadFileFunc func(name string) ([]byte, error)
+type ReadDirFunc func(name string) ([]fs.DirEntry, error)
+
+func NewSourceFileRepository(path string, proxyResources string, stat StatFunc, readFile ReadFileFunc, readDir ReadDirFunc) SourceRepositoryInterface {
+	return &SourceFileRepository{
+		Path:           path,
+		ProxyResources: proxyResources,
+		Stat:           stat,
+		ReadFile:       readFile,
+		ReadDir:        readDir,
+	}
+}
+
+func (r *SourceFileRepository) LoadSources() ([]entity.Source, error) {
+	info, err := r.Stat(r.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading sources %s: %v", r.Path, err)
+	}
+
+	filePaths := []string{r.Path}
+	if info.IsDir() {
+		entries, err := r.ReadDir(r.Path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading directory %s: %v", r.Path, err)
+		}
+
+		filePaths = nil
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".json", ".yaml", ".yml":
+				if !entry.IsDir() {
+					filePaths = append(filePaths, filepath.Join(r.Path, entry.Name()))
+				}
+			}
+		}
+	}
+
+	var (
+		sources []entity.Source
+		errs    []error
+	)
+	for _, filePath := range filePaths {
+		fileSources, err := r.loadFile(filePath)
+		sources = append(sources, fileSources...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if r.ProxyResources != "" {
+		envSources, err := NewSourceRepository(r.ProxyResources).LoadSources()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("PROXY_RESOURCES: %v", err))
+		}
+		for _, source := range envSources {
+			source.Origin = "PROXY_RESOURCES"
+			sources = append(sources, source)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no proxy sources found in %s", r.Path)
+	}
+
+	return sources, nil
+}
+
+func (r *SourceFileRepository) loadFile(filePath string) ([]entity.Source, error) {
+	data, err := r.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %s: %v", filePath, err)
+	}
+
+	if strings.ToLower(filepath.Ext(filePath)) == ".json" {
+		return r.decodeJSON(filePath, data)
+	}
+	return r.decodeYAML(filePath, data)
+}
+
+func (r *SourceFileRepository) decodeJSON(filePath string, data []byte) ([]entity.Source, error) {
+	data = stripComments(data)
+	lineOf := func(offset int64) int {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err == io.EOF {
+		return nil, nil
+	} else if err != nil || token != json.Delim('[') {
+		return nil, fmt.Errorf("%s:%d: expected a list of sources", filePath, lineOf(decoder.InputOffset()))
+	}
+
+	var (
+		sources []entity.Source
+		errs    []error
+	)
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return sources, fmt.Errorf("%s:%d: %v", filePath, lineOf(decoder.InputOffset()), err)
+		}
+
+		line := lineOf(decoder.InputOffset() - int64(len(raw)))
+		source := entity.Source{}
+		if err := json.Unmarshal(raw, &source); err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %v", filePath, line, err))
+			continue
+		}
+		source.Origin = fmt.Sprintf("%s:%d", filePath, line)
+		sources = append(sources, source)
+	}
+
+	return sources, errors.Join(errs...)
+}
+
+func (r *SourceFileRepository) decodeYAML(filePath string, data []byte) ([]entity.Source, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("%s: %v", filePath, err)
+	}
+
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%s:%d: expected a list of sources", filePath, root.Line)
+	}
+
+	var (
+		sources []entity.Source
+		errs    []error
+	)
+	for _, node := range root.Content {
+		source := entity.Source{}
+		if err := node.Decode(&source); err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %v", filePath, node.Line, err))
+			continue
+		}
+		source.Origin = fmt.Sprintf("%s:%d", filePath, node.Line)
+		sources = append(sources, source)
+	}
+
+	return sources, errors.Join(errs...)
+}
+
+func stripComments(data []byte) []byte {
+	var (
+		result   = make([]byte, 0, len(data))
+		inString = false
+		escaped  = false
+	)
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			result = append(result, c)
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		if c == '#' || (c == '/' && i+1 < len(data) && data[i+1] == '/') {
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				result = append(result, '\n')
+			}
+			continue
+		}
+
+		if c == '"' {
+			inString = true
+		}
+		result = append(result, c)
+	}
+	return result
+}
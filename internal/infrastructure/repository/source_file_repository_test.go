@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+var (
+	testSourcesYAML = []byte(`# HTTP sources
+- method: LIST
+  category: HTTP
+  url: http://example.com/http.txt
+
+# SOCKS5 sources
+- method: SCRAP
+  category: SOCKS5
+  url: http://example.com/socks5.html
+  is_checked: false
+`)
+	testSourcesJSON = []byte(`[
+  // HTTPS sources
+  {"method": "LIST", "category": "HTTPS", "url": "http://example.com/#https"},
+  # SOCKS4 sources
+  {"method": "LIST", "category": "SOCKS4", "url": "http://example.com/socks4.txt", "is_checked": false}
+]
+`)
+	testSourcesFS = fstest.MapFS{
+		"sources.yaml":          {Data: testSourcesYAML},
+		"sources.json":          {Data: testSourcesJSON},
+		"conf.d/10-http.yaml":   {Data: testSourcesYAML},
+		"conf.d/20-https.json":  {Data: testSourcesJSON},
+		"conf.d/README.md":      {Data: []byte("# Sources")},
+		"invalid.yaml":          {Data: []byte("- method: LIST\n  is_checked: maybe\n- method: SCRAP\n")},
+		"invalid.json":          {Data: []byte("[\n  {\"method\": \"LIST\"},\n  {\"method\": 1}\n]")},
+		"object.json":           {Data: []byte(`{"method": "LIST"}`)},
+		"empty.yaml":            {Data: []byte("# no sources yet\n")},
+		"conf.d/empty/.gitkeep": {Data: []byte{}},
+	}
+	testYAMLSources = []entity.Source{
+		{Method: "LIST", Category: "HTTP", URL: "http://example.com/http.txt", IsChecked: true, Origin: "sources.yaml:2"},
+		{Method: "SCRAP", Category: "SOCKS5", URL: "http://example.com/socks5.html", IsChecked: false, Origin: "sources.yaml:7"},
+	}
+	testJSONSources = []entity.Source{
+		{Method: "LIST", Category: "HTTPS", URL: "http://example.com/#https", IsChecked: true, Origin: "sources.json:3"},
+		{Method: "LIST", Category: "SOCKS4", URL: "http://example.com/socks4.txt", IsChecked: false, Origin: "sources.json:5"},
+	}
+)
+
+func newTestSourceFileRepository(path string, proxyResources string) *SourceFileRepository {
+	return &SourceFileRepository{
+		Path:           path,
+		ProxyResources: proxyResources,
+		Stat: func(name string) (fs.FileInfo, error) {
+			return fs.Stat(testSourcesFS, name)
+		},
+		ReadFile: testSourcesFS.ReadFile,
+		ReadDir:  testSourcesFS.ReadDir,
+	}
+}
+
+func TestNewSourceFileRepository(t *testing.T) {
+	sourceRepository := NewSourceFileRepository("sources.yaml", "", nil, nil, nil)
+	if sourceRepository == nil {
+		t.Errorf(expectedReturnNonNil, "NewSourceFileRepository", "SourceRepositoryInterface")
+	}
+
+	got, ok := sourceRepository.(*SourceFileRepository)
+	if !ok {
+		t.Errorf(expectedTypeAssertionErrorMessage, "*SourceFileRepository")
+	}
+
+	if got.Path != "sources.yaml" {
+		t.Errorf(expectedButGotMessage, "Path", "sources.yaml", got.Path)
+	}
+}
+
+func TestLoadSourcesFromFile(t *testing.T) {
+	tests := []struct {
+		name           string
+		path           string
+		proxyResources string
+		want           []entity.Source
+		wantErr        error
+	}{
+		{
+			name:    "YAML",
+			path:    "sources.yaml",
+			want:    testYAMLSources,
+			wantErr: nil,
+		},
+		{
+			name:    "JSON",
+			path:    "sources.json",
+			want:    testJSONSources,
+			wantErr: nil,
+		},
+		{
+			name: "Directory",
+			path: "conf.d",
+			want: []entity.Source{
+				{Method: "LIST", Category: "HTTP", URL: "http://example.com/http.txt", IsChecked: true, Origin: "conf.d/10-http.yaml:2"},
+				{Method: "SCRAP", Category: "SOCKS5", URL: "http://example.com/socks5.html", IsChecked: false, Origin: "conf.d/10-http.yaml:7"},
+				{Method: "LIST", Category: "HTTPS", URL: "http://example.com/#https", IsChecked: true, Origin: "conf.d/20-https.json:3"},
+				{Method: "LIST", Category: "SOCKS4", URL: "http://example.com/socks4.txt", IsChecked: false, Origin: "conf.d/20-https.json:5"},
+			},
+			wantErr: nil,
+		},
+		{
+			name:           "MergeWithEnvironment",
+			path:           "sources.yaml",
+			proxyResources: `[{"method": "LIST", "category": "HTTP", "url": "http://example.com/env.txt"}]`,
+			want: append(append([]entity.Source{}, testYAMLSources...), entity.Source{
+				Method: "LIST", Category: "HTTP", URL: "http://example.com/env.txt", IsChecked: true, Origin: "PROXY_RESOURCES",
+			}),
+			wantErr: nil,
+		},
+		{
+			name:           "InvalidEnvironment",
+			path:           "sources.yaml",
+			proxyResources: `[`,
+			want:           nil,
+			wantErr:        errors.New("PROXY_RESOURCES: error parsing JSON: unexpected end of JSON input"),
+		},
+		{
+			name:    "NotFound",
+			path:    "missing.yaml",
+			want:    nil,
+			wantErr: errors.New("error reading sources missing.yaml: open missing.yaml: file does not exist"),
+		},
+		{
+			name:    "InvalidYAMLEntry",
+			path:    "invalid.yaml",
+			want:    nil,
+			wantErr: errors.New("invalid.yaml:1: yaml: unmarshal errors:\n  line 2: cannot unmarshal !!str `maybe` into bool"),
+		},
+		{
+			name:    "InvalidJSONEntry",
+			path:    "invalid.json",
+			want:    nil,
+			wantErr: errors.New("invalid.json:3: json: cannot unmarshal number into Go struct field .method of type string"),
+		},
+		{
+			name:    "NotAList",
+			path:    "object.json",
+			want:    nil,
+			wantErr: errors.New("object.json:1: expected a list of sources"),
+		},
+		{
+			name:    "Empty",
+			path:    "empty.yaml",
+			want:    nil,
+			wantErr: errors.New("no proxy sources found in empty.yaml"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestSourceFileRepository(tt.path, tt.proxyResources)
+			got, err := r.LoadSources()
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "LoadSources()", tt.want, got)
+			}
+
+			if (err != nil && tt.wantErr != nil && err.Error() != tt.wantErr.Error()) ||
+				(err != nil && tt.wantErr == nil) ||
+				(err == nil && tt.wantErr != nil) {
+				t.Errorf(expectedErrorButGotMessage, "LoadSources()", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+type SourceStateRepository struct {
+	Path      string
+	Mutex     sync.RWMutex
+	FetchedAt map[string]time.Time
+	ReadFile  func(name string) ([]byte, error)
+	WriteFile func(name string, data []byte) error
+}
+
+type SourceStateRepositoryInterface interface {
+	Load() error
+	GetFetchedAt(source *entity.Source) time.Time
+	SetFetchedAt(source *entity.Source, fetchedAt time.Time)
+	Save() error
+}
+
+type WriteFileFunc func(name string, data []byte) error
+
+func NewSourceStateRepository(path string, readFile ReadFileFunc, writeFile WriteFileFunc) SourceStateRepositoryInterface {
+	return &SourceStateRepository{
+		Path:      path,
+		Mutex:     sync.RWMutex{},
+		FetchedAt: map[string]time.Time{},
+		ReadFile:  readFile,
+		WriteFile: writeFile,
+	}
+}
+
+func (r *SourceStateRepository) Load() error {
+	data, err := r.ReadFile(r.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error reading source state %s: %v", r.Path, err)
+	}
+
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	if err := json.Unmarshal(data, &r.FetchedAt); err != nil {
+		return fmt.Errorf("error parsing source state %s: %v", r.Path, err)
+	}
+	return nil
+}
+
+func (r *SourceStateRepository) GetFetchedAt(source *entity.Source) time.Time {
+	r.Mutex.RLock()
+	defer r.Mutex.RUnlock()
+
+	return r.FetchedAt[sourceKey(source)]
+}
+
+func (r *SourceStateRepository) SetFetchedAt(source *entity.Source, fetchedAt time.Time) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+
+	r.FetchedAt[sourceKey(source)] = fetchedAt
+}
+
+func (r *SourceStateRepository) Save() error {
+	r.Mutex.RLock()
+	defer r.Mutex.RUnlock()
+
+	data, err := json.MarshalIndent(r.FetchedAt, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding source state: %v", err)
+	}
+
+	if err := r.WriteFile(r.Path, data); err != nil {
+		return fmt.Errorf("error writing source state %s: %v", r.Path, err)
+	}
+	return nil
+}
+
+func sourceKey(source *entity.Source) string {
+	return source.Method + " " + source.Category + " " + source.URL
+}
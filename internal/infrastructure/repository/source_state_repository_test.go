@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+var testSourceStatePath = "storage/sources.state.json"
+
+func TestNewSourceStateRepository(t *testing.T) {
+	sourceStateRepository := NewSourceStateRepository(testSourceStatePath, nil, nil)
+	if sourceStateRepository == nil {
+		t.Errorf(expectedReturnNonNil, "NewSourceStateRepository", "SourceStateRepositoryInterface")
+	}
+
+	r, ok := sourceStateRepository.(*SourceStateRepository)
+	if !ok {
+		t.Errorf(expectedTypeAssertionErrorMessage, "*SourceStateRepository")
+	}
+
+	if r.Path != testSourceStatePath {
+		t.Errorf(expectedButGotMessage, "Path", testSourceStatePath, r.Path)
+	}
+}
+
+func TestSourceStateRepository(t *testing.T) {
+	var (
+		written   []byte
+		source    = &entity.Source{Method: "LIST", Category: testHTTPCategory, URL: "https://example.com/http.txt"}
+		fetchedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+	writeFile := func(name string, data []byte) error {
+		written = data
+		return nil
+	}
+	readFile := func(name string) ([]byte, error) {
+		if written == nil {
+			return nil, fs.ErrNotExist
+		}
+		return written, nil
+	}
+
+	r := NewSourceStateRepository(testSourceStatePath, readFile, writeFile)
+	if err := r.Load(); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "Load()", nil, err)
+	}
+
+	if got := r.GetFetchedAt(source); !got.IsZero() {
+		t.Errorf(expectedButGotMessage, "GetFetchedAt()", time.Time{}, got)
+	}
+
+	r.SetFetchedAt(source, fetchedAt)
+	if err := r.Save(); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "Save()", nil, err)
+	}
+
+	r = NewSourceStateRepository(testSourceStatePath, readFile, writeFile)
+	if err := r.Load(); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "Load()", nil, err)
+	}
+
+	if got := r.GetFetchedAt(source); !got.Equal(fetchedAt) {
+		t.Errorf(expectedButGotMessage, "GetFetchedAt()", fetchedAt, got)
+	}
+
+	if got := r.GetFetchedAt(&entity.Source{Method: "SCRAP", Category: testHTTPCategory, URL: source.URL}); !got.IsZero() {
+		t.Errorf(expectedButGotMessage, "GetFetchedAt()", time.Time{}, got)
+	}
+}
+
+func TestSourceStateRepositoryErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		readFile  ReadFileFunc
+		writeFile WriteFileFunc
+		wantError error
+	}{
+		{
+			name: "ReadError",
+			readFile: func(name string) ([]byte, error) {
+				return nil, fs.ErrPermission
+			},
+			wantError: errors.New("error reading source state " + testSourceStatePath + ": permission denied"),
+		},
+		{
+			name: "ParseError",
+			readFile: func(name string) ([]byte, error) {
+				return []byte("[]"), nil
+			},
+			wantError: errors.New("error parsing source state " + testSourceStatePath + ": json: cannot unmarshal array into Go value of type map[string]time.Time"),
+		},
+		{
+			name: "WriteError",
+			readFile: func(name string) ([]byte, error) {
+				return []byte("{}"), nil
+			},
+			writeFile: func(name string, data []byte) error {
+				return errors.New("disk full")
+			},
+			wantError: errors.New("error writing source state " + testSourceStatePath + ": disk full"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewSourceStateRepository(testSourceStatePath, tt.readFile, tt.writeFile)
+			err := r.Load()
+			if err == nil {
+				err = r.Save()
+			}
+
+			if err == nil || err.Error() != tt.wantError.Error() {
+				t.Errorf(expectedErrorButGotMessage, "SourceStateRepository", tt.wantError, err)
+			}
+		})
+	}
+}
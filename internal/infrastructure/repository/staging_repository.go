@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+type StagingRepository struct {
+	Dir       string
+	ReadFile  func(name string) ([]byte, error)
+	ReadDir   func(name string) ([]fs.DirEntry, error)
+	Rename    func(oldPath string, newPath string) error
+	RemoveAll func(path string) error
+}
+
+type StagingRepositoryInterface interface {
+	Path() string
+	Publish() error
+	Discard() error
+}
+
+func NewStagingRepository(dir string, readFile ReadFileFunc, readDir ReadDirFunc, rename RenameFunc, removeAll RemoveAllFunc) StagingRepositoryInterface {
+	return &StagingRepository{
+		Dir:       filepath.Clean(dir),
+		ReadFile:  readFile,
+		ReadDir:   readDir,
+		Rename:    rename,
+		RemoveAll: removeAll,
+	}
+}
+
+func (r *StagingRepository) Path() string {
+	return r.Dir + ".staging"
+}
+
+// Publish swaps the staging directory with the output directory, so readers get either every previous output or every
+// new one. The entries the previous manifest did not describe, like a history kept in the output directory, are moved
+// into the staging directory first, and everything is put back when a rename fails.
+func (r *StagingRepository) Publish() error {
+	var (
+		stagingDir = r.Path()
+		oldDir     = r.Dir + ".old"
+	)
+	kept, err := r.keptEntries()
+	if err != nil {
+		return err
+	}
+
+	restore := func(names []string) {
+		for _, name := range names {
+			r.Rename(filepath.Join(stagingDir, name), filepath.Join(r.Dir, name))
+		}
+	}
+	for i, name := range kept {
+		if err := r.Rename(filepath.Join(r.Dir, name), filepath.Join(stagingDir, name)); err != nil {
+			restore(kept[:i])
+			return fmt.Errorf("error moving %s: %v", filepath.Join(r.Dir, name), err)
+		}
+	}
+
+	if err := r.RemoveAll(oldDir); err != nil {
+		restore(kept)
+		return fmt.Errorf("error removing directory %s: %v", oldDir, err)
+	}
+	hasOutput := true
+	if err := r.Rename(r.Dir, oldDir); errors.Is(err, fs.ErrNotExist) {
+		hasOutput = false
+	} else if err != nil {
+		restore(kept)
+		return fmt.Errorf("error publishing %s: %v", r.Dir, err)
+	}
+	if err := r.Rename(stagingDir, r.Dir); err != nil {
+		if hasOutput {
+			r.Rename(oldDir, r.Dir)
+		}
+		restore(kept)
+		return fmt.Errorf("error publishing %s: %v", r.Dir, err)
+	}
+
+	if err := r.RemoveAll(oldDir); err != nil {
+		return fmt.Errorf("error removing directory %s: %v", oldDir, err)
+	}
+	return nil
+}
+
+func (r *StagingRepository) Discard() error {
+	if err := r.RemoveAll(r.Path()); err != nil {
+		return fmt.Errorf("error removing directory %s: %v", r.Path(), err)
+	}
+	return nil
+}
+
+// keptEntries lists the entries of the output directory that are neither described by its manifest nor staged again
+func (r *StagingRepository) keptEntries() ([]string, error) {
+	entries, err := r.ReadDir(r.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading directory %s: %v", r.Dir, err)
+	}
+
+	staged, err := r.ReadDir(r.Path())
+	if err != nil {
+		return nil, fmt.Errorf("error reading directory %s: %v", r.Path(), err)
+	}
+
+	manifest := entity.Manifest{}
+	manifestPath := filepath.Join(r.Dir, "manifest.json")
+	if data, err := r.ReadFile(manifestPath); err == nil {
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("error parsing manifest %s: %v", manifestPath, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("error reading manifest %s: %v", manifestPath, err)
+	}
+
+	published := map[string]struct{}{"manifest.json": {}}
+	for _, file := range manifest.Files {
+		name, _, _ := strings.Cut(file.Path, "/")
+		published[name] = struct{}{}
+	}
+	for _, entry := range staged {
+		published[entry.Name()] = struct{}{}
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if _, found := published[entry.Name()]; !found {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
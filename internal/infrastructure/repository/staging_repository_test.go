@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+var testStagingDir = "storage"
+
+func TestNewStagingRepository(t *testing.T) {
+	stagingRepository := NewStagingRepository(testStagingDir+"/", nil, nil, nil, nil)
+	if stagingRepository == nil {
+		t.Errorf(expectedReturnNonNil, "NewStagingRepository", "StagingRepositoryInterface")
+	}
+
+	r, ok := stagingRepository.(*StagingRepository)
+	if !ok {
+		t.Errorf(expectedTypeAssertionErrorMessage, "*StagingRepository")
+	}
+
+	if r.Dir != testStagingDir {
+		t.Errorf(expectedButGotMessage, "Dir", testStagingDir, r.Dir)
+	}
+
+	if got, want := r.Path(), testStagingDir+".staging"; got != want {
+		t.Errorf(expectedButGotMessage, "Path()", want, got)
+	}
+}
+
+func TestStagingPublish(t *testing.T) {
+	var (
+		stagingDir = testStagingDir + ".staging"
+		oldDir     = testStagingDir + ".old"
+		outputFS   = fstest.MapFS{
+			"storage/manifest.json":            {Data: []byte(`{"files":[{"path":"classic/http.txt"},{"path":"socks4.txt"}]}`)},
+			"storage/classic/http.txt":         {},
+			"storage/socks4.txt":               {},
+			"storage/history.jsonl":            {},
+			"storage.staging/manifest.json":    {Data: []byte(`{"files":[{"path":"classic/http.txt"}]}`)},
+			"storage.staging/classic/http.txt": {},
+		}
+		moveHistory    = "rename " + filepath.Join(testStagingDir, "history.jsonl") + " " + filepath.Join(stagingDir, "history.jsonl")
+		restoreHistory = "rename " + filepath.Join(stagingDir, "history.jsonl") + " " + filepath.Join(testStagingDir, "history.jsonl")
+	)
+
+	tests := []struct {
+		name      string
+		files     fstest.MapFS
+		renameErr map[string]error
+		want      []string
+		wantError error
+	}{
+		{
+			name:  "Success",
+			files: outputFS,
+			want: []string{
+				moveHistory,
+				"remove " + oldDir,
+				"rename " + testStagingDir + " " + oldDir,
+				"rename " + stagingDir + " " + testStagingDir,
+				"remove " + oldDir,
+			},
+			wantError: nil,
+		},
+		{
+			name: "FirstPublish",
+			files: fstest.MapFS{
+				"storage.staging/manifest.json": {Data: []byte(`{"files":[]}`)},
+			},
+			renameErr: map[string]error{testStagingDir: fs.ErrNotExist},
+			want: []string{
+				"remove " + oldDir,
+				"rename " + testStagingDir + " " + oldDir,
+				"rename " + stagingDir + " " + testStagingDir,
+				"remove " + oldDir,
+			},
+			wantError: nil,
+		},
+		{
+			name:      "SwapError",
+			files:     outputFS,
+			renameErr: map[string]error{stagingDir: errors.New("device busy")},
+			want: []string{
+				moveHistory,
+				"remove " + oldDir,
+				"rename " + testStagingDir + " " + oldDir,
+				"rename " + stagingDir + " " + testStagingDir,
+				"rename " + oldDir + " " + testStagingDir,
+				restoreHistory,
+			},
+			wantError: fmt.Errorf("error publishing %s: %v", testStagingDir, "device busy"),
+		},
+		{
+			name: "InvalidManifest",
+			files: fstest.MapFS{
+				"storage/manifest.json":         {Data: []byte(`{"files":`)},
+				"storage.staging/manifest.json": {},
+			},
+			want:      nil,
+			wantError: fmt.Errorf("error parsing manifest %s: %v", filepath.Join(testStagingDir, "manifest.json"), "unexpected end of JSON input"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			r := NewStagingRepository(
+				testStagingDir,
+				tt.files.ReadFile,
+				tt.files.ReadDir,
+				func(oldPath string, newPath string) error {
+					got = append(got, "rename "+oldPath+" "+newPath)
+					return tt.renameErr[oldPath]
+				},
+				func(path string) error {
+					got = append(got, "remove "+path)
+					return nil
+				},
+			)
+
+			err := r.Publish()
+			if (err != nil && tt.wantError == nil) ||
+				(err == nil && tt.wantError != nil) ||
+				(err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) {
+				t.Errorf(expectedErrorButGotMessage, "Publish()", tt.wantError, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "Publish()", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStagingDiscard(t *testing.T) {
+	var got []string
+	r := NewStagingRepository(testStagingDir, nil, nil, nil, func(path string) error {
+		got = append(got, path)
+		return errors.New("permission denied")
+	})
+
+	wantError := fmt.Errorf("error removing directory %s: %v", testStagingDir+".staging", "permission denied")
+	if err := r.Discard(); err == nil || err.Error() != wantError.Error() {
+		t.Errorf(expectedErrorButGotMessage, "Discard()", wantError, err)
+	}
+
+	if want := []string{testStagingDir + ".staging"}; !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "RemoveAll()", want, got)
+	}
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+type TemplateRepository struct {
+	Path      string
+	ReadFile  func(name string) ([]byte, error)
+	WriteFile func(name string, data []byte) error
+}
+
+type TemplateRepositoryInterface interface {
+	Render(outputPath string, data interface{}, funcs template.FuncMap) error
+}
+
+func NewTemplateRepository(path string, readFile ReadFileFunc, writeFile WriteFileFunc) TemplateRepositoryInterface {
+	return &TemplateRepository{
+		Path:      path,
+		ReadFile:  readFile,
+		WriteFile: writeFile,
+	}
+}
+
+func (r *TemplateRepository) Render(outputPath string, data interface{}, funcs template.FuncMap) error {
+	text, err := r.ReadFile(r.Path)
+	if err != nil {
+		return fmt.Errorf("error reading template %s: %v", r.Path, err)
+	}
+
+	tmpl, err := template.New(r.Path).Funcs(funcs).Option("missingkey=zero").Parse(string(text))
+	if err != nil {
+		return fmt.Errorf("error parsing template %s: %v", r.Path, err)
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return fmt.Errorf("error rendering template %s: %v", r.Path, err)
+	}
+
+	if err := r.WriteFile(outputPath, buffer.Bytes()); err != nil {
+		return fmt.Errorf("error writing %s: %v", outputPath, err)
+	}
+	return nil
+}
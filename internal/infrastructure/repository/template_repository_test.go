@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+var testTemplatePath = "docs/README.template.md"
+
+func TestNewTemplateRepository(t *testing.T) {
+	templateRepository := NewTemplateRepository(testTemplatePath, nil, nil)
+	if templateRepository == nil {
+		t.Errorf(expectedReturnNonNil, "NewTemplateRepository", "TemplateRepositoryInterface")
+	}
+
+	r, ok := templateRepository.(*TemplateRepository)
+	if !ok {
+		t.Errorf(expectedTypeAssertionErrorMessage, "*TemplateRepository")
+	}
+
+	if r.Path != testTemplatePath {
+		t.Errorf(expectedButGotMessage, "Path", testTemplatePath, r.Path)
+	}
+}
+
+func TestTemplateRepositoryRender(t *testing.T) {
+	data := map[string]interface{}{
+		"Counts": map[string]int{testHTTPCategory: 2},
+	}
+	funcs := template.FuncMap{
+		"upper": strings.ToUpper,
+	}
+
+	tests := []struct {
+		name      string
+		template  string
+		readError error
+		want      string
+		wantError error
+	}{
+		{
+			name:     "Success",
+			template: `{{upper "http"}}: {{.Counts.HTTP}}, SOCKS5: {{.Counts.SOCKS5}}`,
+			want:     "HTTP: 2, SOCKS5: 0",
+		},
+		{
+			name:      "ReadError",
+			readError: errors.New("not found"),
+			wantError: errors.New("error reading template " + testTemplatePath + ": not found"),
+		},
+		{
+			name:      "ParseError",
+			template:  `{{UPDATED_AT}}`,
+			wantError: errors.New("error parsing template " + testTemplatePath + `: template: ` + testTemplatePath + `:1: function "UPDATED_AT" not defined`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var written []byte
+			r := NewTemplateRepository(testTemplatePath, func(name string) ([]byte, error) {
+				return []byte(tt.template), tt.readError
+			}, func(name string, data []byte) error {
+				written = data
+				return nil
+			})
+
+			err := r.Render("README.md", data, funcs)
+			if (err != nil && tt.wantError == nil) || (err == nil && tt.wantError != nil) || (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) {
+				t.Fatalf(expectedErrorButGotMessage, "Render()", tt.wantError, err)
+			}
+			if string(written) != tt.want {
+				t.Errorf(expectedButGotMessage, "Render()", tt.want, string(written))
+			}
+		})
+	}
+}
@@ -1,12 +1,27 @@
 package service
 
 import (
+	"bufio"
+	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"net"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fyvri/fresh-proxy-list/internal/entity"
@@ -18,24 +33,47 @@ import (
 type ProxyService struct {
 	FetcherUtil       utils.FetcherUtilInterface
 	URLParserUtil     utils.URLParserUtilInterface
-	HTTPTestingSites  []string
-	HTTPSTestingSites []string
+	HTTPTestingSites  []entity.TestingSite
+	HTTPSTestingSites []entity.TestingSite
 	UserAgents        []string
+	Timeout           time.Duration
+	JudgeURL          string
+	VerifyTLS         bool
+	ConnectTargets    []string
+	RootCAs           *x509.CertPool
+	ProxyHeaders      []string
+	RealIP            string
+	RealIPMutex       sync.Mutex
 	Semaphore         chan struct{}
 }
 
 type ProxyServiceInterface interface {
-	Check(category string, ip string, port string) (*entity.Proxy, error)
-	GetTestingSite(category string) string
+	Check(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error)
+	GetTestingSite(category string) entity.TestingSite
 	GetRandomUserAgent() string
+	GetRealIP(ctx context.Context) string
+	GetAnonymity(judgeResponse *entity.JudgeResponse, realIP string) string
+	GetExitIP(body []byte) string
+	ValidateResponse(testingSite *entity.TestingSite, header http.Header, body []byte) error
+	IsIntercepted(testingSite *entity.TestingSite, state *tls.ConnectionState) bool
+	ProbeConnect(ctx context.Context, category string, proxy string, username string, password string) []int
+	Dial(ctx context.Context, category string, proxy string, username string, password string, addr string) (net.Conn, error)
 }
 
+var ErrResponseRejected = errors.New("response rejected")
+
 func NewProxyService(
 	fetcherUtil utils.FetcherUtilInterface,
 	urlParserUtil utils.URLParserUtilInterface,
-	httpTestingSites []string,
-	httpsTestingSites []string,
+	httpTestingSites []entity.TestingSite,
+	httpsTestingSites []entity.TestingSite,
 	userAgents []string,
+	concurrency int,
+	timeout time.Duration,
+	judgeURL string,
+	verifyTLS bool,
+	connectTargets []string,
+	proxyHeaders []string,
 ) ProxyServiceInterface {
 	return &ProxyService{
 		FetcherUtil:       fetcherUtil,
@@ -43,20 +81,34 @@ func NewProxyService(
 		HTTPTestingSites:  httpTestingSites,
 		HTTPSTestingSites: httpsTestingSites,
 		UserAgents:        userAgents,
-		Semaphore:         make(chan struct{}, 500),
+		Timeout:           timeout,
+		JudgeURL:          judgeURL,
+		VerifyTLS:         verifyTLS,
+		ConnectTargets:    connectTargets,
+		ProxyHeaders:      proxyHeaders,
+		Semaphore:         make(chan struct{}, concurrency),
 	}
 }
 
-func (s *ProxyService) Check(category string, ip string, port string) (*entity.Proxy, error) {
-	s.Semaphore <- struct{}{}
-	defer func() { <-s.Semaphore }()
+func (s *ProxyService) Check(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error) {
+	select {
+	case s.Semaphore <- struct{}{}:
+		defer func() { <-s.Semaphore }()
+	case <-ctx.Done():
+		return nil, fmt.Errorf("check canceled: %v", ctx.Err())
+	}
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("check canceled: %v", ctx.Err())
+	}
 
 	var (
 		transport   *http.Transport
-		proxy       = ip + ":" + port
-		proxyURI    = strings.ToLower(category + "://" + proxy)
+		intercepted atomic.Bool
+		proxy       = net.JoinHostPort(ip, port)
+		proxyURI    = strings.ToLower(category) + "://" + proxy
 		testingSite = s.GetTestingSite(category)
-		timeout     = 60 * time.Second
+		timeout     = s.Timeout
 	)
 
 	if category == "HTTP" || category == "HTTPS" {
@@ -64,6 +116,9 @@ func (s *ProxyService) Check(category string, ip string, port string) (*entity.P
 		if err != nil {
 			return nil, fmt.Errorf("error parsing proxy URL: %v", err)
 		}
+		if username != "" {
+			proxyURL.User = url.UserPassword(username, password)
+		}
 
 		transport = &http.Transport{
 			Proxy:             http.ProxyURL(proxyURL),
@@ -75,33 +130,34 @@ func (s *ProxyService) Check(category string, ip string, port string) (*entity.P
 			TLSHandshakeTimeout: timeout,
 			TLSClientConfig: &tls.Config{
 				InsecureSkipVerify: category == "HTTPS",
+				VerifyConnection: func(state tls.ConnectionState) error {
+					// The same config is used for the handshake with an HTTPS proxy itself, only the testing site is verified
+					if siteURL, err := url.Parse(testingSite.URL); s.VerifyTLS && err == nil && state.ServerName == siteURL.Hostname() {
+						intercepted.Store(s.IsIntercepted(&testingSite, &state))
+					}
+					return nil
+				},
 			},
 		}
-	} else if category == "SOCKS4" || category == "SOCKS5" {
-		proxyURL := socks.Dial(proxyURI)
-		transport = &http.Transport{
-			Dial:              proxyURL,
-			DisableKeepAlives: true,
-			DialContext: (&net.Dialer{
-				Timeout:   timeout,
-				KeepAlive: timeout,
-			}).DialContext,
-		}
+	} else if category == "SOCKS4" || category == "SOCKS4A" || category == "SOCKS5" || category == "SOCKS5H" {
+		transport = s.newSOCKSTransport(category, proxy, username, password, category == "SOCKS4A" || category == "SOCKS5H")
 	} else {
 		return nil, fmt.Errorf("proxy category %s not supported", category)
 	}
 
-	req, err := s.FetcherUtil.NewRequest("GET", testingSite, nil)
+	// In-flight checks are drained rather than aborted, bounded by the timeout
+	req, err := s.FetcherUtil.NewRequest(context.WithoutCancel(ctx), "GET", testingSite.URL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("error creating request: %s", err)
 	}
 	req.Header.Set("User-Agent", s.GetRandomUserAgent())
 
-	startTime := time.Now()
-	resp, err := s.FetcherUtil.Do(&http.Client{
+	client := &http.Client{
 		Transport: transport,
 		Timeout:   timeout,
-	}, req)
+	}
+	startTime := time.Now()
+	resp, err := s.FetcherUtil.Do(client, req)
 
 	// statusCode := ""
 	// if err == nil {
@@ -120,17 +176,256 @@ func (s *ProxyService) Check(category string, ip string, port string) (*entity.P
 		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
 	}
 
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %v", err)
+	}
+
+	if err := s.ValidateResponse(&testingSite, resp.Header, body); err != nil {
+		return nil, err
+	}
+
+	// The judge only classifies the anonymity, the proxy passes or fails on the testing site of its category
+	anonymity := ""
+	if s.JudgeURL != "" {
+		if anonymity, err = s.judgeAnonymity(ctx, client); err != nil {
+			return nil, err
+		}
+	}
+
+	remoteDNS := category == "SOCKS4A" || category == "SOCKS5H"
+	if category == "SOCKS4" || category == "SOCKS5" {
+		remoteDNS = s.resolvesRemotely(ctx, category, proxy, username, password, &testingSite)
+	}
+
+	var connectPorts []int
+	if category == "HTTP" || category == "HTTPS" {
+		connectPorts = s.ProbeConnect(ctx, category, proxy, username, password)
+	}
+
+	exitIP := s.GetExitIP(body)
 	return &entity.Proxy{
-		Proxy:     proxy,
-		IP:        ip,
-		Port:      port,
-		Category:  category,
-		CheckedAt: endTime.Format(time.RFC3339),
-		TimeTaken: timeTaken,
+		Proxy:        proxy,
+		IP:           ip,
+		Port:         port,
+		Username:     username,
+		Password:     password,
+		Category:     category,
+		CheckedAt:    endTime.Format(time.RFC3339),
+		TimeTaken:    timeTaken,
+		Anonymity:    anonymity,
+		ExitIP:       exitIP,
+		IsRotating:   exitIP != "" && !net.ParseIP(exitIP).Equal(net.ParseIP(ip)),
+		IsMITM:       intercepted.Load(),
+		RemoteDNS:    remoteDNS,
+		ConnectPorts: connectPorts,
 	}, nil
 }
 
-func (s *ProxyService) GetTestingSite(category string) string {
+func (s *ProxyService) judgeAnonymity(ctx context.Context, client *http.Client) (string, error) {
+	req, err := s.FetcherUtil.NewRequest(context.WithoutCancel(ctx), "GET", s.JudgeURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating judge request: %s", err)
+	}
+	req.Header.Set("User-Agent", s.GetRandomUserAgent())
+
+	resp, err := s.FetcherUtil.Do(client, req)
+	if err != nil {
+		return "", fmt.Errorf("judge request error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected judge status code %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	judgeResponse := entity.JudgeResponse{}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&judgeResponse); err != nil {
+		return "", fmt.Errorf("error decoding judge response: %v", err)
+	}
+	return s.GetAnonymity(&judgeResponse, s.GetRealIP(ctx)), nil
+}
+
+func (s *ProxyService) newSOCKSTransport(category string, proxy string, username string, password string, remoteDNS bool) *http.Transport {
+	return &http.Transport{
+		DisableKeepAlives: true,
+		DialContext:       s.newSOCKSDialer(category, proxy, username, password, remoteDNS),
+	}
+}
+
+func (s *ProxyService) newSOCKSDialer(category string, proxy string, username string, password string, remoteDNS bool) func(ctx context.Context, network string, addr string) (net.Conn, error) {
+	scheme := "socks5"
+	if strings.HasPrefix(category, "SOCKS4") {
+		scheme = "socks4"
+		if remoteDNS {
+			scheme = "socks4a"
+		}
+	}
+
+	socksURL := &url.URL{
+		Scheme:   scheme,
+		Host:     proxy,
+		RawQuery: "timeout=" + s.Timeout.String(),
+	}
+	if username != "" {
+		socksURL.User = url.UserPassword(username, password)
+	}
+	dial := socks.Dial(socksURL.String())
+
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		// SOCKS5 always sends the hostname, so resolve it here unless the proxy is meant to
+		if scheme == "socks5" && !remoteDNS {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			if net.ParseIP(host) == nil {
+				ips, err := net.DefaultResolver.LookupIP(ctx, "ip", host)
+				if err != nil {
+					return nil, err
+				}
+				addr = net.JoinHostPort(ips[0].String(), port)
+			}
+		}
+		return dial(network, addr)
+	}
+}
+
+func (s *ProxyService) resolvesRemotely(ctx context.Context, category string, proxy string, username string, password string, testingSite *entity.TestingSite) bool {
+	siteURL, err := url.Parse(testingSite.URL)
+	if err != nil || net.ParseIP(siteURL.Hostname()) != nil {
+		return false
+	}
+
+	// A probe already started is drained like the check itself, but none is started once the run is interrupted
+	if ctx.Err() != nil {
+		return false
+	}
+
+	req, err := s.FetcherUtil.NewRequest(context.WithoutCancel(ctx), "GET", testingSite.URL, nil)
+	if err != nil {
+		return false
+	}
+	req.Header.Set("User-Agent", s.GetRandomUserAgent())
+
+	resp, err := s.FetcherUtil.Do(&http.Client{
+		Transport: s.newSOCKSTransport(category, proxy, username, password, true),
+		Timeout:   s.Timeout,
+	}, req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK
+}
+
+func (s *ProxyService) ProbeConnect(ctx context.Context, category string, proxy string, username string, password string) []int {
+	var ports []int
+	for _, target := range s.ConnectTargets {
+		if ctx.Err() != nil {
+			break
+		}
+
+		_, port, err := net.SplitHostPort(target)
+		if err != nil {
+			continue
+		}
+		portNumber, err := strconv.Atoi(port)
+		if err != nil || slices.Contains(ports, portNumber) {
+			continue
+		}
+
+		if conn, err := s.dialConnect(context.WithoutCancel(ctx), category, proxy, username, password, target); err == nil {
+			conn.Close()
+			ports = append(ports, portNumber)
+		}
+	}
+	slices.Sort(ports)
+
+	return ports
+}
+
+func (s *ProxyService) Dial(ctx context.Context, category string, proxy string, username string, password string, addr string) (net.Conn, error) {
+	if category == "HTTP" || category == "HTTPS" {
+		return s.dialConnect(ctx, category, proxy, username, password, addr)
+	} else if category == "SOCKS4" || category == "SOCKS4A" || category == "SOCKS5" || category == "SOCKS5H" {
+		return s.newSOCKSDialer(category, proxy, username, password, category == "SOCKS4A" || category == "SOCKS5H")(ctx, "tcp", addr)
+	}
+	return nil, fmt.Errorf("proxy category %s not supported", category)
+}
+
+func (s *ProxyService) dialConnect(ctx context.Context, category string, proxy string, username string, password string, target string) (_ net.Conn, err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.Timeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", proxy)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			conn.Close()
+		}
+	}()
+
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if category == "HTTPS" {
+		tlsConn := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: target},
+		Host:   target,
+		Header: http.Header{},
+	}
+	if username != "" {
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(username+":"+password)))
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+	// Servers speaking first (SSH, SMTP) may have sent their banner along with the response
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, Reader: reader}, nil
+	}
+	return conn, nil
+}
+
+type bufferedConn struct {
+	net.Conn
+	Reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.Reader.Read(b)
+}
+
+func (s *ProxyService) GetTestingSite(category string) entity.TestingSite {
 	if category == "HTTPS" {
 		return s.HTTPSTestingSites[rand.Intn(len(s.HTTPSTestingSites))]
 	}
@@ -140,3 +435,146 @@ func (s *ProxyService) GetTestingSite(category string) string {
 func (s *ProxyService) GetRandomUserAgent() string {
 	return s.UserAgents[rand.Intn(len(s.UserAgents))]
 }
+
+// GetRealIP asks the judge for the IP of this host once, a failed request is retried by the next caller instead of
+// leaving every later check without it
+func (s *ProxyService) GetRealIP(ctx context.Context) string {
+	s.RealIPMutex.Lock()
+	defer s.RealIPMutex.Unlock()
+
+	if s.RealIP != "" {
+		return s.RealIP
+	}
+
+	req, err := s.FetcherUtil.NewRequest(context.WithoutCancel(ctx), "GET", s.JudgeURL, nil)
+	if err != nil {
+		return ""
+	}
+
+	resp, err := s.FetcherUtil.Do(&http.Client{Timeout: s.Timeout}, req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	judgeResponse := entity.JudgeResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&judgeResponse); err == nil {
+		s.RealIP = judgeResponse.IP
+	}
+	return s.RealIP
+}
+
+func (s *ProxyService) GetAnonymity(judgeResponse *entity.JudgeResponse, realIP string) string {
+	if realIP != "" && judgeResponse.IP == realIP {
+		return "transparent"
+	}
+
+	anonymity := "elite"
+	for name, values := range judgeResponse.Headers {
+		for _, value := range values {
+			fields := strings.FieldsFunc(value, func(r rune) bool {
+				return strings.ContainsRune(" ,;=\"[]", r)
+			})
+			for _, field := range fields {
+				if host, _, err := net.SplitHostPort(field); err == nil {
+					field = host
+				}
+				if realIP != "" && field == realIP {
+					return "transparent"
+				}
+			}
+		}
+
+		if slices.ContainsFunc(s.ProxyHeaders, func(header string) bool {
+			return strings.EqualFold(header, name)
+		}) {
+			anonymity = "anonymous"
+		}
+	}
+	return anonymity
+}
+
+func (s *ProxyService) GetExitIP(body []byte) string {
+	ipResponse := struct {
+		IP     string `json:"ip"`
+		Origin string `json:"origin"`
+	}{}
+	exitIP := strings.TrimSpace(string(body))
+	if err := json.Unmarshal(body, &ipResponse); err == nil {
+		exitIP = ipResponse.IP
+		if exitIP == "" {
+			// httpbin-style echoes list every hop, the first one is the client
+			exitIP, _, _ = strings.Cut(ipResponse.Origin, ",")
+		}
+	}
+
+	if ipAddress := net.ParseIP(strings.TrimSpace(exitIP)); ipAddress != nil {
+		return ipAddress.String()
+	}
+	return ""
+}
+
+func (s *ProxyService) ValidateResponse(testingSite *entity.TestingSite, header http.Header, body []byte) error {
+	if testingSite.Regex != "" {
+		// Sites that did not go through the config validation are compiled on the spot
+		re := testingSite.Pattern
+		if re == nil {
+			var err error
+			if re, err = regexp.Compile(testingSite.Regex); err != nil {
+				return fmt.Errorf("error compiling testing site regex: %v", err)
+			}
+		}
+		if !re.Match(body) {
+			return fmt.Errorf("%w: body does not match %s", ErrResponseRejected, testingSite.Regex)
+		}
+	}
+
+	if testingSite.Body != "" && string(body) != testingSite.Body {
+		return fmt.Errorf("%w: body differs from the expected body", ErrResponseRejected)
+	}
+
+	if testingSite.Header != "" {
+		name, value, hasValue := strings.Cut(testingSite.Header, ":")
+		values, found := header[http.CanonicalHeaderKey(strings.TrimSpace(name))]
+		if !found || (hasValue && !slices.Contains(values, strings.TrimSpace(value))) {
+			return fmt.Errorf("%w: header %s missing", ErrResponseRejected, testingSite.Header)
+		}
+	}
+
+	if testingSite.SHA256 != "" {
+		sum := sha256.Sum256(body)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), testingSite.SHA256) {
+			return fmt.Errorf("%w: body sha256 differs from %s", ErrResponseRejected, testingSite.SHA256)
+		}
+	}
+
+	return nil
+}
+
+func (s *ProxyService) IsIntercepted(testingSite *entity.TestingSite, state *tls.ConnectionState) bool {
+	if len(state.PeerCertificates) == 0 {
+		return true
+	}
+	leaf := state.PeerCertificates[0]
+
+	if testingSite.CertSHA256 != "" {
+		sum := sha256.Sum256(leaf.Raw)
+		return !strings.EqualFold(hex.EncodeToString(sum[:]), testingSite.CertSHA256)
+	}
+
+	if testingSite.SPKIPin != "" {
+		sum := sha256.Sum256(leaf.RawSubjectPublicKeyInfo)
+		return base64.StdEncoding.EncodeToString(sum[:]) != strings.TrimPrefix(testingSite.SPKIPin, "sha256/")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range state.PeerCertificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		DNSName:       state.ServerName,
+		Roots:         s.RootCAs,
+		Intermediates: intermediates,
+	})
+	return err != nil
+}
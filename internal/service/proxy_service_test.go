@@ -1,13 +1,25 @@
 package service
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -16,6 +28,7 @@ import (
 )
 
 var (
+	unexpectedMessage                 = "Unexpected %v: %v"
 	expectedButGotMessage             = "Expected %v = %v, but got = %v"
 	expectedErrorButGotMessage        = "Expected %v error = %v, but got = %v"
 	expectedNonEmptyMessage           = "Expected non-empty %v from %v"
@@ -24,12 +37,23 @@ var (
 	testIP                            = "13.37.0.1"
 	testPort                          = "8080"
 	testProxy                         = testIP + ":" + testPort
+	testUsername                      = "user"
+	testPassword                      = "p@ss:word"
+	testIPv6                          = "2606:4700::1111"
+	testProxyIPv6                     = "[" + testIPv6 + "]:" + testPort
 	testHTTPCategory                  = "HTTP"
 	testHTTPSCategory                 = "HTTPS"
 	testSOCKS4Category                = "SOCKS4"
-	testHTTPTestingSites              = []string{"http://test1.com", "http://test2.com"}
-	testHTTPSTestingSites             = []string{"https://secure1.com", "https://secure2.com"}
+	testSOCKS5Category                = "SOCKS5"
+	testHTTPTestingSites              = []entity.TestingSite{{URL: "http://test1.com"}, {URL: "http://test2.com"}}
+	testHTTPSTestingSites             = []entity.TestingSite{{URL: "https://secure1.com"}, {URL: "https://secure2.com"}}
 	testUserAgents                    = []string{"Mozilla", "Chrome", "Safari"}
+	testConcurrency                   = 10
+	testJudgeURL                      = "http://judge.example.com"
+	testProxyHeaders                  = []string{"Via", "X-Forwarded-For"}
+	testConnectTargets                = []string{"example.com:443", "example.com:22", "example.com:25"}
+	testRealIP                        = "13.37.13.37"
+	testTimeout                       = 10 * time.Second
 )
 
 type mockURLParserUtil struct {
@@ -46,17 +70,24 @@ func (m *mockURLParserUtil) Parse(urlStr string) (*url.URL, error) {
 type mockFetcherUtil struct {
 	fetchDataByte  []byte
 	fetcherError   error
-	NewRequestFunc func(method, url string, body io.Reader) (*http.Request, error)
+	NewRequestFunc func(ctx context.Context, method, url string, body io.Reader) (*http.Request, error)
 	DoFunc         func(client *http.Client, req *http.Request) (*http.Response, error)
 }
 
-func (m *mockFetcherUtil) FetchData(url string) ([]byte, error) {
+func (m *mockFetcherUtil) FetchData(ctx context.Context, url string) ([]byte, error) {
 	if m.fetcherError != nil {
 		return nil, m.fetcherError
 	}
 	return m.fetchDataByte, nil
 }
 
+func (m *mockFetcherUtil) FetchStream(ctx context.Context, url string) (io.ReadCloser, error) {
+	if m.fetcherError != nil {
+		return nil, m.fetcherError
+	}
+	return io.NopCloser(bytes.NewReader(m.fetchDataByte)), nil
+}
+
 func (m *mockFetcherUtil) Do(client *http.Client, req *http.Request) (*http.Response, error) {
 	if m.DoFunc != nil {
 		return m.DoFunc(client, req)
@@ -64,15 +95,15 @@ func (m *mockFetcherUtil) Do(client *http.Client, req *http.Request) (*http.Resp
 	return httptest.NewRecorder().Result(), nil
 }
 
-func (m *mockFetcherUtil) NewRequest(method string, url string, body io.Reader) (*http.Request, error) {
+func (m *mockFetcherUtil) NewRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
 	if m.NewRequestFunc != nil {
-		return m.NewRequestFunc(method, url, body)
+		return m.NewRequestFunc(ctx, method, url, body)
 	}
-	return http.NewRequest(method, url, body)
+	return http.NewRequestWithContext(ctx, method, url, body)
 }
 
 func TestNewProxyService(t *testing.T) {
-	proxyService := NewProxyService(&mockFetcherUtil{}, &mockURLParserUtil{}, testHTTPTestingSites, testHTTPSTestingSites, testUserAgents)
+	proxyService := NewProxyService(&mockFetcherUtil{}, &mockURLParserUtil{}, testHTTPTestingSites, testHTTPSTestingSites, testUserAgents, testConcurrency, testTimeout, testJudgeURL, false, testConnectTargets, testProxyHeaders)
 	if proxyService == nil {
 		t.Errorf(expectedReturnNonNil, "NewProxyService", "ProxyServiceInterface")
 	}
@@ -93,6 +124,22 @@ func TestNewProxyService(t *testing.T) {
 	if !reflect.DeepEqual(s.UserAgents, testUserAgents) {
 		t.Errorf(expectedButGotMessage, "UserAgents", testUserAgents, s.UserAgents)
 	}
+
+	if s.Timeout != testTimeout {
+		t.Errorf(expectedButGotMessage, "Timeout", testTimeout, s.Timeout)
+	}
+
+	if s.JudgeURL != testJudgeURL {
+		t.Errorf(expectedButGotMessage, "JudgeURL", testJudgeURL, s.JudgeURL)
+	}
+
+	if !reflect.DeepEqual(s.ConnectTargets, testConnectTargets) {
+		t.Errorf(expectedButGotMessage, "ConnectTargets", testConnectTargets, s.ConnectTargets)
+	}
+
+	if cap(s.Semaphore) != testConcurrency {
+		t.Errorf(expectedButGotMessage, "Semaphore capacity", testConcurrency, cap(s.Semaphore))
+	}
 }
 
 func TestCheck(t *testing.T) {
@@ -102,9 +149,12 @@ func TestCheck(t *testing.T) {
 	}
 
 	type args struct {
+		ctx      context.Context
 		category string
 		ip       string
 		port     string
+		username string
+		password string
 	}
 
 	tests := []struct {
@@ -121,6 +171,7 @@ func TestCheck(t *testing.T) {
 				urlParserUtil: &mockURLParserUtil{},
 			},
 			args: args{
+				ctx:      context.Background(),
 				category: testHTTPSCategory,
 				ip:       testIP,
 				port:     testPort,
@@ -135,6 +186,72 @@ func TestCheck(t *testing.T) {
 			},
 			wantError: nil,
 		},
+		{
+			name: "TestValidIPv6",
+			fields: fields{
+				fetcherUtil: &mockFetcherUtil{
+					DoFunc: func(client *http.Client, req *http.Request) (*http.Response, error) {
+						proxyURL, err := client.Transport.(*http.Transport).Proxy(req)
+						if err != nil || proxyURL.Host != testProxyIPv6 {
+							return nil, fmt.Errorf("unexpected proxy %v", proxyURL)
+						}
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       http.NoBody,
+						}, nil
+					},
+				},
+				urlParserUtil: &mockURLParserUtil{},
+			},
+			args: args{
+				ctx:      context.Background(),
+				category: testHTTPCategory,
+				ip:       testIPv6,
+				port:     testPort,
+			},
+			want: &entity.Proxy{
+				Category: testHTTPCategory,
+				Proxy:    testProxyIPv6,
+				IP:       testIPv6,
+				Port:     testPort,
+			},
+			wantError: nil,
+		},
+		{
+			name: "TestValidWithCredentials",
+			fields: fields{
+				fetcherUtil: &mockFetcherUtil{
+					DoFunc: func(client *http.Client, req *http.Request) (*http.Response, error) {
+						proxyURL, err := client.Transport.(*http.Transport).Proxy(req)
+						if password, _ := proxyURL.User.Password(); err != nil || proxyURL.User.Username() != testUsername || password != testPassword {
+							return nil, fmt.Errorf("unexpected proxy %v", proxyURL)
+						}
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       http.NoBody,
+						}, nil
+					},
+				},
+				urlParserUtil: &mockURLParserUtil{},
+			},
+			args: args{
+				ctx:      context.Background(),
+				category: testHTTPCategory,
+				ip:       testIP,
+				port:     testPort,
+				username: testUsername,
+				password: testPassword,
+			},
+			want: &entity.Proxy{
+				Category: testHTTPCategory,
+				Proxy:    testProxy,
+				IP:       testIP,
+				Port:     testPort,
+				Username: testUsername,
+				Password: testPassword,
+			},
+			wantError: nil,
+		},
 		{
 			name: "TestErrorParseURL",
 			fields: fields{
@@ -146,6 +263,7 @@ func TestCheck(t *testing.T) {
 				},
 			},
 			args: args{
+				ctx:      context.Background(),
 				category: testHTTPCategory,
 				ip:       testIP,
 				port:     testPort,
@@ -157,12 +275,13 @@ func TestCheck(t *testing.T) {
 			name: "TestCreatingRequest",
 			fields: fields{
 				fetcherUtil: &mockFetcherUtil{
-					NewRequestFunc: func(method, url string, body io.Reader) (*http.Request, error) {
+					NewRequestFunc: func(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
 						return nil, errors.New("error creating request")
 					},
 				},
 			},
 			args: args{
+				ctx:      context.Background(),
 				category: testSOCKS4Category,
 				ip:       testIP,
 				port:     testPort,
@@ -174,6 +293,7 @@ func TestCheck(t *testing.T) {
 			name:   "TestUnsupportedProxyCategory",
 			fields: fields{},
 			args: args{
+				ctx:      context.Background(),
 				category: "FTP",
 				ip:       testIP,
 				port:     testPort,
@@ -192,6 +312,7 @@ func TestCheck(t *testing.T) {
 				urlParserUtil: &mockURLParserUtil{},
 			},
 			args: args{
+				ctx:      context.Background(),
 				category: testHTTPCategory,
 				ip:       testIP,
 				port:     testPort,
@@ -213,6 +334,7 @@ func TestCheck(t *testing.T) {
 				urlParserUtil: &mockURLParserUtil{},
 			},
 			args: args{
+				ctx:      context.Background(),
 				category: testHTTPCategory,
 				ip:       testIP,
 				port:     testPort,
@@ -220,6 +342,25 @@ func TestCheck(t *testing.T) {
 			want:      nil,
 			wantError: errors.New("unexpected status code 500: Internal Server Error"),
 		},
+		{
+			name: "TestCanceled",
+			fields: fields{
+				fetcherUtil:   &mockFetcherUtil{},
+				urlParserUtil: &mockURLParserUtil{},
+			},
+			args: args{
+				ctx: func() context.Context {
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+					return ctx
+				}(),
+				category: testHTTPCategory,
+				ip:       testIP,
+				port:     testPort,
+			},
+			want:      nil,
+			wantError: errors.New("check canceled: context canceled"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -230,9 +371,10 @@ func TestCheck(t *testing.T) {
 				HTTPTestingSites:  testHTTPTestingSites,
 				HTTPSTestingSites: testHTTPSTestingSites,
 				UserAgents:        testUserAgents,
-				Semaphore:         make(chan struct{}, 10),
+				Timeout:           testTimeout,
+				Semaphore:         make(chan struct{}, testConcurrency),
 			}
-			got, err := s.Check(tt.args.category, tt.args.ip, tt.args.port)
+			got, err := s.Check(tt.args.ctx, tt.args.category, tt.args.ip, tt.args.port, tt.args.username, tt.args.password)
 
 			if (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) ||
 				(err == nil && tt.wantError != nil) ||
@@ -244,23 +386,811 @@ func TestCheck(t *testing.T) {
 				(!reflect.DeepEqual(got.Category, tt.want.Category) ||
 					!reflect.DeepEqual(got.Proxy, tt.want.Proxy) ||
 					!reflect.DeepEqual(got.IP, tt.want.IP) ||
-					!reflect.DeepEqual(got.Port, tt.want.Port)) {
+					!reflect.DeepEqual(got.Port, tt.want.Port) ||
+					!reflect.DeepEqual(got.Username, tt.want.Username) ||
+					!reflect.DeepEqual(got.Password, tt.want.Password)) {
 				t.Errorf(expectedButGotMessage, "ProxyService.Check()", tt.want, got)
 			}
 		})
 	}
 }
 
+func TestCheckSOCKSDialsProxy(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	defer listener.Close()
+
+	accepted := make(chan struct{}, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			accepted <- struct{}{}
+			conn.Close()
+		}
+	}()
+
+	ip, port, _ := net.SplitHostPort(listener.Addr().String())
+	s := &ProxyService{
+		FetcherUtil: &mockFetcherUtil{
+			DoFunc: func(client *http.Client, req *http.Request) (*http.Response, error) {
+				_, err := client.Transport.(*http.Transport).DialContext(req.Context(), "tcp", testProxy)
+				return nil, err
+			},
+		},
+		URLParserUtil:     &mockURLParserUtil{},
+		HTTPTestingSites:  testHTTPTestingSites,
+		HTTPSTestingSites: testHTTPSTestingSites,
+		UserAgents:        testUserAgents,
+		Timeout:           testTimeout,
+		Semaphore:         make(chan struct{}, testConcurrency),
+	}
+	s.Check(context.Background(), testSOCKS4Category, ip, port, "", "")
+
+	select {
+	case <-accepted:
+	case <-time.After(testTimeout):
+		t.Errorf(expectedButGotMessage, "SOCKS dial", listener.Addr(), "no connection")
+	}
+}
+
+func TestCheckSOCKS5Authenticates(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	defer listener.Close()
+
+	credentials := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// Greeting offering username/password authentication
+		greeting := make([]byte, 3)
+		if _, err := io.ReadFull(conn, greeting); err != nil {
+			return
+		}
+		conn.Write([]byte{5, 2})
+
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		username := make([]byte, header[1])
+		io.ReadFull(conn, username)
+		length := make([]byte, 1)
+		io.ReadFull(conn, length)
+		password := make([]byte, length[0])
+		io.ReadFull(conn, password)
+		credentials <- string(username) + ":" + string(password)
+	}()
+
+	ip, port, _ := net.SplitHostPort(listener.Addr().String())
+	s := &ProxyService{
+		FetcherUtil: &mockFetcherUtil{
+			DoFunc: func(client *http.Client, req *http.Request) (*http.Response, error) {
+				_, err := client.Transport.(*http.Transport).DialContext(req.Context(), "tcp", testProxy)
+				return nil, err
+			},
+		},
+		URLParserUtil:     &mockURLParserUtil{},
+		HTTPTestingSites:  testHTTPTestingSites,
+		HTTPSTestingSites: testHTTPSTestingSites,
+		UserAgents:        testUserAgents,
+		Timeout:           testTimeout,
+		Semaphore:         make(chan struct{}, testConcurrency),
+	}
+	go s.Check(context.Background(), testSOCKS5Category, ip, port, testUsername, testPassword)
+
+	select {
+	case got := <-credentials:
+		if got != testUsername+":"+testPassword {
+			t.Errorf(expectedButGotMessage, "SOCKS5 credentials", testUsername+":"+testPassword, got)
+		}
+	case <-time.After(testTimeout):
+		t.Errorf(expectedButGotMessage, "SOCKS5 credentials", testUsername+":"+testPassword, "no authentication")
+	}
+}
+
+func serveSOCKS5(t *testing.T, remoteDNS bool) (string, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+
+				greeting := make([]byte, 3)
+				if _, err := io.ReadFull(conn, greeting); err != nil {
+					return
+				}
+				conn.Write([]byte{5, 0})
+
+				header := make([]byte, 5)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				host := make([]byte, header[4]+2)
+				if _, err := io.ReadFull(conn, host); err != nil {
+					return
+				}
+				if net.ParseIP(string(host[:header[4]])) == nil && !remoteDNS {
+					conn.Write([]byte{5, 4, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+				if _, err := http.ReadRequest(bufio.NewReader(conn)); err != nil {
+					return
+				}
+				conn.Write([]byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nOK"))
+			}()
+		}
+	}()
+
+	ip, port, _ := net.SplitHostPort(listener.Addr().String())
+	return ip, port
+}
+
+func TestCheckRecordsRemoteDNS(t *testing.T) {
+	tests := []struct {
+		name      string
+		category  string
+		remoteDNS bool
+		want      bool
+		wantErr   bool
+	}{
+		{
+			name:      "ResolvesRemotely",
+			category:  testSOCKS5Category,
+			remoteDNS: true,
+			want:      true,
+		},
+		{
+			name:      "ResolvesLocallyOnly",
+			category:  testSOCKS5Category,
+			remoteDNS: false,
+			want:      false,
+		},
+		{
+			name:      "SOCKS5H",
+			category:  "SOCKS5H",
+			remoteDNS: true,
+			want:      true,
+		},
+		{
+			name:      "SOCKS5HWithoutRemoteDNS",
+			category:  "SOCKS5H",
+			remoteDNS: false,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, port := serveSOCKS5(t, tt.remoteDNS)
+			s := &ProxyService{
+				FetcherUtil: &mockFetcherUtil{
+					DoFunc: func(client *http.Client, req *http.Request) (*http.Response, error) {
+						return client.Do(req)
+					},
+				},
+				URLParserUtil:     &mockURLParserUtil{},
+				HTTPTestingSites:  []entity.TestingSite{{URL: "http://localhost/"}},
+				HTTPSTestingSites: testHTTPSTestingSites,
+				UserAgents:        testUserAgents,
+				Timeout:           testTimeout,
+				Semaphore:         make(chan struct{}, testConcurrency),
+			}
+
+			got, err := s.Check(context.Background(), tt.category, ip, port, "", "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf(expectedErrorButGotMessage, "ProxyService.Check()", tt.wantErr, err)
+			}
+			if err == nil && got.RemoteDNS != tt.want {
+				t.Errorf(expectedButGotMessage, "RemoteDNS", tt.want, got.RemoteDNS)
+			}
+		})
+	}
+}
+
+func TestCheckWithJudge(t *testing.T) {
+	var (
+		mutex    sync.Mutex
+		requests []string
+	)
+	s := &ProxyService{
+		FetcherUtil: &mockFetcherUtil{
+			DoFunc: func(client *http.Client, req *http.Request) (*http.Response, error) {
+				mutex.Lock()
+				requests = append(requests, req.URL.String())
+				mutex.Unlock()
+
+				body := testIP
+				if req.URL.String() == testJudgeURL {
+					body = `{"ip":"` + testRealIP + `","headers":{}}`
+					if client.Transport != nil {
+						body = `{"ip":"` + testIP + `","headers":{"Via":["1.1 proxy"]}}`
+					}
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(body)),
+				}, nil
+			},
+		},
+		URLParserUtil:     &mockURLParserUtil{},
+		HTTPTestingSites:  testHTTPTestingSites,
+		HTTPSTestingSites: testHTTPSTestingSites,
+		UserAgents:        testUserAgents,
+		Timeout:           testTimeout,
+		JudgeURL:          testJudgeURL,
+		ProxyHeaders:      testProxyHeaders,
+		Semaphore:         make(chan struct{}, testConcurrency),
+	}
+
+	got, err := s.Check(context.Background(), testHTTPCategory, testIP, testPort, "", "")
+	if err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "ProxyService.Check()", nil, err)
+	}
+
+	if got.Anonymity != "anonymous" {
+		t.Errorf(expectedButGotMessage, "Anonymity", "anonymous", got.Anonymity)
+	}
+
+	if s.RealIP != testRealIP {
+		t.Errorf(expectedButGotMessage, "RealIP", testRealIP, s.RealIP)
+	}
+
+	// HTTPS proxies are still checked against an HTTPS testing site, the plain HTTP judge is only asked for the anonymity
+	requests = nil
+	if _, err := s.Check(context.Background(), testHTTPSCategory, testIP, testPort, "", ""); err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "ProxyService.Check()", nil, err)
+	}
+
+	if len(requests) != 2 || !strings.HasPrefix(requests[0], "https://") || requests[1] != testJudgeURL {
+		t.Errorf(expectedButGotMessage, "requests", "[<https testing site> "+testJudgeURL+"]", requests)
+	}
+}
+
+func TestGetAnonymity(t *testing.T) {
+	tests := []struct {
+		name          string
+		judgeResponse entity.JudgeResponse
+		realIP        string
+		want          string
+	}{
+		{
+			name: "Elite",
+			judgeResponse: entity.JudgeResponse{
+				IP:      testIP,
+				Headers: map[string][]string{"User-Agent": {"Mozilla"}},
+			},
+			realIP: testRealIP,
+			want:   "elite",
+		},
+		{
+			name: "Anonymous",
+			judgeResponse: entity.JudgeResponse{
+				IP:      testIP,
+				Headers: map[string][]string{"X-Forwarded-For": {testIP}},
+			},
+			realIP: testRealIP,
+			want:   "anonymous",
+		},
+		{
+			name: "TransparentForwardedFor",
+			judgeResponse: entity.JudgeResponse{
+				IP:      testIP,
+				Headers: map[string][]string{"X-Forwarded-For": {testRealIP + ", " + testIP}},
+			},
+			realIP: testRealIP,
+			want:   "transparent",
+		},
+		{
+			name: "TransparentForwarded",
+			judgeResponse: entity.JudgeResponse{
+				IP:      testIP,
+				Headers: map[string][]string{"Forwarded": {`for="` + testRealIP + `:4711";proto=http`}},
+			},
+			realIP: testRealIP,
+			want:   "transparent",
+		},
+		{
+			name: "TransparentIP",
+			judgeResponse: entity.JudgeResponse{
+				IP: testRealIP,
+			},
+			realIP: testRealIP,
+			want:   "transparent",
+		},
+		{
+			name: "UnknownRealIP",
+			judgeResponse: entity.JudgeResponse{
+				IP:      testIP,
+				Headers: map[string][]string{"Via": {"1.1 proxy"}},
+			},
+			realIP: "",
+			want:   "anonymous",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ProxyService{
+				ProxyHeaders: testProxyHeaders,
+			}
+			if got := s.GetAnonymity(&tt.judgeResponse, tt.realIP); got != tt.want {
+				t.Errorf(expectedButGotMessage, "GetAnonymity()", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetRealIPError(t *testing.T) {
+	s := &ProxyService{
+		FetcherUtil: &mockFetcherUtil{
+			DoFunc: func(client *http.Client, req *http.Request) (*http.Response, error) {
+				return nil, errors.New("network error")
+			},
+		},
+		JudgeURL: testJudgeURL,
+	}
+
+	if got := s.GetRealIP(context.Background()); got != "" {
+		t.Errorf(expectedButGotMessage, "GetRealIP()", "", got)
+	}
+
+	// The next call retries instead of keeping the failure for the whole run
+	s.FetcherUtil = &mockFetcherUtil{
+		DoFunc: func(client *http.Client, req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"ip":"` + testRealIP + `"}`)),
+			}, nil
+		},
+	}
+	if got := s.GetRealIP(context.Background()); got != testRealIP {
+		t.Errorf(expectedButGotMessage, "GetRealIP()", testRealIP, got)
+	}
+}
+
+func TestCheckRecordsExitIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantExitIP string
+		wantRotate bool
+	}{
+		{
+			name:       "SameIP",
+			body:       testIP + "\n",
+			wantExitIP: testIP,
+			wantRotate: false,
+		},
+		{
+			name:       "RotatingIP",
+			body:       `{"ip":"` + testRealIP + `"}`,
+			wantExitIP: testRealIP,
+			wantRotate: true,
+		},
+		{
+			name:       "NotAnIPEcho",
+			body:       "<html>OK</html>",
+			wantExitIP: "",
+			wantRotate: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ProxyService{
+				FetcherUtil: &mockFetcherUtil{
+					DoFunc: func(client *http.Client, req *http.Request) (*http.Response, error) {
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(strings.NewReader(tt.body)),
+						}, nil
+					},
+				},
+				URLParserUtil:     &mockURLParserUtil{},
+				HTTPTestingSites:  testHTTPTestingSites,
+				HTTPSTestingSites: testHTTPSTestingSites,
+				UserAgents:        testUserAgents,
+				Timeout:           testTimeout,
+				Semaphore:         make(chan struct{}, testConcurrency),
+			}
+
+			got, err := s.Check(context.Background(), testHTTPCategory, testIP, testPort, "", "")
+			if err != nil {
+				t.Fatalf(expectedErrorButGotMessage, "ProxyService.Check()", nil, err)
+			}
+
+			if got.ExitIP != tt.wantExitIP {
+				t.Errorf(expectedButGotMessage, "ExitIP", tt.wantExitIP, got.ExitIP)
+			}
+
+			if got.IsRotating != tt.wantRotate {
+				t.Errorf(expectedButGotMessage, "IsRotating", tt.wantRotate, got.IsRotating)
+			}
+		})
+	}
+}
+
+func TestGetExitIP(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "PlainText",
+			body: " " + testIP + "\n",
+			want: testIP,
+		},
+		{
+			name: "IPv6",
+			body: "2606:4700:0:0:0:0:0:1111",
+			want: "2606:4700::1111",
+		},
+		{
+			name: "JSON",
+			body: `{"ip":"` + testIP + `"}`,
+			want: testIP,
+		},
+		{
+			name: "Origin",
+			body: `{"origin":"` + testIP + `, ` + testRealIP + `"}`,
+			want: testIP,
+		},
+		{
+			name: "NotAnIP",
+			body: "<html></html>",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ProxyService{}
+			if got := s.GetExitIP([]byte(tt.body)); got != tt.want {
+				t.Errorf(expectedButGotMessage, "GetExitIP()", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCheckRejectsAlteredResponse(t *testing.T) {
+	s := &ProxyService{
+		FetcherUtil: &mockFetcherUtil{
+			DoFunc: func(client *http.Client, req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("<html>Login to continue</html>")),
+				}, nil
+			},
+		},
+		URLParserUtil:     &mockURLParserUtil{},
+		HTTPTestingSites:  []entity.TestingSite{{URL: "http://test1.com", Regex: `^\s*[0-9.]+\s*$`}},
+		HTTPSTestingSites: testHTTPSTestingSites,
+		UserAgents:        testUserAgents,
+		Timeout:           testTimeout,
+		Semaphore:         make(chan struct{}, testConcurrency),
+	}
+
+	_, err := s.Check(context.Background(), testHTTPCategory, testIP, testPort, "", "")
+	if !errors.Is(err, ErrResponseRejected) {
+		t.Errorf(expectedErrorButGotMessage, "ProxyService.Check()", ErrResponseRejected, err)
+	}
+}
+
+func TestCheckRecordsMITM(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	s := &ProxyService{
+		FetcherUtil: &mockFetcherUtil{
+			DoFunc: func(client *http.Client, req *http.Request) (*http.Response, error) {
+				verifyConnection := client.Transport.(*http.Transport).TLSClientConfig.VerifyConnection
+				if err := verifyConnection(tls.ConnectionState{ServerName: req.URL.Hostname(), PeerCertificates: []*x509.Certificate{server.Certificate()}}); err != nil {
+					return nil, err
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("OK")),
+				}, nil
+			},
+		},
+		URLParserUtil:     &mockURLParserUtil{},
+		HTTPTestingSites:  testHTTPTestingSites,
+		HTTPSTestingSites: testHTTPSTestingSites,
+		UserAgents:        testUserAgents,
+		Timeout:           testTimeout,
+		VerifyTLS:         true,
+		Semaphore:         make(chan struct{}, testConcurrency),
+	}
+
+	got, err := s.Check(context.Background(), testHTTPSCategory, testIP, testPort, "", "")
+	if err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "ProxyService.Check()", nil, err)
+	}
+
+	if !got.IsMITM {
+		t.Errorf(expectedButGotMessage, "IsMITM", true, got.IsMITM)
+	}
+}
+
+func TestProbeConnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	defer listener.Close()
+
+	authorizations := make(chan string, len(testConnectTargets))
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+
+				req, err := http.ReadRequest(bufio.NewReader(conn))
+				if err != nil {
+					return
+				}
+				authorizations <- req.Header.Get("Proxy-Authorization")
+
+				// Only tunnels to 443 and 22 are allowed
+				if req.Method == http.MethodConnect && (strings.HasSuffix(req.Host, ":443") || strings.HasSuffix(req.Host, ":22")) {
+					conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+				} else {
+					conn.Write([]byte("HTTP/1.1 403 Forbidden\r\nContent-Length: 0\r\n\r\n"))
+				}
+			}()
+		}
+	}()
+
+	s := &ProxyService{
+		ConnectTargets: testConnectTargets,
+		Timeout:        testTimeout,
+	}
+
+	got := s.ProbeConnect(context.Background(), testHTTPCategory, listener.Addr().String(), testUsername, testPassword)
+	want := []int{22, 443}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "ProxyService.ProbeConnect()", want, got)
+	}
+
+	wantAuthorization := "Basic " + base64.StdEncoding.EncodeToString([]byte(testUsername+":"+testPassword))
+	if authorization := <-authorizations; authorization != wantAuthorization {
+		t.Errorf(expectedButGotMessage, "Proxy-Authorization", wantAuthorization, authorization)
+	}
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	closed.Close()
+	if got := s.ProbeConnect(context.Background(), testHTTPCategory, closed.Addr().String(), "", ""); got != nil {
+		t.Errorf(expectedButGotMessage, "ProxyService.ProbeConnect()", nil, got)
+	}
+
+	// No probe is started once the run is interrupted
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := s.ProbeConnect(ctx, testHTTPCategory, listener.Addr().String(), "", ""); got != nil {
+		t.Errorf(expectedButGotMessage, "ProxyService.ProbeConnect()", nil, got)
+	}
+}
+
+func TestResolvesRemotelyCanceled(t *testing.T) {
+	s := &ProxyService{
+		FetcherUtil: &mockFetcherUtil{
+			DoFunc: func(client *http.Client, req *http.Request) (*http.Response, error) {
+				t.Errorf(unexpectedMessage, "request", req.URL)
+				return nil, errors.New("unexpected request")
+			},
+		},
+		UserAgents: testUserAgents,
+		Timeout:    testTimeout,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if s.resolvesRemotely(ctx, testSOCKS5Category, testIP+":"+testPort, "", "", &entity.TestingSite{URL: "https://example.com"}) {
+		t.Errorf(expectedButGotMessage, "resolvesRemotely()", false, true)
+	}
+}
+
+func TestDial(t *testing.T) {
+	ip, port := serveSOCKS5(t, true)
+	s := &ProxyService{
+		Timeout: testTimeout,
+	}
+
+	conn, err := s.Dial(context.Background(), "SOCKS5H", net.JoinHostPort(ip, port), "", "", "example.com:80")
+	if err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "ProxyService.Dial()", nil, err)
+	}
+	conn.Close()
+
+	wantErr := errors.New("proxy category FTP not supported")
+	if _, err := s.Dial(context.Background(), "FTP", net.JoinHostPort(ip, port), "", "", "example.com:80"); err == nil || err.Error() != wantErr.Error() {
+		t.Errorf(expectedErrorButGotMessage, "ProxyService.Dial()", wantErr, err)
+	}
+}
+
+func TestIsIntercepted(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	var (
+		certificate = server.Certificate()
+		certSum     = sha256.Sum256(certificate.Raw)
+		spkiSum     = sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+		rootCAs     = x509.NewCertPool()
+		state       = &tls.ConnectionState{ServerName: "example.com", PeerCertificates: []*x509.Certificate{certificate}}
+	)
+	rootCAs.AddCert(certificate)
+
+	tests := []struct {
+		name        string
+		testingSite entity.TestingSite
+		rootCAs     *x509.CertPool
+		state       *tls.ConnectionState
+		want        bool
+	}{
+		{
+			name:        "CertificatePinMatch",
+			testingSite: entity.TestingSite{URL: "https://example.com", CertSHA256: strings.ToUpper(hex.EncodeToString(certSum[:]))},
+			state:       state,
+			want:        false,
+		},
+		{
+			name:        "CertificatePinMismatch",
+			testingSite: entity.TestingSite{URL: "https://example.com", CertSHA256: strings.Repeat("0", 64)},
+			state:       state,
+			want:        true,
+		},
+		{
+			name:        "SPKIPinMatch",
+			testingSite: entity.TestingSite{URL: "https://example.com", SPKIPin: "sha256/" + base64.StdEncoding.EncodeToString(spkiSum[:])},
+			state:       state,
+			want:        false,
+		},
+		{
+			name:        "TrustedChain",
+			testingSite: entity.TestingSite{URL: "https://example.com"},
+			rootCAs:     rootCAs,
+			state:       state,
+			want:        false,
+		},
+		{
+			name:        "UnknownAuthority",
+			testingSite: entity.TestingSite{URL: "https://example.com"},
+			rootCAs:     x509.NewCertPool(),
+			state:       state,
+			want:        true,
+		},
+		{
+			name:        "NoCertificate",
+			testingSite: entity.TestingSite{URL: "https://example.com"},
+			state:       &tls.ConnectionState{ServerName: "example.com"},
+			want:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ProxyService{RootCAs: tt.rootCAs}
+			if got := s.IsIntercepted(&tt.testingSite, tt.state); got != tt.want {
+				t.Errorf(expectedButGotMessage, "IsIntercepted()", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	var (
+		body   = []byte("13.37.0.1\n")
+		header = http.Header{"Server": {"echo"}}
+	)
+
+	tests := []struct {
+		name        string
+		testingSite entity.TestingSite
+		wantError   error
+	}{
+		{
+			name:        "NoRule",
+			testingSite: entity.TestingSite{URL: "http://test1.com"},
+			wantError:   nil,
+		},
+		{
+			name:        "RegexMatch",
+			testingSite: entity.TestingSite{URL: "http://test1.com", Regex: `^\s*[0-9.]+\s*$`},
+			wantError:   nil,
+		},
+		{
+			name:        "RegexMismatch",
+			testingSite: entity.TestingSite{URL: "http://test1.com", Regex: `^<html>`},
+			wantError:   fmt.Errorf("%w: body does not match ^<html>", ErrResponseRejected),
+		},
+		{
+			name:        "CompiledRegex",
+			testingSite: entity.TestingSite{URL: "http://test1.com", Regex: `^<html>`, Pattern: regexp.MustCompile(`^[0-9.]+`)},
+			wantError:   nil,
+		},
+		{
+			name:        "BodyMismatch",
+			testingSite: entity.TestingSite{URL: "http://test1.com", Body: "13.37.0.1"},
+			wantError:   fmt.Errorf("%w: body differs from the expected body", ErrResponseRejected),
+		},
+		{
+			name:        "HeaderPresent",
+			testingSite: entity.TestingSite{URL: "http://test1.com", Header: "server"},
+			wantError:   nil,
+		},
+		{
+			name:        "HeaderValueMismatch",
+			testingSite: entity.TestingSite{URL: "http://test1.com", Header: "Server: nginx"},
+			wantError:   fmt.Errorf("%w: header Server: nginx missing", ErrResponseRejected),
+		},
+		{
+			name:        "SHA256Match",
+			testingSite: entity.TestingSite{URL: "http://test1.com", SHA256: "D091446BCEA4C8612A1EF039500AA4CA32FE81EEF2122DF7C9AFADF0D2A90F1D"},
+			wantError:   nil,
+		},
+		{
+			name:        "SHA256Mismatch",
+			testingSite: entity.TestingSite{URL: "http://test1.com", SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+			wantError:   fmt.Errorf("%w: body sha256 differs from e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", ErrResponseRejected),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ProxyService{}
+			err := s.ValidateResponse(&tt.testingSite, header, body)
+			if (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) ||
+				(err == nil && tt.wantError != nil) ||
+				(err != nil && tt.wantError == nil) {
+				t.Errorf(expectedErrorButGotMessage, "ValidateResponse()", tt.wantError, err)
+			}
+
+			if err != nil && !errors.Is(err, ErrResponseRejected) {
+				t.Errorf(expectedErrorButGotMessage, "ValidateResponse()", ErrResponseRejected, err)
+			}
+		})
+	}
+}
+
 func TestGetTestingSite(t *testing.T) {
 	type fields struct {
-		httpTestingSites  []string
-		httpsTestingSites []string
+		httpTestingSites  []entity.TestingSite
+		httpsTestingSites []entity.TestingSite
 	}
 
 	tests := []struct {
 		name   string
 		fields fields
-		want   []string
+		want   []entity.TestingSite
 	}{
 		{
 			name: "HTTP",
@@ -286,7 +1216,7 @@ func TestGetTestingSite(t *testing.T) {
 			}
 
 			site := s.GetTestingSite(tt.name)
-			if len(site) == 0 {
+			if len(site.URL) == 0 {
 				t.Errorf(expectedNonEmptyMessage, "site", tt.name+" sites")
 			}
 
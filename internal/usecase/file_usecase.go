@@ -1,59 +1,296 @@
 package usecase
 
 import (
+	"errors"
+	"iter"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
 	"github.com/fyvri/fresh-proxy-list/internal/infrastructure/repository"
 )
 
+// Formats that need the latency and categories of the advanced views, they are only written next to them
+var advancedFormats = []string{"jsonl", "pac", "clash.yaml", "proxychains.conf", "sing-box.json"}
+
+// Formats written one proxy at a time, they read the repository views directly instead of a redacted copy
+var streamFormats = []string{"jsonl"}
+
 type fileUsecase struct {
 	FileRepository       repository.FileRepositoryInterface
 	ProxyRepository      repository.ProxyRepositoryInterface
+	CredentialRepository repository.CredentialRepositoryInterface
 	FileOutputExtensions []string
+	Compressions         []string
+	StorageDir           string
+	Categories           []string
+	AnonymityLevels      []string
+	IncludeCredentials   bool
+	IncludeMITM          bool
 	WaitGroup            sync.WaitGroup
+	Mutex                sync.Mutex
+	Errors               []error
 }
 
 type FileUsecaseInterface interface {
-	SaveFiles()
+	SaveFiles() error
+	LoadFile(filePath string) ([]entity.AdvancedProxy, error)
 }
 
-func NewFileUsecase(fileRepository repository.FileRepositoryInterface, proxyRepository repository.ProxyRepositoryInterface, fileOutputExtensions []string) FileUsecaseInterface {
+func NewFileUsecase(
+	fileRepository repository.FileRepositoryInterface,
+	proxyRepository repository.ProxyRepositoryInterface,
+	credentialRepository repository.CredentialRepositoryInterface,
+	fileOutputExtensions []string,
+	compressions []string,
+	storageDir string,
+	categories []string,
+	anonymityLevels []string,
+	includeCredentials bool,
+	includeMITM bool,
+) FileUsecaseInterface {
 	return &fileUsecase{
 		FileRepository:       fileRepository,
 		ProxyRepository:      proxyRepository,
+		CredentialRepository: credentialRepository,
 		FileOutputExtensions: fileOutputExtensions,
+		Compressions:         compressions,
+		StorageDir:           storageDir,
+		Categories:           categories,
+		AnonymityLevels:      anonymityLevels,
+		IncludeCredentials:   includeCredentials,
+		IncludeMITM:          includeMITM,
 		WaitGroup:            sync.WaitGroup{},
+		Mutex:                sync.Mutex{},
 	}
 }
 
-func (uc *fileUsecase) SaveFiles() {
-	createFile := func(filename string, classic []string, advanced interface{}) {
-		uc.WaitGroup.Add((len(uc.FileOutputExtensions) * 2) + 1)
+func (uc *fileUsecase) SaveFiles() error {
+	uc.Errors = nil
+	files := []entity.ManifestFile{}
+	saveFile := func(filePath string, data interface{}, format string, category string, count int) {
+		for _, compression := range append([]string{""}, uc.Compressions...) {
+			file := entity.ManifestFile{Path: filePath, Count: count, Category: category, Format: format, Compression: compression}
+			fileFormat := format
+			if compression != "" {
+				file.Path, fileFormat = filePath+"."+compression, format+"."+compression
+			}
+			files = append(files, file)
 
-		filename = strings.ToLower(filename)
-		for _, ext := range uc.FileOutputExtensions {
-			go func(ext string) {
+			uc.WaitGroup.Add(1)
+			go func() {
 				defer uc.WaitGroup.Done()
-				uc.FileRepository.SaveFile(filepath.Join("storage", "classic", filename+"."+ext), classic, ext)
-			}(ext)
-			go func(ext string) {
-				defer uc.WaitGroup.Done()
-				uc.FileRepository.SaveFile(filepath.Join("storage", "advanced", filename+"."+ext), advanced, ext)
-			}(ext)
+				if err := uc.FileRepository.SaveFile(filepath.Join(uc.StorageDir, file.Path), data, fileFormat); err != nil {
+					uc.Mutex.Lock()
+					uc.Errors = append(uc.Errors, err)
+					uc.Mutex.Unlock()
+				}
+			}()
+		}
+	}
+	copiesAdvanced := slices.ContainsFunc(uc.FileOutputExtensions, func(ext string) bool {
+		return !slices.Contains(streamFormats, ext)
+	})
+	createFile := func(filename string, classic interface{}, advanced interface{}) {
+		count := countProxies(classic)
+		stream := streamProxies(advanced, !uc.IncludeCredentials)
+		if !uc.IncludeCredentials {
+			classic = redactCredentials(classic)
+			if copiesAdvanced {
+				advanced = redactCredentials(advanced)
+			}
 		}
 
-		go func() {
-			defer uc.WaitGroup.Done()
-			uc.FileRepository.SaveFile(filepath.Join("storage", "classic", filename+".txt"), classic, "txt")
-		}()
+		filename = strings.ToLower(filename)
+		for _, ext := range uc.FileOutputExtensions {
+			if !slices.Contains(advancedFormats, ext) {
+				saveFile("classic/"+filename+"."+ext, classic, ext, filename, count)
+			}
+			data := advanced
+			if slices.Contains(streamFormats, ext) {
+				data = stream
+			}
+			saveFile("advanced/"+filename+"."+ext, data, ext, filename, count)
+		}
+		saveFile("classic/"+filename+".txt", classic, "txt", filename, count)
 	}
 
-	createFile("all", uc.ProxyRepository.GetAllClassicView(), uc.ProxyRepository.GetAllAdvancedView())
-	createFile("http", uc.ProxyRepository.GetHTTPClassicView(), uc.ProxyRepository.GetHTTPAdvancedView())
-	createFile("https", uc.ProxyRepository.GetHTTPSClassicView(), uc.ProxyRepository.GetHTTPSAdvancedView())
-	createFile("socks4", uc.ProxyRepository.GetSOCKS4ClassicView(), uc.ProxyRepository.GetSOCKS4AdvancedView())
-	createFile("socks5", uc.ProxyRepository.GetSOCKS5ClassicView(), uc.ProxyRepository.GetSOCKS5AdvancedView())
+	// Proxies that intercept TLS are kept out of every view that uses them as HTTPS proxies
+	createMergedFile := func(filename string, classic []string, advanced []entity.AdvancedProxy) {
+		if !uc.IncludeMITM {
+			classic, advanced = excludeMITMCategory(classic, advanced)
+		}
+		createFile(filename, classic, advanced)
+	}
+	createMergedFile("all", uc.ProxyRepository.GetAllClassicView(), uc.ProxyRepository.GetAllAdvancedView())
+	for _, category := range uc.Categories {
+		classic, advanced := uc.ProxyRepository.GetClassicView(category), uc.ProxyRepository.GetAdvancedView(category)
+		if category == "HTTPS" && !uc.IncludeMITM {
+			classic, advanced = excludeMITM(classic, advanced)
+		}
+		createFile(category, classic, advanced)
+	}
+	for _, anonymity := range uc.AnonymityLevels {
+		createMergedFile(anonymity, uc.ProxyRepository.GetAnonymityClassicView(anonymity), uc.ProxyRepository.GetAnonymityAdvancedView(anonymity))
+	}
+	createMergedFile("rotating", uc.ProxyRepository.GetRotatingClassicView(), uc.ProxyRepository.GetRotatingAdvancedView())
 	uc.WaitGroup.Wait()
+	if len(uc.Errors) > 0 {
+		return errors.Join(uc.Errors...)
+	}
+
+	// The manifest is written last so that it only ever describes a complete set of outputs
+	manifest := entity.Manifest{GeneratedAt: time.Now().Format(time.RFC3339), Files: files}
+	for i, file := range manifest.Files {
+		size, checksum, err := uc.FileRepository.Checksum(filepath.Join(uc.StorageDir, file.Path))
+		if err != nil {
+			return err
+		}
+		manifest.Files[i].Size, manifest.Files[i].SHA256 = size, checksum
+	}
+	if err := uc.FileRepository.SaveFile(filepath.Join(uc.StorageDir, "manifest.json"), manifest, "json"); err != nil {
+		return err
+	}
+
+	// Redacted outputs lose the credentials, they are kept aside so that the outputs can be read back as inputs
+	if !uc.IncludeCredentials && uc.CredentialRepository != nil {
+		return uc.CredentialRepository.Save(uc.ProxyRepository.GetAllAdvancedView())
+	}
+	return nil
+}
+
+func (uc *fileUsecase) LoadFile(filePath string) ([]entity.AdvancedProxy, error) {
+	var proxies []entity.AdvancedProxy
+	format := strings.TrimPrefix(filepath.Ext(filePath), ".")
+	if format == "yaml" {
+		view := struct {
+			Proxies []entity.AdvancedProxy `yaml:"proxies"`
+		}{}
+		if err := uc.FileRepository.LoadFile(filePath, &view, format); err != nil {
+			return nil, err
+		}
+		proxies = view.Proxies
+	} else if err := uc.FileRepository.LoadFile(filePath, &proxies, format); err != nil {
+		return nil, err
+	}
+
+	if uc.CredentialRepository != nil {
+		for i := range proxies {
+			uc.CredentialRepository.Restore(&proxies[i])
+		}
+	}
+	return proxies, nil
+}
+
+func excludeMITM(classic []string, advanced []entity.Proxy) ([]string, []entity.Proxy) {
+	var (
+		filteredClassic  = []string{}
+		filteredAdvanced = []entity.Proxy{}
+	)
+	for i, proxy := range advanced {
+		if !proxy.IsMITM {
+			filteredClassic = append(filteredClassic, classic[i])
+			filteredAdvanced = append(filteredAdvanced, proxy)
+		}
+	}
+	return filteredClassic, filteredAdvanced
+}
+
+// excludeMITMCategory drops the HTTPS category of the proxies that intercept TLS, and the proxies left without any
+func excludeMITMCategory(classic []string, advanced []entity.AdvancedProxy) ([]string, []entity.AdvancedProxy) {
+	var (
+		dropped          = map[string]struct{}{}
+		filteredClassic  = []string{}
+		filteredAdvanced = []entity.AdvancedProxy{}
+	)
+	for _, proxy := range advanced {
+		if proxy.IsMITM && slices.Contains(proxy.Categories, "HTTPS") {
+			proxy.Categories = slices.DeleteFunc(slices.Clone(proxy.Categories), func(category string) bool {
+				return category == "HTTPS"
+			})
+			if len(proxy.Categories) == 0 {
+				dropped[proxy.WithCredentials()] = struct{}{}
+				continue
+			}
+		}
+		filteredAdvanced = append(filteredAdvanced, proxy)
+	}
+	for _, proxy := range classic {
+		if _, found := dropped[proxy]; !found {
+			filteredClassic = append(filteredClassic, proxy)
+		}
+	}
+	return filteredClassic, filteredAdvanced
+}
+
+func countProxies(data interface{}) int {
+	switch proxyData := data.(type) {
+	case []string:
+		return len(proxyData)
+	case []entity.Proxy:
+		return len(proxyData)
+	case []entity.AdvancedProxy:
+		return len(proxyData)
+	default:
+		return 0
+	}
+}
+
+func streamProxies(data interface{}, redact bool) interface{} {
+	switch proxyData := data.(type) {
+	case []entity.Proxy:
+		return iter.Seq[entity.Proxy](func(yield func(entity.Proxy) bool) {
+			for _, proxy := range proxyData {
+				if redact {
+					proxy.Username, proxy.Password = "", ""
+				}
+				if !yield(proxy) {
+					return
+				}
+			}
+		})
+	case []entity.AdvancedProxy:
+		return iter.Seq[entity.AdvancedProxy](func(yield func(entity.AdvancedProxy) bool) {
+			for _, proxy := range proxyData {
+				if redact {
+					proxy.Username, proxy.Password = "", ""
+				}
+				if !yield(proxy) {
+					return
+				}
+			}
+		})
+	default:
+		return data
+	}
+}
+
+func redactCredentials(data interface{}) interface{} {
+	switch proxyData := data.(type) {
+	case []string:
+		redacted := make([]string, len(proxyData))
+		for i, proxy := range proxyData {
+			redacted[i] = proxy[strings.LastIndex(proxy, "@")+1:]
+		}
+		return redacted
+	case []entity.Proxy:
+		redacted := make([]entity.Proxy, len(proxyData))
+		for i, proxy := range proxyData {
+			proxy.Username, proxy.Password = "", ""
+			redacted[i] = proxy
+		}
+		return redacted
+	case []entity.AdvancedProxy:
+		redacted := make([]entity.AdvancedProxy, len(proxyData))
+		for i, proxy := range proxyData {
+			proxy.Username, proxy.Password = "", ""
+			redacted[i] = proxy
+		}
+		return redacted
+	default:
+		return data
+	}
 }
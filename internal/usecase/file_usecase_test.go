@@ -1,7 +1,11 @@
 package usecase
 
 import (
+	"errors"
+	"iter"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
@@ -34,46 +38,23 @@ func TestSaveFiles(t *testing.T) {
 		}
 	}
 
-	mockProxyRepository.GetHTTPClassicViewFunc = func() []string {
-		return []string{testProxy1}
+	classicViews := map[string][]string{
+		testProxyEntity1.Category: {testProxy1},
+		testProxyEntity2.Category: {testProxy2},
+		testProxyEntity3.Category: {testProxy3},
+		testProxyEntity4.Category: {testProxy4},
 	}
-	mockProxyRepository.GetHTTPAdvancedViewFunc = func() []entity.Proxy {
-		return []entity.Proxy{
-			testProxyEntity1,
-		}
-	}
-
-	mockProxyRepository.GetHTTPSClassicViewFunc = func() []string {
-		return []string{
-			testProxy2,
-		}
-	}
-	mockProxyRepository.GetHTTPSAdvancedViewFunc = func() []entity.Proxy {
-		return []entity.Proxy{
-			testProxyEntity2,
-		}
+	advancedViews := map[string][]entity.Proxy{
+		testProxyEntity1.Category: {testProxyEntity1},
+		testProxyEntity2.Category: {testProxyEntity2},
+		testProxyEntity3.Category: {testProxyEntity3},
+		testProxyEntity4.Category: {testProxyEntity4},
 	}
-
-	mockProxyRepository.GetSOCKS4ClassicViewFunc = func() []string {
-		return []string{
-			testProxy3,
-		}
-	}
-	mockProxyRepository.GetSOCKS4AdvancedViewFunc = func() []entity.Proxy {
-		return []entity.Proxy{
-			testProxyEntity3,
-		}
-	}
-
-	mockProxyRepository.GetSOCKS5ClassicViewFunc = func() []string {
-		return []string{
-			testProxy4,
-		}
+	mockProxyRepository.GetClassicViewFunc = func(category string) []string {
+		return classicViews[category]
 	}
-	mockProxyRepository.GetSOCKS5AdvancedViewFunc = func() []entity.Proxy {
-		return []entity.Proxy{
-			testProxyEntity4,
-		}
+	mockProxyRepository.GetAdvancedViewFunc = func(category string) []entity.Proxy {
+		return advancedViews[category]
 	}
 
 	got := 0
@@ -84,7 +65,8 @@ func TestSaveFiles(t *testing.T) {
 		got++
 		t.Logf("SaveFile called with filename: %s, extension: %s", filename, extension)
 		if !strings.HasPrefix(filename, filepath.Join(testStorageDir, testClassicDir)) &&
-			!strings.HasPrefix(filename, filepath.Join(testStorageDir, testAdvancedDir)) {
+			!strings.HasPrefix(filename, filepath.Join(testStorageDir, testAdvancedDir)) &&
+			filename != filepath.Join(testStorageDir, "manifest.json") {
 			t.Errorf(unexpectedMessage, "filename", filename)
 		}
 		if extension != testCSVExtension && extension != testJSONExtension && extension != testXMLExtension && extension != testYAMLExtension && extension != testTXTExtension {
@@ -92,12 +74,473 @@ func TestSaveFiles(t *testing.T) {
 		}
 		return nil
 	}
-	uc := NewFileUsecase(mockFileRepository, mockProxyRepository, testFileOutputExtensions)
+	uc := NewFileUsecase(mockFileRepository, mockProxyRepository, nil, testFileOutputExtensions, nil, testStorageDir, testProxyCategories, nil, false, false)
+	uc.SaveFiles()
+
+	// (6 views (all, categories, rotating) * number of extensions * 2 file types (classic, advanced)) + (6 views * 1 extension txt * 1 file type classic) + 1 manifest
+	want := (6 * len(testFileOutputExtensions) * 2) + (6 * 1 * 1) + 1
+	if got != want {
+		t.Errorf(expectedButGotMessage, "calls", want, got)
+	}
+}
+
+func TestSaveFilesWithCategories(t *testing.T) {
+	got := 0
+	mockFileRepository := &mockFileRepository{
+		SaveFileFunc: func(filename string, data interface{}, extension string) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			got++
+			base := filepath.Base(filename)
+			if !strings.HasPrefix(base, "all.") && !strings.HasPrefix(base, "socks5.") && !strings.HasPrefix(base, "rotating.") && base != "manifest.json" {
+				t.Errorf(unexpectedMessage, "filename", filename)
+			}
+			return nil
+		},
+	}
+	uc := NewFileUsecase(mockFileRepository, &mockProxyRepository{}, nil, testFileOutputExtensions, nil, testStorageDir, []string{testSOCKS5Category}, nil, false, false)
+	uc.SaveFiles()
+
+	want := (3 * len(testFileOutputExtensions) * 2) + (3 * 1 * 1) + 1
+	if got != want {
+		t.Errorf(expectedButGotMessage, "calls", want, got)
+	}
+}
+
+func TestSaveFilesWithAnonymityLevels(t *testing.T) {
+	got := 0
+	mockFileRepository := &mockFileRepository{
+		SaveFileFunc: func(filename string, data interface{}, extension string) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			got++
+			base := filepath.Base(filename)
+			if !strings.HasPrefix(base, "all.") && !strings.HasPrefix(base, "elite.") && !strings.HasPrefix(base, "rotating.") && base != "manifest.json" {
+				t.Errorf(unexpectedMessage, "filename", filename)
+			}
+			return nil
+		},
+	}
+	uc := NewFileUsecase(mockFileRepository, &mockProxyRepository{}, nil, testFileOutputExtensions, nil, testStorageDir, []string{}, []string{"elite"}, false, false)
 	uc.SaveFiles()
 
-	// (5 categories * number of extensions * 2 file types (classic, advanced)) + (5 all * 1 extension txt * 1 file type classic)
-	want := (5 * len(testFileOutputExtensions) * 2) + (5 * 1 * 1)
+	want := (3 * len(testFileOutputExtensions) * 2) + (3 * 1 * 1) + 1
 	if got != want {
 		t.Errorf(expectedButGotMessage, "calls", want, got)
 	}
 }
+
+func TestSaveFilesWritesAdvancedFormatsOnce(t *testing.T) {
+	var got []string
+	mockFileRepository := &mockFileRepository{
+		SaveFileFunc: func(filename string, data interface{}, extension string) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if extension == "pac" {
+				got = append(got, filename)
+			}
+			return nil
+		},
+	}
+	uc := NewFileUsecase(mockFileRepository, &mockProxyRepository{}, nil, []string{"pac"}, nil, testStorageDir, []string{}, nil, false, false)
+	if err := uc.SaveFiles(); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "SaveFiles()", nil, err)
+	}
+
+	for _, filename := range got {
+		if !strings.HasPrefix(filename, filepath.Join(testStorageDir, testAdvancedDir)) {
+			t.Errorf(unexpectedMessage, "filename", filename)
+		}
+	}
+	// all and rotating views
+	if len(got) != 2 {
+		t.Errorf(expectedButGotMessage, "calls", 2, len(got))
+	}
+}
+
+func TestSaveFilesStreamsJSONL(t *testing.T) {
+	var got []entity.Proxy
+	mockFileRepository := &mockFileRepository{
+		SaveFileFunc: func(filename string, data interface{}, extension string) error {
+			if filepath.Base(filename) != "http.jsonl" {
+				return nil
+			}
+			proxies, ok := data.(iter.Seq[entity.Proxy])
+			if !ok {
+				t.Errorf(expectedTypeAssertionErrorMessage, "iter.Seq[entity.Proxy]")
+				return nil
+			}
+			got = slices.Collect(proxies)
+			return nil
+		},
+	}
+	mockProxyRepository := &mockProxyRepository{
+		GetAdvancedViewFunc: func(category string) []entity.Proxy {
+			return []entity.Proxy{{Category: category, Proxy: testProxy1, Username: testUsername, Password: testPassword}}
+		},
+	}
+	uc := NewFileUsecase(mockFileRepository, mockProxyRepository, nil, []string{"jsonl"}, nil, testStorageDir, []string{testHTTPCategory}, nil, false, false)
+	if err := uc.SaveFiles(); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "SaveFiles()", nil, err)
+	}
+
+	want := []entity.Proxy{{Category: testHTTPCategory, Proxy: testProxy1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "SaveFiles()", want, got)
+	}
+}
+
+func TestSaveFilesReturnsErrors(t *testing.T) {
+	mockFileRepository := &mockFileRepository{
+		SaveFileFunc: func(filename string, data interface{}, extension string) error {
+			if filepath.Base(filename) == "rotating."+testCSVExtension {
+				return errors.New("disk full")
+			}
+			return nil
+		},
+	}
+	uc := NewFileUsecase(mockFileRepository, &mockProxyRepository{}, nil, testFileOutputExtensions, nil, testStorageDir, []string{}, nil, false, false)
+
+	// Errors of a previous call must not leak into the next one
+	for i := 0; i < 2; i++ {
+		if err := uc.SaveFiles(); err == nil || err.Error() != "disk full\ndisk full" {
+			t.Errorf(expectedErrorButGotMessage, "SaveFiles()", "disk full\ndisk full", err)
+		}
+	}
+}
+
+func TestSaveFilesWritesManifest(t *testing.T) {
+	var (
+		formats  = map[string]string{}
+		manifest entity.Manifest
+	)
+	mockFileRepository := &mockFileRepository{
+		SaveFileFunc: func(filename string, data interface{}, extension string) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			formats[filename] = extension
+			if filename == filepath.Join(testStorageDir, "manifest.json") {
+				manifest = data.(entity.Manifest)
+			}
+			return nil
+		},
+		ChecksumFunc: func(filePath string) (int64, string, error) {
+			return 42, "checksum of " + filePath, nil
+		},
+	}
+	mockProxyRepository := &mockProxyRepository{
+		GetAllClassicViewFunc: func() []string {
+			return []string{testProxy1, testProxy2}
+		},
+	}
+	uc := NewFileUsecase(mockFileRepository, mockProxyRepository, nil, []string{testJSONExtension}, []string{"gz"}, testStorageDir, []string{}, nil, false, false)
+	if err := uc.SaveFiles(); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "SaveFiles()", nil, err)
+	}
+
+	// (2 views (all, rotating) * 2 files (classic json, advanced json) + 2 views * 1 classic txt) * 2 variants (plain, gz)
+	if len(manifest.Files) != 12 {
+		t.Fatalf(expectedButGotMessage, "files", 12, len(manifest.Files))
+	}
+	if manifest.GeneratedAt == "" {
+		t.Errorf(unexpectedMessage, "generated_at", manifest.GeneratedAt)
+	}
+	want := []entity.ManifestFile{
+		{Path: "classic/all.json", Size: 42, SHA256: "checksum of " + filepath.Join(testStorageDir, "classic", "all.json"), Count: 2, Category: "all", Format: testJSONExtension},
+		{Path: "classic/all.json.gz", Size: 42, SHA256: "checksum of " + filepath.Join(testStorageDir, "classic", "all.json.gz"), Count: 2, Category: "all", Format: testJSONExtension, Compression: "gz"},
+	}
+	if !reflect.DeepEqual(manifest.Files[:2], want) {
+		t.Errorf(expectedButGotMessage, "files", want, manifest.Files[:2])
+	}
+	if got := formats[filepath.Join(testStorageDir, "classic", "all.json.gz")]; got != testJSONExtension+".gz" {
+		t.Errorf(expectedButGotMessage, "format", testJSONExtension+".gz", got)
+	}
+}
+
+func TestSaveFilesSkipsManifestOnError(t *testing.T) {
+	mockFileRepository := &mockFileRepository{
+		SaveFileFunc: func(filename string, data interface{}, extension string) error {
+			if filepath.Base(filename) == "manifest.json" {
+				t.Errorf(unexpectedMessage, "filename", filename)
+			}
+			if filepath.Base(filename) == "all."+testJSONExtension {
+				return errors.New("disk full")
+			}
+			return nil
+		},
+	}
+	uc := NewFileUsecase(mockFileRepository, &mockProxyRepository{}, nil, []string{testJSONExtension}, nil, testStorageDir, []string{}, nil, false, false)
+	if err := uc.SaveFiles(); err == nil {
+		t.Errorf(expectedErrorButGotMessage, "SaveFiles()", "disk full", err)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		filePath  string
+		loadFile  func(filePath string, data interface{}, format string) error
+		want      []entity.AdvancedProxy
+		wantError error
+	}{
+		{
+			name:     "JSON",
+			filePath: filepath.Join(testStorageDir, testAdvancedDir, "all."+testJSONExtension),
+			loadFile: func(filePath string, data interface{}, format string) error {
+				if format != testJSONExtension {
+					t.Errorf(expectedButGotMessage, "format", testJSONExtension, format)
+				}
+				*data.(*[]entity.AdvancedProxy) = []entity.AdvancedProxy{testAdvancedProxyEntity1}
+				return nil
+			},
+			want:      []entity.AdvancedProxy{testAdvancedProxyEntity1},
+			wantError: nil,
+		},
+		{
+			name:     "YAML",
+			filePath: filepath.Join(testStorageDir, testAdvancedDir, "all."+testYAMLExtension),
+			loadFile: func(filePath string, data interface{}, format string) error {
+				if format != testYAMLExtension {
+					t.Errorf(expectedButGotMessage, "format", testYAMLExtension, format)
+				}
+				reflect.ValueOf(data).Elem().Field(0).Set(reflect.ValueOf([]entity.AdvancedProxy{testAdvancedProxyEntity2}))
+				return nil
+			},
+			want:      []entity.AdvancedProxy{testAdvancedProxyEntity2},
+			wantError: nil,
+		},
+		{
+			name:     "Error",
+			filePath: filepath.Join(testStorageDir, testAdvancedDir, "all."+testJSONExtension),
+			loadFile: func(filePath string, data interface{}, format string) error {
+				return errors.New("error opening file")
+			},
+			want:      nil,
+			wantError: errors.New("error opening file"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewFileUsecase(&mockFileRepository{LoadFileFunc: tt.loadFile}, &mockProxyRepository{}, nil, testFileOutputExtensions, nil, testStorageDir, testProxyCategories, nil, false, false)
+			got, err := uc.LoadFile(tt.filePath)
+
+			if (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) ||
+				(err == nil && tt.wantError != nil) ||
+				(err != nil && tt.wantError == nil) {
+				t.Errorf(expectedErrorButGotMessage, "FileUsecase.LoadFile()", tt.wantError, err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "FileUsecase.LoadFile()", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestFileUsecaseKeepsRedactedCredentials(t *testing.T) {
+	var saved []entity.AdvancedProxy
+	mockCredentialRepository := &mockCredentialRepository{
+		SaveFunc: func(proxies []entity.AdvancedProxy) error {
+			saved = proxies
+			return nil
+		},
+		Credentials: map[string]entity.Credential{
+			testProxy1: {Username: testUsername, Password: testPassword},
+		},
+	}
+	mockFileRepository := &mockFileRepository{
+		SaveFileFunc: func(filename string, data interface{}, extension string) error {
+			return nil
+		},
+		LoadFileFunc: func(filePath string, data interface{}, format string) error {
+			reflect.ValueOf(data).Elem().Set(reflect.ValueOf([]entity.AdvancedProxy{{Proxy: testProxy1}, {Proxy: testProxy2}}))
+			return nil
+		},
+	}
+	mockProxyRepository := &mockProxyRepository{
+		GetAllAdvancedViewFunc: func() []entity.AdvancedProxy {
+			return []entity.AdvancedProxy{{Proxy: testProxy1, Username: testUsername, Password: testPassword}}
+		},
+	}
+
+	uc := NewFileUsecase(mockFileRepository, mockProxyRepository, mockCredentialRepository, []string{testJSONExtension}, nil, testStorageDir, []string{}, nil, false, false)
+	if err := uc.SaveFiles(); err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "FileUsecase.SaveFiles()", nil, err)
+	}
+	if len(saved) != 1 || saved[0].Username != testUsername {
+		t.Errorf(expectedButGotMessage, "saved credentials", testUsername, saved)
+	}
+
+	got, err := uc.LoadFile(filepath.Join(testStorageDir, testAdvancedDir, "all."+testJSONExtension))
+	if err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "FileUsecase.LoadFile()", nil, err)
+	}
+	want := []entity.AdvancedProxy{{Proxy: testProxy1, Username: testUsername, Password: testPassword}, {Proxy: testProxy2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "FileUsecase.LoadFile()", want, got)
+	}
+
+	saved = nil
+	uc = NewFileUsecase(mockFileRepository, mockProxyRepository, mockCredentialRepository, []string{testJSONExtension}, nil, testStorageDir, []string{}, nil, true, false)
+	if err := uc.SaveFiles(); err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "FileUsecase.SaveFiles()", nil, err)
+	}
+	if saved != nil {
+		t.Errorf(unexpectedMessage, "saved credentials", saved)
+	}
+}
+
+func TestRedactCredentials(t *testing.T) {
+	credentials := testUsername + ":" + testPassword + "@"
+	tests := []struct {
+		name string
+		data interface{}
+		want interface{}
+	}{
+		{
+			name: "Classic",
+			data: []string{credentials + testProxy1, testProxy2},
+			want: []string{testProxy1, testProxy2},
+		},
+		{
+			name: "Advanced",
+			data: []entity.Proxy{{Proxy: testProxy1, Username: testUsername, Password: testPassword}},
+			want: []entity.Proxy{{Proxy: testProxy1}},
+		},
+		{
+			name: "AllAdvanced",
+			data: []entity.AdvancedProxy{{Proxy: testProxy1, Username: testUsername, Password: testPassword}},
+			want: []entity.AdvancedProxy{{Proxy: testProxy1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactCredentials(tt.data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "redactCredentials()", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSaveFilesWithCredentials(t *testing.T) {
+	credentials := testUsername + ":" + testPassword + "@"
+	mockFileRepository := &mockFileRepository{
+		SaveFileFunc: func(filename string, data interface{}, extension string) error {
+			if classic, ok := data.([]string); ok && len(classic) > 0 && !strings.HasPrefix(classic[0], credentials) {
+				t.Errorf(expectedButGotMessage, "classic proxy", credentials+testProxy1, classic[0])
+			}
+			return nil
+		},
+	}
+	mockProxyRepository := &mockProxyRepository{
+		GetAllClassicViewFunc: func() []string {
+			return []string{credentials + testProxy1}
+		},
+	}
+	uc := NewFileUsecase(mockFileRepository, mockProxyRepository, nil, testFileOutputExtensions, nil, testStorageDir, []string{}, nil, true, false)
+	uc.SaveFiles()
+}
+
+func TestSaveFilesExcludesMITM(t *testing.T) {
+	tests := []struct {
+		name        string
+		includeMITM bool
+		want        []string
+	}{
+		{
+			name:        "Excluded",
+			includeMITM: false,
+			want:        []string{testProxy1},
+		},
+		{
+			name:        "Included",
+			includeMITM: true,
+			want:        []string{testProxy1, testProxy2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFileRepository := &mockFileRepository{
+				SaveFileFunc: func(filename string, data interface{}, extension string) error {
+					if classic, ok := data.([]string); ok && strings.HasPrefix(filepath.Base(filename), "https.") && !reflect.DeepEqual(classic, tt.want) {
+						t.Errorf(expectedButGotMessage, "classic proxies", tt.want, classic)
+					}
+					return nil
+				},
+			}
+			mockProxyRepository := &mockProxyRepository{
+				GetClassicViewFunc: func(category string) []string {
+					return []string{testProxy1, testProxy2}
+				},
+				GetAdvancedViewFunc: func(category string) []entity.Proxy {
+					return []entity.Proxy{{Proxy: testProxy1}, {Proxy: testProxy2, IsMITM: true}}
+				},
+			}
+			uc := NewFileUsecase(mockFileRepository, mockProxyRepository, nil, testFileOutputExtensions, nil, testStorageDir, []string{"HTTPS"}, nil, false, tt.includeMITM)
+			uc.SaveFiles()
+		})
+	}
+}
+
+func TestSaveFilesExcludesMITMFromMergedViews(t *testing.T) {
+	var (
+		mutex sync.Mutex
+		saved = map[string]interface{}{}
+	)
+	mockFileRepository := &mockFileRepository{
+		SaveFileFunc: func(filename string, data interface{}, extension string) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			saved[filepath.ToSlash(filename)] = data
+			return nil
+		},
+	}
+	advanced := []entity.AdvancedProxy{
+		{Proxy: testProxy1, IsMITM: true, IsRotating: true, Categories: []string{testHTTPCategory, "HTTPS"}},
+		{Proxy: testProxy2, IsMITM: true, IsRotating: true, Categories: []string{"HTTPS"}},
+		{Proxy: testProxy3, IsRotating: true, Categories: []string{"HTTPS"}},
+	}
+	mockProxyRepository := &mockProxyRepository{
+		GetAllClassicViewFunc: func() []string {
+			return []string{testProxy1, testProxy2, testProxy3}
+		},
+		GetAllAdvancedViewFunc: func() []entity.AdvancedProxy {
+			return advanced
+		},
+		GetRotatingClassicViewFunc: func() []string {
+			return []string{testProxy1, testProxy2, testProxy3}
+		},
+		GetRotatingAdvancedViewFunc: func() []entity.AdvancedProxy {
+			return advanced
+		},
+	}
+	uc := NewFileUsecase(mockFileRepository, mockProxyRepository, nil, []string{testJSONExtension}, nil, testStorageDir, []string{}, nil, true, false)
+	if err := uc.SaveFiles(); err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "FileUsecase.SaveFiles()", nil, err)
+	}
+
+	wantClassic := []string{testProxy1, testProxy3}
+	wantAdvanced := []entity.AdvancedProxy{
+		{Proxy: testProxy1, IsMITM: true, IsRotating: true, Categories: []string{testHTTPCategory}},
+		{Proxy: testProxy3, IsRotating: true, Categories: []string{"HTTPS"}},
+	}
+	for _, filename := range []string{"all", "rotating"} {
+		if got := saved[testStorageDir+"/"+testClassicDir+"/"+filename+".txt"]; !reflect.DeepEqual(got, wantClassic) {
+			t.Errorf(expectedButGotMessage, filename+" classic proxies", wantClassic, got)
+		}
+		if got := saved[testStorageDir+"/"+testAdvancedDir+"/"+filename+".json"]; !reflect.DeepEqual(got, wantAdvanced) {
+			t.Errorf(expectedButGotMessage, filename+" advanced proxies", wantAdvanced, got)
+		}
+	}
+
+	if !reflect.DeepEqual(advanced[0].Categories, []string{testHTTPCategory, "HTTPS"}) {
+		t.Errorf(unexpectedMessage, "repository view", advanced[0].Categories)
+	}
+}
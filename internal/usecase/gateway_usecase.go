@@ -0,0 +1,308 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+	"github.com/fyvri/fresh-proxy-list/internal/infrastructure/repository"
+	"github.com/fyvri/fresh-proxy-list/internal/service"
+)
+
+type GatewayUsecase struct {
+	ProxyRepository repository.ProxyRepositoryInterface
+	ProxyService    service.ProxyServiceInterface
+	Strategy        string
+	IncludeMITM     bool
+	Mutex           sync.Mutex
+	Upstreams       []entity.Upstream
+	Next            int
+	Sessions        map[string]entity.GatewaySession
+	SweptAt         time.Time
+}
+
+type GatewayUsecaseInterface interface {
+	Load(categories []string) int
+	Pick(session string) (*entity.Upstream, error)
+	Evict(proxy string)
+	Dial(ctx context.Context, network string, addr string, session string) (net.Conn, error)
+	RoundTrip(req *http.Request, session string) (*http.Response, error)
+	Recheck(ctx context.Context) int
+}
+
+var ErrNoUpstream = errors.New("no upstream available")
+
+const maxDialAttempts = 3
+
+// Sticky sessions idle for longer than this are forgotten, so that one entry per client does not pile up forever
+const stickySessionTTL = 30 * time.Minute
+
+func NewGatewayUsecase(proxyRepository repository.ProxyRepositoryInterface, proxyService service.ProxyServiceInterface, strategy string, includeMITM bool) GatewayUsecaseInterface {
+	return &GatewayUsecase{
+		ProxyRepository: proxyRepository,
+		ProxyService:    proxyService,
+		Strategy:        strategy,
+		IncludeMITM:     includeMITM,
+		Mutex:           sync.Mutex{},
+		Sessions:        map[string]entity.GatewaySession{},
+	}
+}
+
+func (uc *GatewayUsecase) Load(categories []string) int {
+	uc.Mutex.Lock()
+	defer uc.Mutex.Unlock()
+
+	uc.Upstreams = nil
+	for _, proxy := range uc.ProxyRepository.GetAllAdvancedView() {
+		// Each upstream is dialed through the most capable category it passed, never as HTTPS when it intercepts TLS
+		i := slices.IndexFunc(categories, func(category string) bool {
+			return slices.Contains(proxy.Categories, category) && (category != "HTTPS" || !proxy.IsMITM || uc.IncludeMITM)
+		})
+		if i < 0 {
+			continue
+		}
+		uc.Upstreams = append(uc.Upstreams, entity.Upstream{
+			Proxy:    proxy,
+			Category: categories[i],
+		})
+	}
+
+	return len(uc.Upstreams)
+}
+
+func (uc *GatewayUsecase) Pick(session string) (*entity.Upstream, error) {
+	return uc.pick(session, nil)
+}
+
+// pick selects an active upstream among those accepted, all of them when accept is nil
+func (uc *GatewayUsecase) pick(session string, accept func(upstream entity.Upstream) bool) (*entity.Upstream, error) {
+	uc.Mutex.Lock()
+	defer uc.Mutex.Unlock()
+
+	var active []int
+	for i, upstream := range uc.Upstreams {
+		if !upstream.Evicted && (accept == nil || accept(upstream)) {
+			active = append(active, i)
+		}
+	}
+	if len(active) == 0 {
+		return nil, ErrNoUpstream
+	}
+
+	roundRobin := func() int {
+		i, found := slices.BinarySearch(active, uc.Next)
+		if !found && i == len(active) {
+			i = 0
+		}
+		uc.Next = active[i] + 1
+		return active[i]
+	}
+
+	var picked int
+	switch uc.Strategy {
+	case "random":
+		picked = active[rand.Intn(len(active))]
+	case "least-latency":
+		picked = slices.MinFunc(active, func(a, b int) int {
+			return cmp.Compare(uc.Upstreams[a].Proxy.TimeTaken, uc.Upstreams[b].Proxy.TimeTaken)
+		})
+	case "sticky":
+		now := time.Now()
+		uc.expireSessions(now)
+		i := slices.IndexFunc(active, func(i int) bool {
+			return uc.Upstreams[i].Proxy.Proxy == uc.Sessions[session].Proxy
+		})
+		if i >= 0 {
+			picked = active[i]
+		} else {
+			picked = roundRobin()
+		}
+		uc.Sessions[session] = entity.GatewaySession{Proxy: uc.Upstreams[picked].Proxy.Proxy, LastUsed: now}
+	default:
+		picked = roundRobin()
+	}
+
+	upstream := uc.Upstreams[picked]
+	return &upstream, nil
+}
+
+// expireSessions drops the idle sticky sessions, at most once per TTL so that picks stay cheap
+func (uc *GatewayUsecase) expireSessions(now time.Time) {
+	if now.Sub(uc.SweptAt) < stickySessionTTL {
+		return
+	}
+	maps.DeleteFunc(uc.Sessions, func(session string, stickySession entity.GatewaySession) bool {
+		return now.Sub(stickySession.LastUsed) > stickySessionTTL
+	})
+	uc.SweptAt = now
+}
+
+func (uc *GatewayUsecase) Evict(proxy string) {
+	uc.Mutex.Lock()
+	defer uc.Mutex.Unlock()
+
+	for i := range uc.Upstreams {
+		if uc.Upstreams[i].Proxy.Proxy == proxy {
+			uc.Upstreams[i].Evicted = true
+		}
+	}
+}
+
+func (uc *GatewayUsecase) Dial(ctx context.Context, network string, addr string, session string) (net.Conn, error) {
+	_, port, _ := net.SplitHostPort(addr)
+	var lastErr error
+	for attempt := 0; attempt < maxDialAttempts; attempt++ {
+		upstream, err := uc.pick(session, tunnels(port))
+		if err != nil {
+			if lastErr != nil {
+				return nil, fmt.Errorf("error dialing %s: %v", addr, lastErr)
+			}
+			return nil, err
+		}
+
+		conn, err := uc.ProxyService.Dial(ctx, upstream.Category, upstream.Proxy.Proxy, upstream.Proxy.Username, upstream.Proxy.Password, addr)
+		if err == nil {
+			return conn, nil
+		}
+		uc.Evict(upstream.Proxy.Proxy)
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("error dialing %s: %v", addr, lastErr)
+}
+
+// RoundTrip forwards a plain HTTP request, as an absolute-URI request through HTTP upstreams since not all of them allow CONNECT
+func (uc *GatewayUsecase) RoundTrip(req *http.Request, session string) (*http.Response, error) {
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	accept := tunnels(port)
+	if req.URL.Scheme == "http" {
+		accept = nil
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxDialAttempts; attempt++ {
+		upstream, err := uc.pick(session, accept)
+		if err != nil {
+			if lastErr != nil {
+				return nil, fmt.Errorf("error forwarding to %s: %v", req.URL.Host, lastErr)
+			}
+			return nil, err
+		}
+
+		transport := &http.Transport{DisableKeepAlives: true}
+		if isHTTPCategory(upstream.Category) && req.URL.Scheme == "http" {
+			proxyURL := &url.URL{Scheme: strings.ToLower(upstream.Category), Host: upstream.Proxy.Proxy}
+			if upstream.Proxy.Username != "" {
+				proxyURL.User = url.UserPassword(upstream.Proxy.Username, upstream.Proxy.Password)
+			}
+			transport.Proxy = http.ProxyURL(proxyURL)
+			// HTTPS upstreams are not verified, as when they are dialed for a tunnel
+			transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		} else {
+			transport.DialContext = func(ctx context.Context, network string, addr string) (net.Conn, error) {
+				return uc.ProxyService.Dial(ctx, upstream.Category, upstream.Proxy.Proxy, upstream.Proxy.Username, upstream.Proxy.Password, addr)
+			}
+		}
+
+		resp, err := transport.RoundTrip(req)
+		if err == nil && resp.StatusCode != http.StatusProxyAuthRequired {
+			return resp, nil
+		} else if err == nil {
+			resp.Body.Close()
+			err = errors.New(resp.Status)
+		}
+		uc.Evict(upstream.Proxy.Proxy)
+		lastErr = err
+
+		// A request body that has been read cannot be sent again
+		if req.Body != nil && req.Body != http.NoBody {
+			break
+		}
+	}
+
+	return nil, fmt.Errorf("error forwarding to %s: %v", req.URL.Host, lastErr)
+}
+
+func (uc *GatewayUsecase) Recheck(ctx context.Context) int {
+	uc.Mutex.Lock()
+	var evicted []entity.Upstream
+	for _, upstream := range uc.Upstreams {
+		if upstream.Evicted {
+			evicted = append(evicted, upstream)
+		}
+	}
+	uc.Mutex.Unlock()
+
+	var (
+		wg       sync.WaitGroup
+		restored = make(chan *entity.Proxy, len(evicted))
+	)
+	for _, upstream := range evicted {
+		wg.Add(1)
+		go func(upstream entity.Upstream) {
+			defer wg.Done()
+
+			data, err := uc.ProxyService.Check(ctx, upstream.Category, upstream.Proxy.IP, upstream.Proxy.Port, upstream.Proxy.Username, upstream.Proxy.Password)
+			if err == nil {
+				restored <- data
+			}
+		}(upstream)
+	}
+	wg.Wait()
+	close(restored)
+
+	uc.Mutex.Lock()
+	defer uc.Mutex.Unlock()
+
+	total := 0
+	for data := range restored {
+		for i := range uc.Upstreams {
+			if uc.Upstreams[i].Proxy.Proxy == data.Proxy && uc.Upstreams[i].Evicted {
+				uc.Upstreams[i].Evicted = false
+				uc.Upstreams[i].Proxy.TimeTaken = data.TimeTaken
+				uc.Upstreams[i].Proxy.CheckedAt = data.CheckedAt
+				// Without connect targets the recheck probes no port, and the ports of the last run are kept
+				if data.ConnectPorts != nil {
+					uc.Upstreams[i].Proxy.ConnectPorts = data.ConnectPorts
+				}
+				total++
+			}
+		}
+	}
+
+	return total
+}
+
+func isHTTPCategory(category string) bool {
+	return category == "HTTP" || category == "HTTPS"
+}
+
+// tunnels accepts the upstreams that may CONNECT to port, HTTP upstreams probed with CONNECT only to the ports that passed
+func tunnels(port string) func(upstream entity.Upstream) bool {
+	return func(upstream entity.Upstream) bool {
+		if !isHTTPCategory(upstream.Category) || len(upstream.Proxy.ConnectPorts) == 0 {
+			return true
+		}
+		n, err := strconv.Atoi(port)
+		return err == nil && slices.Contains(upstream.Proxy.ConnectPorts, n)
+	}
+}
@@ -0,0 +1,355 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+func newTestGatewayUsecase(strategy string, proxyService *mockProxyService) *GatewayUsecase {
+	return NewGatewayUsecase(&mockProxyRepository{
+		GetAllAdvancedViewFunc: func() []entity.AdvancedProxy {
+			return []entity.AdvancedProxy{
+				{Proxy: testProxy1, IP: testIP1, Port: testPort1, TimeTaken: 3, Categories: []string{testHTTPCategory, testSOCKS5Category}},
+				{Proxy: testProxy2, IP: testIP2, Port: testPort2, TimeTaken: 1, Categories: []string{testHTTPSCategory}},
+				{Proxy: testProxy3, IP: testIP3, Port: testPort3, TimeTaken: 2, Categories: []string{testSOCKS4Category}},
+			}
+		},
+	}, proxyService, strategy, false).(*GatewayUsecase)
+}
+
+func TestNewGatewayUsecase(t *testing.T) {
+	gatewayUsecase := NewGatewayUsecase(&mockProxyRepository{}, &mockProxyService{}, "sticky", false)
+	if gatewayUsecase == nil {
+		t.Errorf(expectedReturnNonNil, "NewGatewayUsecase", "GatewayUsecaseInterface")
+	}
+
+	uc, ok := gatewayUsecase.(*GatewayUsecase)
+	if !ok {
+		t.Errorf(expectedTypeAssertionErrorMessage, "*GatewayUsecase")
+	}
+
+	if uc.Strategy != "sticky" {
+		t.Errorf(expectedButGotMessage, "Strategy", "sticky", uc.Strategy)
+	}
+}
+
+func TestGatewayLoad(t *testing.T) {
+	uc := newTestGatewayUsecase("round-robin", &mockProxyService{})
+
+	got := uc.Load([]string{testSOCKS5Category, testHTTPSCategory, testHTTPCategory})
+	if got != 2 {
+		t.Errorf(expectedButGotMessage, "Load()", 2, got)
+	}
+
+	want := []string{testSOCKS5Category, testHTTPSCategory}
+	for i, upstream := range uc.Upstreams {
+		if upstream.Category != want[i] {
+			t.Errorf(expectedButGotMessage, "Upstream.Category", want[i], upstream.Category)
+		}
+	}
+}
+
+func TestGatewayPick(t *testing.T) {
+	categories := []string{testHTTPCategory, testHTTPSCategory, testSOCKS4Category}
+	tests := []struct {
+		name     string
+		strategy string
+		sessions []string
+		evict    string
+		want     []string
+	}{
+		{
+			name:     "RoundRobin",
+			strategy: "round-robin",
+			sessions: []string{"a", "a", "a", "a"},
+			want:     []string{testProxy1, testProxy2, testProxy3, testProxy1},
+		},
+		{
+			name:     "RoundRobinSkipsEvicted",
+			strategy: "round-robin",
+			sessions: []string{"a", "a", "a"},
+			evict:    testProxy2,
+			want:     []string{testProxy1, testProxy3, testProxy1},
+		},
+		{
+			name:     "LeastLatency",
+			strategy: "least-latency",
+			sessions: []string{"a", "b"},
+			want:     []string{testProxy2, testProxy2},
+		},
+		{
+			name:     "LeastLatencySkipsEvicted",
+			strategy: "least-latency",
+			sessions: []string{"a"},
+			evict:    testProxy2,
+			want:     []string{testProxy3},
+		},
+		{
+			name:     "Sticky",
+			strategy: "sticky",
+			sessions: []string{"a", "b", "a", "b", "c"},
+			want:     []string{testProxy1, testProxy2, testProxy1, testProxy2, testProxy3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := newTestGatewayUsecase(tt.strategy, &mockProxyService{})
+			uc.Load(categories)
+			if tt.evict != "" {
+				uc.Evict(tt.evict)
+			}
+
+			var got []string
+			for _, session := range tt.sessions {
+				upstream, err := uc.Pick(session)
+				if err != nil {
+					t.Fatalf(unexpectedMessage, "error", err)
+				}
+				got = append(got, upstream.Proxy.Proxy)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "Pick()", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGatewayPickRandom(t *testing.T) {
+	uc := newTestGatewayUsecase("random", &mockProxyService{})
+	uc.Load([]string{testHTTPCategory, testHTTPSCategory, testSOCKS4Category})
+	uc.Evict(testProxy1)
+	uc.Evict(testProxy3)
+
+	for i := 0; i < 10; i++ {
+		upstream, err := uc.Pick("")
+		if err != nil {
+			t.Fatalf(unexpectedMessage, "error", err)
+		}
+		if upstream.Proxy.Proxy != testProxy2 {
+			t.Errorf(expectedButGotMessage, "Pick()", testProxy2, upstream.Proxy.Proxy)
+		}
+	}
+
+	uc.Evict(testProxy2)
+	if _, err := uc.Pick(""); !errors.Is(err, ErrNoUpstream) {
+		t.Errorf(expectedErrorButGotMessage, "Pick()", ErrNoUpstream, err)
+	}
+}
+
+func TestGatewayDial(t *testing.T) {
+	var dialed []string
+	uc := newTestGatewayUsecase("round-robin", &mockProxyService{
+		DialFunc: func(ctx context.Context, category string, proxy string, username string, password string, addr string) (net.Conn, error) {
+			dialed = append(dialed, category+" "+proxy)
+			if proxy != testProxy3 {
+				return nil, errors.New("connection refused")
+			}
+			client, server := net.Pipe()
+			server.Close()
+			return client, nil
+		},
+	})
+	uc.Load([]string{testHTTPCategory, testHTTPSCategory, testSOCKS4Category})
+
+	conn, err := uc.Dial(context.Background(), "tcp", "example.com:443", "")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	conn.Close()
+
+	want := []string{testHTTPCategory + " " + testProxy1, testHTTPSCategory + " " + testProxy2, testSOCKS4Category + " " + testProxy3}
+	if !reflect.DeepEqual(dialed, want) {
+		t.Errorf(expectedButGotMessage, "dialed upstreams", want, dialed)
+	}
+	for _, upstream := range uc.Upstreams {
+		if upstream.Evicted != (upstream.Proxy.Proxy != testProxy3) {
+			t.Errorf(expectedButGotMessage, upstream.Proxy.Proxy+" evicted", upstream.Proxy.Proxy != testProxy3, upstream.Evicted)
+		}
+	}
+
+	uc.Evict(testProxy3)
+	wantErr := ErrNoUpstream
+	if _, err := uc.Dial(context.Background(), "tcp", "example.com:443", ""); !errors.Is(err, wantErr) {
+		t.Errorf(expectedErrorButGotMessage, "Dial()", wantErr, err)
+	}
+}
+
+func TestGatewayDialConnectPorts(t *testing.T) {
+	var dialed []string
+	uc := newTestGatewayUsecase("round-robin", &mockProxyService{
+		DialFunc: func(ctx context.Context, category string, proxy string, username string, password string, addr string) (net.Conn, error) {
+			dialed = append(dialed, proxy)
+			client, server := net.Pipe()
+			server.Close()
+			return client, nil
+		},
+	})
+	uc.Load([]string{testHTTPCategory, testHTTPSCategory, testSOCKS4Category})
+	uc.Upstreams[0].Proxy.ConnectPorts = []int{443}
+	uc.Evict(testProxy2)
+	uc.Evict(testProxy3)
+
+	// The HTTP upstream only passed CONNECT to port 443
+	if _, err := uc.Dial(context.Background(), "tcp", "example.com:22", ""); !errors.Is(err, ErrNoUpstream) {
+		t.Errorf(expectedErrorButGotMessage, "Dial()", ErrNoUpstream, err)
+	}
+	conn, err := uc.Dial(context.Background(), "tcp", "example.com:443", "")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	conn.Close()
+
+	if want := []string{testProxy1}; !reflect.DeepEqual(dialed, want) {
+		t.Errorf(expectedButGotMessage, "dialed upstreams", want, dialed)
+	}
+}
+
+func TestGatewayRoundTrip(t *testing.T) {
+	var forwarded []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodConnect {
+			t.Errorf(unexpectedMessage, "method", r.Method)
+		}
+		if r.Header.Get("Proxy-Authorization") == "" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		forwarded = append(forwarded, r.URL.String())
+		io.WriteString(w, "forwarded")
+	}))
+	defer upstream.Close()
+	upstreamAddr := strings.TrimPrefix(upstream.URL, "http://")
+
+	uc := NewGatewayUsecase(&mockProxyRepository{
+		GetAllAdvancedViewFunc: func() []entity.AdvancedProxy {
+			return []entity.AdvancedProxy{
+				{Proxy: strings.Replace(upstreamAddr, "127.0.0.1", "localhost", 1), Categories: []string{testHTTPCategory}},
+				{Proxy: upstreamAddr, Username: testUsername, Password: testPassword, Categories: []string{testHTTPCategory}},
+			}
+		},
+	}, &mockProxyService{}, "round-robin", false).(*GatewayUsecase)
+	uc.Load([]string{testHTTPCategory})
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/path", nil)
+	req.RequestURI, req.Body = "", http.NoBody
+	resp, err := uc.RoundTrip(req, "")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "forwarded" {
+		t.Errorf(expectedButGotMessage, "body", "forwarded", string(body))
+	}
+	if want := []string{"http://example.com/path"}; !reflect.DeepEqual(forwarded, want) {
+		t.Errorf(expectedButGotMessage, "forwarded requests", want, forwarded)
+	}
+	if !uc.Upstreams[0].Evicted || uc.Upstreams[1].Evicted {
+		t.Errorf(expectedButGotMessage, "evicted upstreams", "[true false]", []bool{uc.Upstreams[0].Evicted, uc.Upstreams[1].Evicted})
+	}
+}
+
+func TestGatewayRecheck(t *testing.T) {
+	uc := newTestGatewayUsecase("round-robin", &mockProxyService{
+		CheckFunc: func(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error) {
+			if ip == testIP2 {
+				return nil, errors.New("proxy unreachable")
+			}
+			return &entity.Proxy{Category: category, Proxy: net.JoinHostPort(ip, port), IP: ip, Port: port, TimeTaken: 0.5, ConnectPorts: []int{443}}, nil
+		},
+	})
+	uc.Load([]string{testHTTPCategory, testHTTPSCategory, testSOCKS4Category})
+	uc.Evict(testProxy1)
+	uc.Evict(testProxy2)
+
+	if got := uc.Recheck(context.Background()); got != 1 {
+		t.Errorf(expectedButGotMessage, "Recheck()", 1, got)
+	}
+
+	want := map[string]bool{testProxy1: false, testProxy2: true, testProxy3: false}
+	for _, upstream := range uc.Upstreams {
+		if upstream.Evicted != want[upstream.Proxy.Proxy] {
+			t.Errorf(expectedButGotMessage, upstream.Proxy.Proxy+" evicted", want[upstream.Proxy.Proxy], upstream.Evicted)
+		}
+	}
+	if uc.Upstreams[0].Proxy.TimeTaken != 0.5 {
+		t.Errorf(expectedButGotMessage, "TimeTaken", 0.5, uc.Upstreams[0].Proxy.TimeTaken)
+	}
+	if want := []int{443}; !reflect.DeepEqual(uc.Upstreams[0].Proxy.ConnectPorts, want) {
+		t.Errorf(expectedButGotMessage, "ConnectPorts", want, uc.Upstreams[0].Proxy.ConnectPorts)
+	}
+}
+
+func TestGatewayPickExpiresSessions(t *testing.T) {
+	uc := newTestGatewayUsecase("sticky", &mockProxyService{})
+	uc.Load([]string{testHTTPCategory, testHTTPSCategory, testSOCKS4Category})
+	uc.Sessions["idle"] = entity.GatewaySession{Proxy: testProxy3, LastUsed: time.Now().Add(-time.Hour)}
+	uc.Sessions["recent"] = entity.GatewaySession{Proxy: testProxy3, LastUsed: time.Now().Add(-time.Minute)}
+
+	upstream, err := uc.Pick("new")
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+
+	want := map[string]string{"recent": testProxy3, "new": upstream.Proxy.Proxy}
+	got := map[string]string{}
+	for session, stickySession := range uc.Sessions {
+		got[session] = stickySession.Proxy
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "Sessions", want, got)
+	}
+}
+
+func TestGatewayLoadExcludesMITM(t *testing.T) {
+	tests := []struct {
+		name        string
+		includeMITM bool
+		want        []string
+	}{
+		{
+			name:        "Excluded",
+			includeMITM: false,
+			want:        []string{testHTTPCategory},
+		},
+		{
+			name:        "Included",
+			includeMITM: true,
+			want:        []string{testHTTPSCategory, testHTTPSCategory},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewGatewayUsecase(&mockProxyRepository{
+				GetAllAdvancedViewFunc: func() []entity.AdvancedProxy {
+					return []entity.AdvancedProxy{
+						{Proxy: testProxy1, IsMITM: true, Categories: []string{testHTTPCategory, testHTTPSCategory}},
+						{Proxy: testProxy2, IsMITM: true, Categories: []string{testHTTPSCategory}},
+					}
+				},
+			}, &mockProxyService{}, "round-robin", tt.includeMITM).(*GatewayUsecase)
+			uc.Load([]string{testHTTPSCategory, testHTTPCategory})
+
+			var got []string
+			for _, upstream := range uc.Upstreams {
+				got = append(got, upstream.Category)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "upstream categories", tt.want, got)
+			}
+		})
+	}
+}
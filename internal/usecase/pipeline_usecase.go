@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+type PipelineUsecase struct {
+	SourceUsecase SourceUsecaseInterface
+	ProxyUsecase  ProxyUsecaseInterface
+	Workers       int
+	Fetchers      int
+	Yields        []entity.SourceYield
+	Mutex         sync.Mutex
+}
+
+type PipelineUsecaseInterface interface {
+	Process(ctx context.Context, proxies []entity.AdvancedProxy, sources []entity.Source, isChecked bool) int
+	SourceYields() []entity.SourceYield
+}
+
+func NewPipelineUsecase(sourceUsecase SourceUsecaseInterface, proxyUsecase ProxyUsecaseInterface, workers int, fetchers int) PipelineUsecaseInterface {
+	return &PipelineUsecase{
+		SourceUsecase: sourceUsecase,
+		ProxyUsecase:  proxyUsecase,
+		Workers:       workers,
+		Fetchers:      fetchers,
+		Mutex:         sync.Mutex{},
+	}
+}
+
+func (uc *PipelineUsecase) Process(ctx context.Context, proxies []entity.AdvancedProxy, sources []entity.Source, isChecked bool) int {
+	var (
+		scraped    = make(chan entity.ProxyCandidate, uc.Workers)
+		candidates = make(chan entity.ProxyCandidate, uc.Workers)
+		fetchers   = make(chan struct{}, max(uc.Fetchers, 1))
+		sourceWG   = sync.WaitGroup{}
+		workerWG   = sync.WaitGroup{}
+		total      = 0
+	)
+
+	uc.Yields = make([]entity.SourceYield, len(sources))
+	for i, source := range sources {
+		uc.Yields[i] = entity.SourceYield{Method: source.Method, Category: source.Category, URL: source.URL}
+		sourceWG.Add(1)
+		go func(source entity.Source, yield *entity.SourceYield) {
+			defer sourceWG.Done()
+
+			// Only a few sources are downloaded at once, each one blocks on the checkers instead of buffering its body
+			select {
+			case fetchers <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-fetchers }()
+
+			uc.SourceUsecase.ProcessSource(ctx, &source, func(proxy string) bool {
+				uc.Mutex.Lock()
+				yield.Collected++
+				uc.Mutex.Unlock()
+
+				select {
+				case scraped <- entity.ProxyCandidate{Category: source.Category, Proxy: proxy, IsChecked: source.IsChecked && isChecked, Source: yield}:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			})
+		}(source, &uc.Yields[i])
+	}
+	go func() {
+		sourceWG.Wait()
+		close(scraped)
+	}()
+
+	go func() {
+		defer close(candidates)
+
+		// Only the keys of the proxies passed in are kept, so no source is credited for them. Scraped duplicates are
+		// dropped by ProcessProxy, which already remembers every distinct proxy, instead of a set of every scraped line
+		queued := make(map[string]struct{}, len(proxies))
+		emit := func(candidate entity.ProxyCandidate) bool {
+			select {
+			case candidates <- candidate:
+				total++
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, proxy := range proxies {
+			for _, category := range proxy.Categories {
+				queued[category+"_"+proxy.WithCredentials()] = struct{}{}
+				if !emit(entity.ProxyCandidate{Category: category, Proxy: proxy.WithCredentials(), IsChecked: isChecked}) {
+					return
+				}
+			}
+		}
+
+		for candidate := range scraped {
+			if _, found := queued[candidate.Category+"_"+strings.TrimSpace(candidate.Proxy)]; found {
+				continue
+			}
+			if !emit(candidate) {
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < uc.Workers; i++ {
+		workerWG.Add(1)
+		go func() {
+			defer workerWG.Done()
+			for candidate := range candidates {
+				// A proxy listed by several sources is credited to the first one whose candidate was processed
+				if _, err := uc.ProxyUsecase.ProcessProxy(ctx, candidate.Category, candidate.Proxy, candidate.IsChecked); err == nil && candidate.Source != nil {
+					uc.Mutex.Lock()
+					candidate.Source.Working++
+					uc.Mutex.Unlock()
+				}
+			}
+		}()
+	}
+	workerWG.Wait()
+
+	return total
+}
+
+func (uc *PipelineUsecase) SourceYields() []entity.SourceYield {
+	uc.Mutex.Lock()
+	defer uc.Mutex.Unlock()
+
+	return slices.Clone(uc.Yields)
+}
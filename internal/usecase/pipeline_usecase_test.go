@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+func TestNewPipelineUsecase(t *testing.T) {
+	sourceUsecase := &SourceUsecase{}
+	proxyUsecase := &ProxyUsecase{}
+	pipelineUsecase := NewPipelineUsecase(sourceUsecase, proxyUsecase, 10, 2)
+	if pipelineUsecase == nil {
+		t.Errorf(expectedReturnNonNil, "NewPipelineUsecase", "PipelineUsecaseInterface")
+	}
+
+	uc, ok := pipelineUsecase.(*PipelineUsecase)
+	if !ok {
+		t.Errorf(expectedTypeAssertionErrorMessage, "*PipelineUsecase")
+	}
+
+	if uc.Workers != 10 {
+		t.Errorf(expectedButGotMessage, "Workers", 10, uc.Workers)
+	}
+
+	if uc.Fetchers != 2 {
+		t.Errorf(expectedButGotMessage, "Fetchers", 2, uc.Fetchers)
+	}
+}
+
+func TestPipelineProcess(t *testing.T) {
+	var (
+		mutex         sync.Mutex
+		checked       []string
+		running       atomic.Int32
+		maxRunning    atomic.Int32
+		workers       = 3
+		proxyService  = &mockProxyService{}
+		proxyRepo     = &mockProxyRepository{}
+		sourceUsecase = &SourceUsecase{
+			FetcherUtil: &mockFetcherUtil{
+				fetchDataByte: []byte(strings.Join([]string{testProxy1, testProxy2, testProxy2, testProxy3, testProxy4}, "\n")),
+			},
+		}
+	)
+	proxyService.CheckFunc = func(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			current := maxRunning.Load()
+			if n <= current || maxRunning.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		mutex.Lock()
+		checked = append(checked, category+"_"+ip+":"+port)
+		mutex.Unlock()
+		return &entity.Proxy{Category: category, Proxy: ip + ":" + port, IP: ip, Port: port}, nil
+	}
+	proxyUsecase := &ProxyUsecase{
+		ProxyRepository: proxyRepo,
+		ProxyService:    proxyService,
+		SpecialIPs:      testSpecialIPs,
+		PrivateIPs:      testPrivateIPs,
+	}
+
+	uc := NewPipelineUsecase(sourceUsecase, proxyUsecase, workers, 1)
+	got := uc.Process(
+		context.Background(),
+		[]entity.AdvancedProxy{testAdvancedProxyEntity1},
+		[]entity.Source{
+			{Method: testListMethod, Category: testHTTPCategory, URL: testURL, IsChecked: true},
+			{Method: testListMethod, Category: testSOCKS5Category, URL: testURL, IsChecked: false},
+		},
+		true,
+	)
+
+	// 1 previous proxy + 4 HTTP candidates (testProxy1 is already queued) + 5 unchecked SOCKS5 candidates, the scraped
+	// duplicate of testProxy2 is queued and then dropped by ProcessProxy
+	want := 1 + 4 + 5
+	if got != want {
+		t.Errorf(expectedButGotMessage, "candidates", want, got)
+	}
+
+	if stored := len(proxyRepo.GetStoredProxies()); stored != 1+3+4 {
+		t.Errorf(expectedButGotMessage, "stored proxies", 1+3+4, stored)
+	}
+
+	if len(checked) != 4 {
+		t.Errorf(expectedButGotMessage, "checked proxies", 4, len(checked))
+	}
+
+	if int(maxRunning.Load()) > workers {
+		t.Errorf(expectedButGotMessage, "max concurrent checks", workers, maxRunning.Load())
+	}
+
+	// Duplicates and proxies queued from the previous run are not credited to a source
+	wantYields := []entity.SourceYield{
+		{Method: testListMethod, Category: testHTTPCategory, URL: testURL, Collected: 5, Working: 3},
+		{Method: testListMethod, Category: testSOCKS5Category, URL: testURL, Collected: 5, Working: 4},
+	}
+	if yields := uc.SourceYields(); !reflect.DeepEqual(yields, wantYields) {
+		t.Errorf(expectedButGotMessage, "SourceYields()", wantYields, yields)
+	}
+}
+
+func TestPipelineProcessPriority(t *testing.T) {
+	var checked []string
+	proxyUsecase := &ProxyUsecase{
+		ProxyRepository: &mockProxyRepository{},
+		ProxyService: &mockProxyService{
+			CheckFunc: func(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error) {
+				checked = append(checked, ip+":"+port)
+				return &entity.Proxy{Category: category, Proxy: ip + ":" + port, IP: ip, Port: port}, nil
+			},
+		},
+	}
+	sourceUsecase := &SourceUsecase{
+		FetcherUtil: &mockFetcherUtil{
+			fetchDataByte: []byte(testProxy1 + "\n" + testProxy2),
+		},
+	}
+
+	uc := NewPipelineUsecase(sourceUsecase, proxyUsecase, 1, 1)
+	uc.Process(
+		context.Background(),
+		[]entity.AdvancedProxy{testAdvancedProxyEntity3},
+		[]entity.Source{{Method: testListMethod, Category: testHTTPCategory, URL: testURL, IsChecked: true}},
+		true,
+	)
+
+	want := []string{testProxy3, testProxy1, testProxy2}
+	if !reflect.DeepEqual(checked, want) {
+		t.Errorf(expectedButGotMessage, "checked proxies", want, checked)
+	}
+}
+
+func TestPipelineProcessCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	sourceUsecase := &SourceUsecase{
+		FetcherUtil: &mockFetcherUtil{
+			fetchDataByte: []byte(testProxy1 + "\n" + testProxy2),
+		},
+	}
+	proxyUsecase := &ProxyUsecase{
+		ProxyRepository: &mockProxyRepository{},
+		ProxyService: &mockProxyService{
+			CheckFunc: func(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error) {
+				return nil, ctx.Err()
+			},
+		},
+	}
+
+	uc := NewPipelineUsecase(sourceUsecase, proxyUsecase, 2, 1)
+	done := make(chan int)
+	go func() {
+		done <- uc.Process(ctx, nil, []entity.Source{{Method: testListMethod, Category: testHTTPCategory, URL: testURL, IsChecked: true}}, true)
+	}()
+
+	select {
+	case got := <-done:
+		if got > 2 {
+			t.Errorf(expectedButGotMessage, "candidates", "at most 2", got)
+		}
+	case <-time.After(time.Second):
+		t.Errorf(unexpectedMessage, "Process()", "did not return after cancellation")
+	}
+}
+
+func TestPipelineProcessBoundsFetches(t *testing.T) {
+	var (
+		fetching    atomic.Int32
+		maxFetching atomic.Int32
+		sources     []entity.Source
+	)
+	sourceUsecase := &SourceUsecase{
+		FetcherUtil: &mockFetcherUtil{
+			FetchStreamFunc: func(ctx context.Context, url string) (io.ReadCloser, error) {
+				n := fetching.Add(1)
+				defer fetching.Add(-1)
+				for {
+					current := maxFetching.Load()
+					if n <= current || maxFetching.CompareAndSwap(current, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				return io.NopCloser(strings.NewReader(testProxy1)), nil
+			},
+		},
+	}
+	proxyUsecase := &ProxyUsecase{
+		ProxyRepository: &mockProxyRepository{},
+		ProxyService:    &mockProxyService{},
+	}
+	for i := 0; i < 10; i++ {
+		sources = append(sources, entity.Source{Method: testListMethod, Category: testHTTPCategory, URL: testURL, IsChecked: false})
+	}
+
+	NewPipelineUsecase(sourceUsecase, proxyUsecase, 2, 3).Process(context.Background(), nil, sources, false)
+	if got := maxFetching.Load(); got > 3 {
+		t.Errorf(expectedButGotMessage, "max concurrent fetches", 3, got)
+	}
+}
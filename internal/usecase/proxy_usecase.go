@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"regexp"
@@ -8,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/fyvri/fresh-proxy-list/internal/entity"
 	"github.com/fyvri/fresh-proxy-list/internal/infrastructure/repository"
@@ -15,56 +17,89 @@ import (
 )
 
 type ProxyUsecase struct {
-	ProxyRepository repository.ProxyRepositoryInterface
-	ProxyService    service.ProxyServiceInterface
-	ProxyMap        sync.Map
-	SpecialIPs      []string
-	PrivateIPs      []net.IPNet
+	ProxyRepository   repository.ProxyRepositoryInterface
+	HistoryRepository repository.HistoryRepositoryInterface
+	ProxyService      service.ProxyServiceInterface
+	ProxyMap          sync.Map
+	SpecialIPs        []string
+	PrivateIPs        []net.IPNet
+}
+
+var remoteDNSCategories = map[string]string{
+	"SOCKS4": "SOCKS4A",
+	"SOCKS5": "SOCKS5H",
 }
 
 type ProxyUsecaseInterface interface {
-	ProcessProxy(category string, proxy string, isChecked bool) (*entity.Proxy, error)
+	ProcessProxy(ctx context.Context, category string, proxy string, isChecked bool) (*entity.Proxy, error)
+	RestoreProxy(category string, proxy *entity.AdvancedProxy) (*entity.Proxy, error)
 	IsSpecialIP(ip string) bool
 	GetAllAdvancedView() []entity.AdvancedProxy
+	SaveHistory() error
 }
 
 func NewProxyUsecase(
 	proxyRepository repository.ProxyRepositoryInterface,
+	historyRepository repository.HistoryRepositoryInterface,
 	proxyService service.ProxyServiceInterface,
 	specialIPs []string,
 	privateIPs []net.IPNet,
 ) ProxyUsecaseInterface {
 	return &ProxyUsecase{
-		ProxyRepository: proxyRepository,
-		ProxyService:    proxyService,
-		SpecialIPs:      specialIPs,
-		PrivateIPs:      privateIPs,
-		ProxyMap:        sync.Map{},
+		ProxyRepository:   proxyRepository,
+		HistoryRepository: historyRepository,
+		ProxyService:      proxyService,
+		SpecialIPs:        specialIPs,
+		PrivateIPs:        privateIPs,
+		ProxyMap:          sync.Map{},
 	}
 }
 
-func (uc *ProxyUsecase) ProcessProxy(category string, proxy string, isChecked bool) (*entity.Proxy, error) {
+func (uc *ProxyUsecase) ProcessProxy(ctx context.Context, category string, proxy string, isChecked bool) (*entity.Proxy, error) {
 	proxy = strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(proxy, "\r", ""), "\n", ""))
 	if proxy == "" {
 		return nil, fmt.Errorf("proxy not found")
 	}
 
-	proxyParts := strings.Split(proxy, ":")
-	if len(proxyParts) != 2 {
+	var username, password string
+	if i := strings.LastIndex(proxy, "@"); i >= 0 {
+		credentials := strings.SplitN(proxy[:i], ":", 2)
+		if len(credentials) != 2 || credentials[0] == "" || credentials[1] == "" {
+			return nil, fmt.Errorf("proxy credentials incorrect")
+		}
+		username, password, proxy = credentials[0], credentials[1], proxy[i+1:]
+	} else if proxyParts := strings.SplitN(proxy, ":", 4); len(proxyParts) == 4 && strings.Contains(proxyParts[0], ".") {
+		if proxyParts[2] == "" || proxyParts[3] == "" {
+			return nil, fmt.Errorf("proxy credentials incorrect")
+		}
+		username, password, proxy = proxyParts[2], proxyParts[3], proxyParts[0]+":"+proxyParts[1]
+	}
+
+	proxyIP, proxyPort, err := net.SplitHostPort(proxy)
+	if err != nil {
 		return nil, fmt.Errorf("proxy format incorrect")
 	}
 
-	pattern := `^((25[0-5]|2[0-4][0-9]|[0-1]?[0-9][0-9]?)\.){3}(25[0-5]|2[0-4][0-9]|[0-1]?[0-9][0-9]?)\:(0|[1-9][0-9]{0,4})$`
-	re := regexp.MustCompile(pattern)
-	if !re.MatchString(proxy) {
-		return nil, fmt.Errorf("proxy format not match")
+	if strings.Contains(proxyIP, ":") {
+		ipAddress := net.ParseIP(proxyIP)
+		if ipAddress == nil || !regexp.MustCompile(`^(0|[1-9][0-9]{0,4})$`).MatchString(proxyPort) {
+			return nil, fmt.Errorf("proxy format not match")
+		}
+		proxyIP = ipAddress.String()
+		proxy = net.JoinHostPort(proxyIP, proxyPort)
+	} else {
+		pattern := `^((25[0-5]|2[0-4][0-9]|[0-1]?[0-9][0-9]?)\.){3}(25[0-5]|2[0-4][0-9]|[0-1]?[0-9][0-9]?)\:(0|[1-9][0-9]{0,4})$`
+		re := regexp.MustCompile(pattern)
+		if !re.MatchString(proxy) {
+			return nil, fmt.Errorf("proxy format not match")
+		}
 	}
 
-	if uc.IsSpecialIP(proxyParts[0]) {
+	if uc.IsSpecialIP(proxyIP) {
 		return nil, fmt.Errorf("proxy belongs to special ip")
 	}
 
-	port, err := strconv.Atoi(proxyParts[1])
+	port, err := strconv.Atoi(proxyPort)
 	if err != nil || port < 0 || port > 65535 {
 		return nil, fmt.Errorf("proxy port format incorrect")
 	}
@@ -74,12 +109,10 @@ func (uc *ProxyUsecase) ProcessProxy(category string, proxy string, isChecked bo
 		return nil, fmt.Errorf("proxy has been processed")
 	}
 
-	var (
-		data               *entity.Proxy
-		proxyIP, proxyPort = proxyParts[0], proxyParts[1]
-	)
+	var data *entity.Proxy
 	if isChecked {
-		data, err = uc.ProxyService.Check(category, proxyIP, proxyPort)
+		data, err = uc.ProxyService.Check(ctx, category, proxyIP, proxyPort, username, password)
+		uc.recordCheck(ctx, category, proxy, data, err)
 		if err != nil {
 			return nil, err
 		}
@@ -88,6 +121,8 @@ func (uc *ProxyUsecase) ProcessProxy(category string, proxy string, isChecked bo
 			Proxy:     proxy,
 			IP:        proxyIP,
 			Port:      proxyPort,
+			Username:  username,
+			Password:  password,
 			Category:  category,
 			TimeTaken: 0,
 			CheckedAt: "",
@@ -95,6 +130,43 @@ func (uc *ProxyUsecase) ProcessProxy(category string, proxy string, isChecked bo
 	}
 	uc.ProxyRepository.Store(data)
 
+	// SOCKS proxies resolving hostnames themselves are also listed under their remote-DNS category
+	if remoteCategory, found := remoteDNSCategories[category]; found && data.RemoteDNS {
+		if _, loaded := uc.ProxyMap.LoadOrStore(remoteCategory+"_"+proxy, true); !loaded {
+			remoteData := *data
+			remoteData.Category = remoteCategory
+			uc.ProxyRepository.Store(&remoteData)
+		}
+	}
+
+	return data, nil
+}
+
+func (uc *ProxyUsecase) RestoreProxy(category string, proxy *entity.AdvancedProxy) (*entity.Proxy, error) {
+	_, loaded := uc.ProxyMap.LoadOrStore(category+"_"+proxy.Proxy, true)
+	if loaded {
+		return nil, fmt.Errorf("proxy has been processed")
+	}
+
+	data := &entity.Proxy{
+		Proxy:        proxy.Proxy,
+		IP:           proxy.IP,
+		Port:         proxy.Port,
+		Username:     proxy.Username,
+		Password:     proxy.Password,
+		Category:     category,
+		TimeTaken:    proxy.TimeTaken,
+		CheckedAt:    proxy.CheckedAt,
+		Anonymity:    proxy.Anonymity,
+		ExitIP:       proxy.ExitIP,
+		IsRotating:   proxy.IsRotating,
+		IsMITM:       proxy.IsMITM,
+		RemoteDNS:    proxy.RemoteDNS,
+		ConnectPorts: proxy.ConnectPorts,
+	}
+	uc.ProxyRepository.Store(data)
+	uc.ProxyRepository.SetHistory(proxy.Proxy, proxy.History())
+
 	return data, nil
 }
 
@@ -124,3 +196,37 @@ func (uc *ProxyUsecase) IsSpecialIP(ip string) bool {
 func (uc *ProxyUsecase) GetAllAdvancedView() []entity.AdvancedProxy {
 	return uc.ProxyRepository.GetAllAdvancedView()
 }
+
+func (uc *ProxyUsecase) SaveHistory() error {
+	if uc.HistoryRepository == nil {
+		return nil
+	}
+
+	if err := uc.HistoryRepository.Save(); err != nil {
+		return err
+	}
+
+	for _, proxy := range uc.ProxyRepository.GetAllAdvancedView() {
+		uc.ProxyRepository.SetHistory(proxy.Proxy, uc.HistoryRepository.GetHistory(proxy.Proxy, proxy.Categories))
+	}
+	return nil
+}
+
+func (uc *ProxyUsecase) recordCheck(ctx context.Context, category string, proxy string, data *entity.Proxy, err error) {
+	// Checks cut short by the deadline say nothing about the proxy
+	if uc.HistoryRepository == nil || ctx.Err() != nil {
+		return
+	}
+
+	record := entity.CheckRecord{
+		Category:  category,
+		Proxy:     proxy,
+		OK:        err == nil,
+		CheckedAt: time.Now().Format(time.RFC3339),
+	}
+	if err == nil {
+		record.TimeTaken = data.TimeTaken
+		record.CheckedAt = data.CheckedAt
+	}
+	uc.HistoryRepository.Record(record)
+}
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"net"
 	"reflect"
@@ -15,7 +16,7 @@ import (
 func TestNewProxyUsecase(t *testing.T) {
 	mockProxyRepository := &mockProxyRepository{}
 	mockProxyService := &mockProxyService{}
-	proxyUsecase := NewProxyUsecase(mockProxyRepository, mockProxyService, testSpecialIPs, testPrivateIPs)
+	proxyUsecase := NewProxyUsecase(mockProxyRepository, nil, mockProxyService, testSpecialIPs, testPrivateIPs)
 	if proxyUsecase == nil {
 		t.Errorf(expectedReturnNonNil, "NewProxyUsecase", "ProxyUsecaseInterface")
 	}
@@ -142,6 +143,132 @@ func TestProcessProxy(t *testing.T) {
 			want:      nil,
 			wantError: errors.New("proxy port format incorrect"),
 		},
+		{
+			name: "IPv6ProxyWithoutBrackets",
+			fields: fields{
+				proxyRepository: &mockProxyRepository{},
+				proxyService:    &mockProxyService{},
+			},
+			args: args{
+				category:  testHTTPCategory,
+				proxy:     testIPv6 + ":" + testIPv6Port,
+				isChecked: false,
+			},
+			want:      nil,
+			wantError: errors.New("proxy format incorrect"),
+		},
+		{
+			name: "IPv6ProxyFormatNotMatch",
+			fields: fields{
+				proxyRepository: &mockProxyRepository{},
+				proxyService:    &mockProxyService{},
+			},
+			args: args{
+				category:  testHTTPCategory,
+				proxy:     "[2606:zzzz::1]:" + testIPv6Port,
+				isChecked: false,
+			},
+			want:      nil,
+			wantError: errors.New("proxy format not match"),
+		},
+		{
+			name: "IPv6ProxyIsPrivateIP",
+			fields: fields{
+				proxyRepository: &mockProxyRepository{},
+				proxyService:    &mockProxyService{},
+			},
+			args: args{
+				category:  testHTTPCategory,
+				proxy:     "[fd00::1]:" + testIPv6Port,
+				isChecked: false,
+			},
+			want:      nil,
+			wantError: errors.New("proxy belongs to special ip"),
+		},
+		{
+			name: "IPv6ProxyIsNormalized",
+			fields: fields{
+				proxyRepository: &mockProxyRepository{},
+				proxyService:    &mockProxyService{},
+			},
+			args: args{
+				category:  testHTTPCategory,
+				proxy:     "[2606:4700:0:0:0:0:0:1111]:" + testIPv6Port,
+				isChecked: false,
+			},
+			want: &entity.Proxy{
+				Category:  testHTTPCategory,
+				Proxy:     "[" + testIPv6 + "]:" + testIPv6Port,
+				IP:        testIPv6,
+				Port:      testIPv6Port,
+				TimeTaken: 0,
+				CheckedAt: "",
+			},
+			wantError: nil,
+		},
+		{
+			name: "ProxyCredentialsIncorrect",
+			fields: fields{
+				proxyRepository: &mockProxyRepository{},
+				proxyService:    &mockProxyService{},
+			},
+			args: args{
+				category:  testHTTPCategory,
+				proxy:     ":" + testPassword + "@" + testProxy1,
+				isChecked: false,
+			},
+			want:      nil,
+			wantError: errors.New("proxy credentials incorrect"),
+		},
+		{
+			name: "ProxyWithLeadingCredentials",
+			fields: fields{
+				proxyRepository: &mockProxyRepository{},
+				proxyService:    &mockProxyService{},
+			},
+			args: args{
+				category:  testHTTPCategory,
+				proxy:     testUsername + ":" + testPassword + "@" + testProxy1,
+				isChecked: false,
+			},
+			want: &entity.Proxy{
+				Category: testHTTPCategory,
+				Proxy:    testProxy1,
+				IP:       testIP1,
+				Port:     testPort1,
+				Username: testUsername,
+				Password: testPassword,
+			},
+			wantError: nil,
+		},
+		{
+			name: "ProxyWithTrailingCredentials",
+			fields: fields{
+				proxyRepository: &mockProxyRepository{},
+				proxyService: &mockProxyService{
+					CheckFunc: func(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error) {
+						if username != testUsername || password != testPassword {
+							return nil, errors.New("proxy credentials not passed")
+						}
+						return &entity.Proxy{Category: category, Proxy: ip + ":" + port, IP: ip, Port: port, Username: username, Password: password}, nil
+					},
+				},
+			},
+			args: args{
+				category:  testHTTPCategory,
+				proxy:     testProxy1 + ":" + testUsername + ":" + testPassword,
+				isChecked: true,
+			},
+			want: &entity.Proxy{
+				Category: testHTTPCategory,
+				Proxy:    testProxy1,
+				IP:       testIP1,
+				Port:     testPort1,
+				Username: testUsername,
+				Password: testPassword,
+			},
+			wantError: nil,
+		},
 		{
 			name: "ProxyHasBeenProcessed",
 			fields: fields{
@@ -160,7 +287,7 @@ func TestProcessProxy(t *testing.T) {
 			fields: fields{
 				proxyRepository: &mockProxyRepository{},
 				proxyService: &mockProxyService{
-					CheckFunc: func(category string, ip string, port string) (*entity.Proxy, error) {
+					CheckFunc: func(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error) {
 						return &testProxyEntity1, nil
 					},
 				},
@@ -185,7 +312,7 @@ func TestProcessProxy(t *testing.T) {
 			fields: fields{
 				proxyRepository: &mockProxyRepository{},
 				proxyService: &mockProxyService{
-					CheckFunc: func(category string, ip string, port string) (*entity.Proxy, error) {
+					CheckFunc: func(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error) {
 						return nil, errors.New("proxy not valid")
 					},
 				},
@@ -203,7 +330,7 @@ func TestProcessProxy(t *testing.T) {
 			fields: fields{
 				proxyRepository: &mockProxyRepository{},
 				proxyService: &mockProxyService{
-					CheckFunc: func(category string, ip string, port string) (*entity.Proxy, error) {
+					CheckFunc: func(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error) {
 						return &testProxyEntity1, nil
 					},
 				},
@@ -239,7 +366,7 @@ func TestProcessProxy(t *testing.T) {
 				uc.ProxyMap.Store(tt.args.category+"_"+tt.args.proxy, true)
 			}
 
-			got, err := uc.ProcessProxy(tt.args.category, tt.args.proxy, tt.args.isChecked)
+			got, err := uc.ProcessProxy(context.Background(), tt.args.category, tt.args.proxy, tt.args.isChecked)
 
 			if err != nil && err.Error() != tt.wantError.Error() {
 				t.Errorf(expectedErrorButGotMessage, "ProcessProxy()", tt.wantError, err)
@@ -252,6 +379,151 @@ func TestProcessProxy(t *testing.T) {
 	}
 }
 
+func TestRestoreProxy(t *testing.T) {
+	mockProxyRepository := &mockProxyRepository{}
+	uc := &ProxyUsecase{
+		ProxyRepository: mockProxyRepository,
+		ProxyMap:        sync.Map{},
+	}
+
+	got, err := uc.RestoreProxy(testCategory1, &testAdvancedProxyEntity1)
+	if err != nil {
+		t.Errorf(expectedErrorButGotMessage, "RestoreProxy()", nil, err)
+	}
+
+	if !reflect.DeepEqual(*got, testProxyEntity1) {
+		t.Errorf(expectedButGotMessage, "RestoreProxy()", testProxyEntity1, *got)
+	}
+
+	if len(mockProxyRepository.GetStoredProxies()) != 1 {
+		t.Errorf(expectedButGotMessage, "stored proxies", 1, len(mockProxyRepository.GetStoredProxies()))
+	}
+
+	wantError := errors.New("proxy has been processed")
+	got, err = uc.RestoreProxy(testCategory1, &testAdvancedProxyEntity1)
+	if err == nil || err.Error() != wantError.Error() {
+		t.Errorf(expectedErrorButGotMessage, "RestoreProxy()", wantError, err)
+	}
+
+	if got != nil {
+		t.Errorf(expectedButGotMessage, "RestoreProxy()", nil, got)
+	}
+}
+
+func TestProcessProxyRecordsHistory(t *testing.T) {
+	mockHistoryRepository := &mockHistoryRepository{}
+	uc := &ProxyUsecase{
+		ProxyRepository:   &mockProxyRepository{},
+		HistoryRepository: mockHistoryRepository,
+		ProxyService: &mockProxyService{
+			CheckFunc: func(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error) {
+				if ip == testIP2 {
+					return nil, errors.New("proxy unreachable")
+				}
+				return &testProxyEntity1, nil
+			},
+		},
+		ProxyMap:   sync.Map{},
+		SpecialIPs: testSpecialIPs,
+		PrivateIPs: testPrivateIPs,
+	}
+
+	uc.ProcessProxy(context.Background(), testCategory1, testProxy1, true)
+	uc.ProcessProxy(context.Background(), testCategory1, testProxy2, true)
+	uc.ProcessProxy(context.Background(), testCategory1, testProxy1+"0", false)
+
+	want := []entity.CheckRecord{
+		{Category: testCategory1, Proxy: testProxy1, OK: true, TimeTaken: testProxyEntity1.TimeTaken, CheckedAt: testProxyEntity1.CheckedAt},
+		{Category: testCategory1, Proxy: testProxy2, OK: false},
+	}
+	if len(mockHistoryRepository.Records) != len(want) {
+		t.Fatalf(expectedButGotMessage, "records", len(want), len(mockHistoryRepository.Records))
+	}
+	for i, record := range mockHistoryRepository.Records {
+		record.CheckedAt = want[i].CheckedAt
+		if !reflect.DeepEqual(record, want[i]) {
+			t.Errorf(expectedButGotMessage, "record", want[i], record)
+		}
+	}
+}
+
+func TestProcessProxyStoresRemoteDNSCategory(t *testing.T) {
+	mockProxyRepository := &mockProxyRepository{}
+	uc := &ProxyUsecase{
+		ProxyRepository: mockProxyRepository,
+		ProxyService: &mockProxyService{
+			CheckFunc: func(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error) {
+				return &entity.Proxy{Category: category, Proxy: ip + ":" + port, IP: ip, Port: port, RemoteDNS: ip == testIP1}, nil
+			},
+		},
+		ProxyMap:   sync.Map{},
+		SpecialIPs: testSpecialIPs,
+		PrivateIPs: testPrivateIPs,
+	}
+
+	uc.ProcessProxy(context.Background(), "SOCKS5", testProxy1, true)
+	uc.ProcessProxy(context.Background(), "SOCKS5H", testProxy1, true)
+	uc.ProcessProxy(context.Background(), "SOCKS4", testProxy2, true)
+
+	want := []string{"SOCKS5 " + testProxy1, "SOCKS5H " + testProxy1, "SOCKS4 " + testProxy2}
+	var got []string
+	for _, proxy := range mockProxyRepository.GetStoredProxies() {
+		got = append(got, proxy.Category+" "+proxy.Proxy)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "stored proxies", want, got)
+	}
+}
+
+func TestSaveHistory(t *testing.T) {
+	var (
+		history = &entity.ProxyHistory{Uptime: 50, FirstSeen: testProxyEntity1.CheckedAt, LastSeen: testProxyEntity1.CheckedAt, ConsecutiveFailures: 1, AverageLatency: 1.5}
+		got     = map[string]*entity.ProxyHistory{}
+	)
+	uc := &ProxyUsecase{
+		ProxyRepository: &mockProxyRepository{
+			GetAllAdvancedViewFunc: func() []entity.AdvancedProxy {
+				return []entity.AdvancedProxy{testAdvancedProxyEntity1}
+			},
+			SetHistoryFunc: func(proxy string, history *entity.ProxyHistory) {
+				got[proxy] = history
+			},
+		},
+		HistoryRepository: &mockHistoryRepository{
+			GetHistoryFunc: func(proxy string, categories []string) *entity.ProxyHistory {
+				if !reflect.DeepEqual(categories, testAdvancedProxyEntity1.Categories) {
+					t.Errorf(expectedButGotMessage, "categories", testAdvancedProxyEntity1.Categories, categories)
+				}
+				return history
+			},
+		},
+	}
+
+	if err := uc.SaveHistory(); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "SaveHistory()", nil, err)
+	}
+
+	want := map[string]*entity.ProxyHistory{testProxy1: history}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "history", want, got)
+	}
+
+	wantError := errors.New("disk full")
+	uc.HistoryRepository = &mockHistoryRepository{
+		SaveFunc: func() error {
+			return wantError
+		},
+	}
+	if err := uc.SaveHistory(); err != wantError {
+		t.Errorf(expectedErrorButGotMessage, "SaveHistory()", wantError, err)
+	}
+
+	uc.HistoryRepository = nil
+	if err := uc.SaveHistory(); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "SaveHistory()", nil, err)
+	}
+}
+
 func TestIsSpecialIP(t *testing.T) {
 	type args struct {
 		ip string
@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+	"github.com/fyvri/fresh-proxy-list/internal/infrastructure/repository"
+)
+
+type QueryUsecase struct {
+	ProxyRepository    repository.ProxyRepositoryInterface
+	FileRepository     repository.FileRepositoryInterface
+	Categories         []string
+	IncludeCredentials bool
+	IncludeMITM        bool
+}
+
+type QueryUsecaseInterface interface {
+	Query(query entity.ProxyQuery) ([]string, interface{}, error)
+	Stream(query entity.ProxyQuery) (interface{}, error)
+	Random(query entity.ProxyQuery) ([]string, interface{}, error)
+	Stats() entity.ProxyStats
+	Encode(writer io.Writer, classic []string, advanced interface{}, format string) error
+}
+
+var ErrProxyNotFound = errors.New("no proxy matches the query")
+
+func NewQueryUsecase(
+	proxyRepository repository.ProxyRepositoryInterface,
+	fileRepository repository.FileRepositoryInterface,
+	categories []string,
+	includeCredentials bool,
+	includeMITM bool,
+) QueryUsecaseInterface {
+	return &QueryUsecase{
+		ProxyRepository:    proxyRepository,
+		FileRepository:     fileRepository,
+		Categories:         categories,
+		IncludeCredentials: includeCredentials,
+		IncludeMITM:        includeMITM,
+	}
+}
+
+func (uc *QueryUsecase) Query(query entity.ProxyQuery) ([]string, interface{}, error) {
+	stream, err := uc.Stream(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	classic := []string{}
+	switch proxies := stream.(type) {
+	case iter.Seq[entity.Proxy]:
+		advanced := []entity.Proxy{}
+		for proxy := range proxies {
+			classic = append(classic, proxy.WithCredentials())
+			advanced = append(advanced, proxy)
+		}
+		return classic, advanced, nil
+	case iter.Seq[entity.AdvancedProxy]:
+		advanced := []entity.AdvancedProxy{}
+		for proxy := range proxies {
+			classic = append(classic, proxy.WithCredentials())
+			advanced = append(advanced, proxy)
+		}
+		return classic, advanced, nil
+	}
+	return classic, nil, nil
+}
+
+func (uc *QueryUsecase) Stream(query entity.ProxyQuery) (interface{}, error) {
+	matches := func(timeTaken float64, anonymity string) bool {
+		return (query.MaxLatency <= 0 || timeTaken <= query.MaxLatency) &&
+			(query.Anonymity == "" || anonymity == query.Anonymity)
+	}
+
+	if query.Category != "" {
+		if !slices.Contains(uc.Categories, query.Category) {
+			return nil, fmt.Errorf("proxy category not found: %s", query.Category)
+		}
+
+		return iter.Seq[entity.Proxy](func(yield func(entity.Proxy) bool) {
+			count := 0
+			for _, proxy := range uc.ProxyRepository.GetAdvancedView(query.Category) {
+				if query.Limit > 0 && count >= query.Limit {
+					return
+				}
+				if (query.Category == "HTTPS" && proxy.IsMITM && !uc.IncludeMITM) || !matches(proxy.TimeTaken, proxy.Anonymity) {
+					continue
+				}
+				if !uc.IncludeCredentials {
+					proxy.Username, proxy.Password = "", ""
+				}
+				if !yield(proxy) {
+					return
+				}
+				count++
+			}
+		}), nil
+	}
+
+	return iter.Seq[entity.AdvancedProxy](func(yield func(entity.AdvancedProxy) bool) {
+		count := 0
+		for _, proxy := range uc.allAdvancedView() {
+			if query.Limit > 0 && count >= query.Limit {
+				return
+			}
+			if !matches(proxy.TimeTaken, proxy.Anonymity) {
+				continue
+			}
+			if !uc.IncludeCredentials {
+				proxy.Username, proxy.Password = "", ""
+			}
+			if !yield(proxy) {
+				return
+			}
+			count++
+		}
+	}), nil
+}
+
+func (uc *QueryUsecase) Random(query entity.ProxyQuery) ([]string, interface{}, error) {
+	query.Limit = 0
+	classic, advanced, err := uc.Query(query)
+	if err != nil {
+		return nil, nil, err
+	} else if len(classic) == 0 {
+		return nil, nil, ErrProxyNotFound
+	}
+
+	i := rand.Intn(len(classic))
+	switch proxies := advanced.(type) {
+	case []entity.Proxy:
+		advanced = proxies[i : i+1]
+	case []entity.AdvancedProxy:
+		advanced = proxies[i : i+1]
+	}
+	return classic[i : i+1], advanced, nil
+}
+
+func (uc *QueryUsecase) Stats() entity.ProxyStats {
+	var (
+		stats = entity.ProxyStats{
+			Categories: map[string]int{},
+			Anonymity:  map[string]int{},
+		}
+		latency     = 0.0
+		lastChecked time.Time
+	)
+	for _, category := range uc.Categories {
+		stats.Categories[category] = 0
+	}
+
+	for _, proxy := range uc.allAdvancedView() {
+		stats.Total++
+		for _, category := range proxy.Categories {
+			stats.Categories[category]++
+		}
+		if proxy.Anonymity != "" {
+			stats.Anonymity[proxy.Anonymity]++
+		}
+		if proxy.IsRotating {
+			stats.Rotating++
+		}
+		if checkedAt, err := time.Parse(time.RFC3339, proxy.CheckedAt); err == nil && checkedAt.After(lastChecked) {
+			lastChecked = checkedAt
+			stats.LastCheckedAt = proxy.CheckedAt
+		}
+		latency += proxy.TimeTaken
+	}
+
+	if stats.Total > 0 {
+		stats.AverageLatency = latency / float64(stats.Total)
+	}
+	return stats
+}
+
+// allAdvancedView drops the HTTPS category of the proxies that intercept TLS, like the files written by SaveFiles
+func (uc *QueryUsecase) allAdvancedView() []entity.AdvancedProxy {
+	proxies := uc.ProxyRepository.GetAllAdvancedView()
+	if !uc.IncludeMITM {
+		_, proxies = excludeMITMCategory(nil, proxies)
+	}
+	return proxies
+}
+
+func (uc *QueryUsecase) Encode(writer io.Writer, classic []string, advanced interface{}, format string) error {
+	if format == "txt" {
+		return uc.FileRepository.Encode(writer, classic, format)
+	}
+	return uc.FileRepository.Encode(writer, advanced, format)
+}
@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+func newTestQueryUsecase(includeCredentials bool, includeMITM bool) QueryUsecaseInterface {
+	return NewQueryUsecase(&mockProxyRepository{
+		GetAllAdvancedViewFunc: func() []entity.AdvancedProxy {
+			return []entity.AdvancedProxy{
+				{Proxy: testProxy1, IP: testIP1, Port: testPort1, Username: testUsername, Password: testPassword, TimeTaken: 1, CheckedAt: "2024-01-01T20:00:00Z", Anonymity: "elite", Categories: []string{testHTTPCategory, testHTTPSCategory}},
+				{Proxy: testProxy2, IP: testIP2, Port: testPort2, TimeTaken: 3, CheckedAt: "2024-01-02T00:00:00+07:00", Anonymity: "transparent", IsRotating: true, Categories: []string{testHTTPSCategory}},
+			}
+		},
+		GetAdvancedViewFunc: func(category string) []entity.Proxy {
+			if category != testHTTPSCategory {
+				return nil
+			}
+			return []entity.Proxy{
+				{Category: testHTTPSCategory, Proxy: testProxy1, IP: testIP1, Port: testPort1, Username: testUsername, Password: testPassword, TimeTaken: 1, Anonymity: "elite"},
+				{Category: testHTTPSCategory, Proxy: testProxy2, IP: testIP2, Port: testPort2, TimeTaken: 3, Anonymity: "transparent", IsMITM: true},
+			}
+		},
+	}, &mockFileRepository{}, testProxyCategories, includeCredentials, includeMITM)
+}
+
+func TestNewQueryUsecase(t *testing.T) {
+	queryUsecase := NewQueryUsecase(&mockProxyRepository{}, &mockFileRepository{}, testProxyCategories, true, true)
+	if queryUsecase == nil {
+		t.Errorf(expectedReturnNonNil, "NewQueryUsecase", "QueryUsecaseInterface")
+	}
+
+	uc, ok := queryUsecase.(*QueryUsecase)
+	if !ok {
+		t.Errorf(expectedTypeAssertionErrorMessage, "*QueryUsecase")
+	}
+
+	if !reflect.DeepEqual(uc.Categories, testProxyCategories) || !uc.IncludeCredentials || !uc.IncludeMITM {
+		t.Errorf(expectedButGotMessage, "QueryUsecase", testProxyCategories, uc)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name               string
+		query              entity.ProxyQuery
+		includeCredentials bool
+		includeMITM        bool
+		wantClassic        []string
+		wantError          error
+	}{
+		{
+			name:        "All",
+			query:       entity.ProxyQuery{},
+			wantClassic: []string{testProxy1, testProxy2},
+		},
+		{
+			name:               "AllWithCredentials",
+			query:              entity.ProxyQuery{},
+			includeCredentials: true,
+			wantClassic:        []string{testUsername + ":" + testPassword + "@" + testProxy1, testProxy2},
+		},
+		{
+			name:        "MaxLatency",
+			query:       entity.ProxyQuery{MaxLatency: 2},
+			wantClassic: []string{testProxy1},
+		},
+		{
+			name:        "Anonymity",
+			query:       entity.ProxyQuery{Anonymity: "transparent"},
+			wantClassic: []string{testProxy2},
+		},
+		{
+			name:        "Limit",
+			query:       entity.ProxyQuery{Limit: 1},
+			wantClassic: []string{testProxy1},
+		},
+		{
+			name:        "CategoryExcludesMITM",
+			query:       entity.ProxyQuery{Category: testHTTPSCategory},
+			wantClassic: []string{testProxy1},
+		},
+		{
+			name:        "CategoryIncludesMITM",
+			query:       entity.ProxyQuery{Category: testHTTPSCategory},
+			includeMITM: true,
+			wantClassic: []string{testProxy1, testProxy2},
+		},
+		{
+			name:        "EmptyCategory",
+			query:       entity.ProxyQuery{Category: testSOCKS5Category},
+			wantClassic: []string{},
+		},
+		{
+			name:      "UnknownCategory",
+			query:     entity.ProxyQuery{Category: "FTP"},
+			wantError: errors.New("proxy category not found: FTP"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := newTestQueryUsecase(tt.includeCredentials, tt.includeMITM)
+			classic, advanced, err := uc.Query(tt.query)
+			if (err != nil && tt.wantError == nil) || (err == nil && tt.wantError != nil) || (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) {
+				t.Fatalf(expectedErrorButGotMessage, "Query()", tt.wantError, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(classic, tt.wantClassic) {
+				t.Errorf(expectedButGotMessage, "classic", tt.wantClassic, classic)
+			}
+			if count := reflect.ValueOf(advanced).Len(); count != len(tt.wantClassic) {
+				t.Errorf(expectedButGotMessage, "advanced count", len(tt.wantClassic), count)
+			}
+			if proxies, ok := advanced.([]entity.AdvancedProxy); ok && len(proxies) > 0 && !tt.includeCredentials && proxies[0].Username != "" {
+				t.Errorf(expectedButGotMessage, "Username", "", proxies[0].Username)
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	stream, err := newTestQueryUsecase(false, true).Stream(entity.ProxyQuery{Category: testHTTPSCategory, Limit: 1})
+	if err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "Stream()", nil, err)
+	}
+
+	proxies, ok := stream.(iter.Seq[entity.Proxy])
+	if !ok {
+		t.Fatalf(expectedTypeAssertionErrorMessage, "iter.Seq[entity.Proxy]")
+	}
+
+	// The stream is lazy, so it can be consumed more than once with the same result
+	for i := 0; i < 2; i++ {
+		got := slices.Collect(proxies)
+		want := []entity.Proxy{{Category: testHTTPSCategory, Proxy: testProxy1, IP: testIP1, Port: testPort1, TimeTaken: 1, Anonymity: "elite"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf(expectedButGotMessage, "Stream()", want, got)
+		}
+	}
+
+	if _, err := newTestQueryUsecase(false, true).Stream(entity.ProxyQuery{Category: "FTP"}); err == nil || err.Error() != "proxy category not found: FTP" {
+		t.Errorf(expectedErrorButGotMessage, "Stream()", "proxy category not found: FTP", err)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	uc := newTestQueryUsecase(false, false)
+
+	classic, advanced, err := uc.Random(entity.ProxyQuery{Category: testHTTPSCategory, Limit: 5})
+	if err != nil {
+		t.Fatalf(unexpectedMessage, "error", err)
+	}
+	if !reflect.DeepEqual(classic, []string{testProxy1}) {
+		t.Errorf(expectedButGotMessage, "classic", []string{testProxy1}, classic)
+	}
+	if proxies := advanced.([]entity.Proxy); len(proxies) != 1 || proxies[0].Proxy != testProxy1 {
+		t.Errorf(expectedButGotMessage, "advanced", testProxy1, proxies)
+	}
+
+	if _, _, err := uc.Random(entity.ProxyQuery{MaxLatency: 0.5}); !errors.Is(err, ErrProxyNotFound) {
+		t.Errorf(expectedErrorButGotMessage, "Random()", ErrProxyNotFound, err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	uc := newTestQueryUsecase(false, false)
+
+	want := entity.ProxyStats{
+		Total:          2,
+		Categories:     map[string]int{testHTTPCategory: 1, testHTTPSCategory: 2, testSOCKS4Category: 0, testSOCKS5Category: 0},
+		Anonymity:      map[string]int{"elite": 1, "transparent": 1},
+		Rotating:       1,
+		AverageLatency: 2,
+		LastCheckedAt:  "2024-01-01T20:00:00Z",
+	}
+	if got := uc.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "Stats()", want, got)
+	}
+}
+
+func TestQueryEncode(t *testing.T) {
+	var got []interface{}
+	uc := &QueryUsecase{
+		FileRepository: &mockFileRepository{
+			EncodeFunc: func(writer io.Writer, data interface{}, format string) error {
+				got = append(got, data)
+				return nil
+			},
+		},
+	}
+
+	classic := []string{testProxy1}
+	advanced := []entity.Proxy{testProxyEntity1}
+	uc.Encode(&bytes.Buffer{}, classic, advanced, "txt")
+	uc.Encode(&bytes.Buffer{}, classic, advanced, "json")
+
+	want := []interface{}{classic, advanced}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "encoded data", want, got)
+	}
+}
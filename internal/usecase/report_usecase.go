@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+	"github.com/fyvri/fresh-proxy-list/internal/infrastructure/repository"
+)
+
+const readmeTimeLayout = "Monday, January 2, 2006 at 15:04:05 (GMT-07:00)"
+
+type ReportUsecase struct {
+	ProxyRepository    repository.ProxyRepositoryInterface
+	TemplateRepository repository.TemplateRepositoryInterface
+	GeoIPRepository    repository.GeoIPRepositoryInterface
+	Categories         []string
+	AnonymityLevels    []string
+	SampleSize         int
+	IncludeMITM        bool
+}
+
+type ReportUsecaseInterface interface {
+	BuildReport(sources []entity.SourceYield, startTime time.Time) entity.Report
+	Render(outputPath string, report entity.Report) error
+}
+
+func NewReportUsecase(
+	proxyRepository repository.ProxyRepositoryInterface,
+	templateRepository repository.TemplateRepositoryInterface,
+	geoIPRepository repository.GeoIPRepositoryInterface,
+	categories []string,
+	anonymityLevels []string,
+	sampleSize int,
+	includeMITM bool,
+) ReportUsecaseInterface {
+	return &ReportUsecase{
+		ProxyRepository:    proxyRepository,
+		TemplateRepository: templateRepository,
+		GeoIPRepository:    geoIPRepository,
+		Categories:         categories,
+		AnonymityLevels:    anonymityLevels,
+		SampleSize:         sampleSize,
+		IncludeMITM:        includeMITM,
+	}
+}
+
+func (uc *ReportUsecase) BuildReport(sources []entity.SourceYield, startTime time.Time) entity.Report {
+	report := entity.Report{
+		UpdatedAt:  time.Now(),
+		Duration:   time.Since(startTime),
+		Categories: map[string]int{},
+		Anonymity:  map[string]int{},
+		Proxies:    map[string][]string{},
+		Sources:    sources,
+		Countries:  []entity.CountryCount{},
+	}
+
+	for _, category := range uc.Categories {
+		var proxies []entity.Proxy
+		for _, proxy := range uc.ProxyRepository.GetAdvancedView(category) {
+			if category != "HTTPS" || !proxy.IsMITM || uc.IncludeMITM {
+				proxies = append(proxies, proxy)
+			}
+		}
+		report.Categories[category] = len(proxies)
+
+		// The fastest proxies are listed, without their credentials
+		slices.SortStableFunc(proxies, func(a, b entity.Proxy) int {
+			return cmp.Compare(a.TimeTaken, b.TimeTaken)
+		})
+		samples := []string{}
+		for _, proxy := range proxies[:min(len(proxies), uc.SampleSize)] {
+			samples = append(samples, proxy.Proxy)
+		}
+		report.Proxies[category] = samples
+	}
+	for _, anonymity := range uc.AnonymityLevels {
+		report.Anonymity[anonymity] = len(uc.ProxyRepository.GetAnonymityClassicView(anonymity))
+	}
+	report.Rotating = len(uc.ProxyRepository.GetRotatingClassicView())
+
+	var (
+		latencies []float64
+		countries = map[string]int{}
+	)
+	for _, proxy := range uc.ProxyRepository.GetAllAdvancedView() {
+		report.Total++
+		latencies = append(latencies, proxy.TimeTaken)
+		if uc.GeoIPRepository != nil {
+			if country := uc.GeoIPRepository.Country(proxy.IP); country != "" {
+				countries[country]++
+			}
+		}
+	}
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		total := 0.0
+		for _, latency := range latencies {
+			total += latency
+		}
+		report.AverageLatency = total / float64(len(latencies))
+		if middle := len(latencies) / 2; len(latencies)%2 == 0 {
+			report.MedianLatency = (latencies[middle-1] + latencies[middle]) / 2
+		} else {
+			report.MedianLatency = latencies[middle]
+		}
+	}
+
+	for country, count := range countries {
+		report.Countries = append(report.Countries, entity.CountryCount{Country: country, Count: count})
+	}
+	slices.SortFunc(report.Countries, func(a, b entity.CountryCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Country, b.Country))
+	})
+
+	return report
+}
+
+func (uc *ReportUsecase) Render(outputPath string, report entity.Report) error {
+	funcs := template.FuncMap{
+		"join": strings.Join,
+		"top": func(n int, countries []entity.CountryCount) []entity.CountryCount {
+			return countries[:min(max(n, 0), len(countries))]
+		},
+		// Placeholders of templates written for the former sed based rendering
+		"UPDATED_AT": func() string {
+			return report.UpdatedAt.Format(readmeTimeLayout)
+		},
+	}
+	for category := range report.Categories {
+		funcs[category+"_PROXY_COUNT"] = func() int {
+			return report.Categories[category]
+		}
+		funcs[category+"_PROXIES"] = func() string {
+			return strings.Join(report.Proxies[category], "\n")
+		}
+	}
+
+	return uc.TemplateRepository.Render(outputPath, report, funcs)
+}
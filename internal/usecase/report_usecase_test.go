@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/fyvri/fresh-proxy-list/internal/entity"
+)
+
+func TestNewReportUsecase(t *testing.T) {
+	reportUsecase := NewReportUsecase(&mockProxyRepository{}, &mockTemplateRepository{}, nil, testProxyCategories, nil, 10, false)
+	if reportUsecase == nil {
+		t.Errorf(expectedReturnNonNil, "NewReportUsecase", "ReportUsecaseInterface")
+	}
+
+	uc, ok := reportUsecase.(*ReportUsecase)
+	if !ok {
+		t.Errorf(expectedTypeAssertionErrorMessage, "*ReportUsecase")
+	}
+
+	if uc.SampleSize != 10 {
+		t.Errorf(expectedButGotMessage, "SampleSize", 10, uc.SampleSize)
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	mockProxyRepository := &mockProxyRepository{
+		GetAdvancedViewFunc: func(category string) []entity.Proxy {
+			if category == testHTTPCategory {
+				return []entity.Proxy{
+					{Proxy: testProxy1, TimeTaken: 3},
+					{Proxy: testProxy2, TimeTaken: 1},
+					{Proxy: testProxy3, TimeTaken: 2},
+				}
+			}
+			return []entity.Proxy{
+				{Proxy: testProxy1, TimeTaken: 1, IsMITM: true},
+				{Proxy: testProxy2, TimeTaken: 2},
+			}
+		},
+		GetAllAdvancedViewFunc: func() []entity.AdvancedProxy {
+			return []entity.AdvancedProxy{
+				{Proxy: testProxy1, IP: testIP1, TimeTaken: 3},
+				{Proxy: testProxy2, IP: testIP2, TimeTaken: 1},
+				{Proxy: testProxy3, IP: testIP3, TimeTaken: 2},
+				{Proxy: testProxy4, IP: testIP4, TimeTaken: 6},
+			}
+		},
+		GetAnonymityClassicViewFunc: func(anonymity string) []string {
+			return []string{testProxy1}
+		},
+		GetRotatingClassicViewFunc: func() []string {
+			return []string{testProxy1, testProxy2}
+		},
+	}
+	mockGeoIPRepository := &mockGeoIPRepository{
+		Countries: map[string]string{testIP1: "US", testIP2: "ID", testIP3: "ID", testIP4: "SG"},
+	}
+	sources := []entity.SourceYield{{Method: testListMethod, Category: testHTTPCategory, URL: testURL, Collected: 4, Working: 3}}
+
+	uc := NewReportUsecase(mockProxyRepository, &mockTemplateRepository{}, mockGeoIPRepository, []string{testHTTPCategory, "HTTPS"}, []string{"elite"}, 2, false)
+	got := uc.BuildReport(sources, time.Now().Add(-time.Minute))
+
+	if got.Total != 4 || got.Rotating != 2 || got.Anonymity["elite"] != 1 {
+		t.Errorf(expectedButGotMessage, "counts", "4 2 1", []int{got.Total, got.Rotating, got.Anonymity["elite"]})
+	}
+	if want := map[string]int{testHTTPCategory: 3, "HTTPS": 1}; !reflect.DeepEqual(got.Categories, want) {
+		t.Errorf(expectedButGotMessage, "Categories", want, got.Categories)
+	}
+	if want := map[string][]string{testHTTPCategory: {testProxy2, testProxy3}, "HTTPS": {testProxy2}}; !reflect.DeepEqual(got.Proxies, want) {
+		t.Errorf(expectedButGotMessage, "Proxies", want, got.Proxies)
+	}
+	if got.AverageLatency != 3 || got.MedianLatency != 2.5 {
+		t.Errorf(expectedButGotMessage, "latencies", "3 2.5", []float64{got.AverageLatency, got.MedianLatency})
+	}
+	if want := []entity.CountryCount{{Country: "ID", Count: 2}, {Country: "SG", Count: 1}, {Country: "US", Count: 1}}; !reflect.DeepEqual(got.Countries, want) {
+		t.Errorf(expectedButGotMessage, "Countries", want, got.Countries)
+	}
+	if !reflect.DeepEqual(got.Sources, sources) || got.Sources[0].Yield() != 75 {
+		t.Errorf(expectedButGotMessage, "Sources", sources, got.Sources)
+	}
+	if got.Duration < time.Minute {
+		t.Errorf(unexpectedMessage, "Duration", got.Duration)
+	}
+}
+
+func TestRenderReport(t *testing.T) {
+	report := entity.Report{
+		UpdatedAt:  time.Date(2026, 8, 23, 0, 21, 28, 0, time.FixedZone("WIB", 7*60*60)),
+		Categories: map[string]int{testHTTPCategory: 2},
+		Proxies:    map[string][]string{testHTTPCategory: {testProxy1, testProxy2}},
+		Countries:  []entity.CountryCount{{Country: "ID", Count: 2}, {Country: "US", Count: 1}},
+	}
+
+	var got strings.Builder
+	mockTemplateRepository := &mockTemplateRepository{
+		RenderFunc: func(outputPath string, data interface{}, funcs template.FuncMap) error {
+			tmpl := template.Must(template.New(outputPath).Funcs(funcs).Parse(
+				`{{UPDATED_AT}}|{{HTTP_PROXY_COUNT}}|{{HTTP_PROXIES}}|{{join .Proxies.HTTP ","}}|{{range top 1 .Countries}}{{.Country}}{{end}}`,
+			))
+			return tmpl.Execute(&got, data)
+		},
+	}
+	uc := NewReportUsecase(&mockProxyRepository{}, mockTemplateRepository, nil, nil, nil, 10, false)
+	if err := uc.Render("README.md", report); err != nil {
+		t.Fatalf(expectedErrorButGotMessage, "Render()", nil, err)
+	}
+
+	want := "Sunday, August 23, 2026 at 00:21:28 (GMT+07:00)|2|" + testProxy1 + "\n" + testProxy2 + "|" + testProxy1 + "," + testProxy2 + "|ID"
+	if got.String() != want {
+		t.Errorf(expectedButGotMessage, "Render()", want, got.String())
+	}
+
+	mockTemplateRepository.RenderFunc = func(outputPath string, data interface{}, funcs template.FuncMap) error {
+		return errors.New("error writing README.md")
+	}
+	if err := uc.Render("README.md", report); err == nil {
+		t.Errorf(expectedErrorButGotMessage, "Render()", "error writing README.md", err)
+	}
+}
@@ -1,29 +1,44 @@
 package usecase
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"net/url"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/fyvri/fresh-proxy-list/internal/entity"
 	"github.com/fyvri/fresh-proxy-list/internal/infrastructure/repository"
 	"github.com/fyvri/fresh-proxy-list/pkg/utils"
 )
 
+// Longest line or word read from a source, longer ones fail the source instead of growing the buffer
+const maxSourceTokenSize = 1024 * 1024
+
+var scrapPattern = regexp.MustCompile(`(?:[^\s:@/<>"']+:[^\s@/<>"']+@)?(?:[0-9]+(?:\.[0-9]+){3}:[0-9]+|\[[0-9A-Fa-f:.]+\]:[0-9]+)`)
+
 type SourceUsecase struct {
-	SourceRepository repository.SourceRepositoryInterface
-	FetcherUtil      utils.FetcherUtilInterface
+	SourceRepository      repository.SourceRepositoryInterface
+	SourceStateRepository repository.SourceStateRepositoryInterface
+	FetcherUtil           utils.FetcherUtilInterface
 }
 
 type SourceUsecaseInterface interface {
 	LoadSources() ([]entity.Source, error)
-	ProcessSource(source *entity.Source) ([]string, error)
+	ProcessSource(ctx context.Context, source *entity.Source, emit func(proxy string) bool) error
+	ValidateSource(source *entity.Source, proxyCategories []string) error
+	SelectDueSources(sources []entity.Source, refreshInterval time.Duration) []entity.Source
+	SaveState() error
 }
 
-func NewSourceUsecase(sourceRepository repository.SourceRepositoryInterface, fetcherUtil utils.FetcherUtilInterface) SourceUsecaseInterface {
+func NewSourceUsecase(sourceRepository repository.SourceRepositoryInterface, sourceStateRepository repository.SourceStateRepositoryInterface, fetcherUtil utils.FetcherUtilInterface) SourceUsecaseInterface {
 	return &SourceUsecase{
-		SourceRepository: sourceRepository,
-		FetcherUtil:      fetcherUtil,
+		SourceRepository:      sourceRepository,
+		SourceStateRepository: sourceStateRepository,
+		FetcherUtil:           fetcherUtil,
 	}
 }
 
@@ -31,22 +46,81 @@ func (uc *SourceUsecase) LoadSources() ([]entity.Source, error) {
 	return uc.SourceRepository.LoadSources()
 }
 
-func (uc *SourceUsecase) ProcessSource(source *entity.Source) ([]string, error) {
-	body, err := uc.FetcherUtil.FetchData(source.URL)
+// ProcessSource streams the proxies of a source into emit as the body is read, until emit returns false
+func (uc *SourceUsecase) ProcessSource(ctx context.Context, source *entity.Source, emit func(proxy string) bool) error {
+	if source.Method != "LIST" && source.Method != "SCRAP" {
+		return fmt.Errorf("source method not found: %s", source.Method)
+	}
+
+	body, err := uc.FetcherUtil.FetchStream(ctx, source.URL)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSourceTokenSize)
+	if source.Method == "SCRAP" {
+		// A proxy never contains whitespace, so the page is matched one word at a time
+		scanner.Split(bufio.ScanWords)
 	}
+	for scanner.Scan() {
+		if source.Method == "LIST" {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !emit(line) {
+				return nil
+			}
+			continue
+		}
+		for _, proxy := range scrapPattern.FindAllString(scanner.Text(), -1) {
+			if !emit(proxy) {
+				return nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading source %s: %v", source.URL, err)
+	}
+
+	if uc.SourceStateRepository != nil {
+		uc.SourceStateRepository.SetFetchedAt(source, time.Now())
+	}
+	return nil
+}
 
-	var proxies []string
-	switch source.Method {
-	case "LIST":
-		proxies = strings.Split(strings.TrimSpace(string(body)), "\n")
-	case "SCRAP":
-		re := regexp.MustCompile(`[0-9]+(?:\.[0-9]+){3}:[0-9]+`)
-		proxies = re.FindAllString(string(body), -1)
-	default:
-		return nil, fmt.Errorf("source method not found: %s", source.Method)
+func (uc *SourceUsecase) ValidateSource(source *entity.Source, proxyCategories []string) error {
+	if source.Method != "LIST" && source.Method != "SCRAP" {
+		return fmt.Errorf("source method not found: %s", source.Method)
 	}
 
-	return proxies, nil
+	if !slices.Contains(proxyCategories, source.Category) {
+		return fmt.Errorf("proxy category not found: %s", source.Category)
+	}
+
+	sourceURL, err := url.Parse(source.URL)
+	if err != nil || (sourceURL.Scheme != "http" && sourceURL.Scheme != "https") || sourceURL.Host == "" {
+		return fmt.Errorf("source url invalid: %s", source.URL)
+	}
+
+	return nil
+}
+
+func (uc *SourceUsecase) SelectDueSources(sources []entity.Source, refreshInterval time.Duration) []entity.Source {
+	if uc.SourceStateRepository == nil {
+		return sources
+	}
+
+	var due []entity.Source
+	for _, source := range sources {
+		if time.Since(uc.SourceStateRepository.GetFetchedAt(&source)) >= refreshInterval {
+			due = append(due, source)
+		}
+	}
+	return due
+}
+
+func (uc *SourceUsecase) SaveState() error {
+	if uc.SourceStateRepository == nil {
+		return nil
+	}
+	return uc.SourceStateRepository.Save()
 }
@@ -1,9 +1,12 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/fyvri/fresh-proxy-list/internal/entity"
 	"github.com/fyvri/fresh-proxy-list/internal/infrastructure/repository"
@@ -20,8 +23,9 @@ var (
 
 func TestNewSourceUsecase(t *testing.T) {
 	type fields struct {
-		sourceRepository repository.SourceRepositoryInterface
-		fetcherUtil      utils.FetcherUtilInterface
+		sourceRepository      repository.SourceRepositoryInterface
+		sourceStateRepository repository.SourceStateRepositoryInterface
+		fetcherUtil           utils.FetcherUtilInterface
 	}
 
 	tests := []struct {
@@ -32,19 +36,21 @@ func TestNewSourceUsecase(t *testing.T) {
 		{
 			name: "Success",
 			fields: fields{
-				sourceRepository: &mockSourceRepository{},
-				fetcherUtil:      &mockFetcherUtil{},
+				sourceRepository:      &mockSourceRepository{},
+				sourceStateRepository: &mockSourceStateRepository{},
+				fetcherUtil:           &mockFetcherUtil{},
 			},
 			want: &SourceUsecase{
-				SourceRepository: &mockSourceRepository{},
-				FetcherUtil:      &mockFetcherUtil{},
+				SourceRepository:      &mockSourceRepository{},
+				SourceStateRepository: &mockSourceStateRepository{},
+				FetcherUtil:           &mockFetcherUtil{},
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sourceUsecase := NewSourceUsecase(tt.fields.sourceRepository, tt.fields.fetcherUtil)
+			sourceUsecase := NewSourceUsecase(tt.fields.sourceRepository, tt.fields.sourceStateRepository, tt.fields.fetcherUtil)
 			if sourceUsecase == nil {
 				t.Errorf(expectedReturnNonNil, "NewSourceUsecase", "SourceUsecaseInterface")
 			}
@@ -191,7 +197,7 @@ func TestProcessSource(t *testing.T) {
 			name: "TestFetcherWithScrapMethod",
 			fields: fields{
 				fetcherUtil: &mockFetcherUtil{
-					fetchDataByte: []byte(testProxy1 + "\n" + testProxy2 + "\n" + testProxy3 + "\n" + testProxy4),
+					fetchDataByte: []byte(testProxy1 + "\n" + testProxy2 + "\n" + testProxy3 + "\n" + testProxy4 + "\n<td>[" + testIPv6 + "]:" + testIPv6Port + "</td>\n<td>" + testUsername + ":" + testPassword + "@" + testProxy1 + "</td>"),
 				},
 			},
 			args: args{
@@ -207,6 +213,8 @@ func TestProcessSource(t *testing.T) {
 				testProxy2,
 				testProxy3,
 				testProxy4,
+				"[" + testIPv6 + "]:" + testIPv6Port,
+				testUsername + ":" + testPassword + "@" + testProxy1,
 			},
 			wantError: nil,
 		},
@@ -233,7 +241,11 @@ func TestProcessSource(t *testing.T) {
 			uc := &SourceUsecase{
 				FetcherUtil: tt.fields.fetcherUtil,
 			}
-			got, err := uc.ProcessSource(&tt.args.source)
+			var got []string
+			err := uc.ProcessSource(context.Background(), &tt.args.source, func(proxy string) bool {
+				got = append(got, proxy)
+				return true
+			})
 
 			if (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) ||
 				(err == nil && tt.wantError != nil) ||
@@ -247,3 +259,169 @@ func TestProcessSource(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessSourceStops(t *testing.T) {
+	var got []string
+	uc := &SourceUsecase{
+		FetcherUtil: &mockFetcherUtil{
+			fetchDataByte: []byte(testProxy1 + "\n\n" + testProxy2 + "\r\n" + testProxy3),
+		},
+	}
+	err := uc.ProcessSource(context.Background(), &entity.Source{Method: testListMethod, Category: testCategory, URL: testURL}, func(proxy string) bool {
+		got = append(got, proxy)
+		return len(got) < 2
+	})
+	if err != nil {
+		t.Errorf(expectedErrorButGotMessage, "SourceUsecase.ProcessSource()", nil, err)
+	}
+
+	want := []string{testProxy1, testProxy2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "SourceUsecase.ProcessSource()", want, got)
+	}
+
+	uc.FetcherUtil = &mockFetcherUtil{fetchDataByte: []byte(strings.Repeat("1", maxSourceTokenSize+1))}
+	wantError := errors.New("error reading source " + testURL + ": bufio.Scanner: token too long")
+	err = uc.ProcessSource(context.Background(), &entity.Source{Method: testScrapMethod, Category: testCategory, URL: testURL}, func(proxy string) bool {
+		return true
+	})
+	if err == nil || err.Error() != wantError.Error() {
+		t.Errorf(expectedErrorButGotMessage, "SourceUsecase.ProcessSource()", wantError, err)
+	}
+}
+
+func TestValidateSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    entity.Source
+		wantError error
+	}{
+		{
+			name: "Valid",
+			source: entity.Source{
+				Method:   testListMethod,
+				Category: testCategory,
+				URL:      testURL,
+			},
+			wantError: nil,
+		},
+		{
+			name: "MethodNotFound",
+			source: entity.Source{
+				Method:   "NO_METHOD",
+				Category: testCategory,
+				URL:      testURL,
+			},
+			wantError: errors.New("source method not found: NO_METHOD"),
+		},
+		{
+			name: "CategoryNotFound",
+			source: entity.Source{
+				Method:   testScrapMethod,
+				Category: "FTP",
+				URL:      testURL,
+			},
+			wantError: errors.New("proxy category not found: FTP"),
+		},
+		{
+			name: "URLInvalid",
+			source: entity.Source{
+				Method:   testScrapMethod,
+				Category: testCategory,
+				URL:      "ftp://example.com",
+			},
+			wantError: errors.New("source url invalid: ftp://example.com"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &SourceUsecase{}
+			err := uc.ValidateSource(&tt.source, testProxyCategories)
+
+			if (err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) ||
+				(err == nil && tt.wantError != nil) ||
+				(err != nil && tt.wantError == nil) {
+				t.Errorf(expectedErrorButGotMessage, "SourceUsecase.ValidateSource()", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestProcessSourceRecordsFetch(t *testing.T) {
+	var (
+		mockSourceStateRepository = &mockSourceStateRepository{}
+		source                    = entity.Source{Method: testListMethod, Category: testCategory, URL: testURL}
+		failingSource             = entity.Source{Method: testListMethod, Category: testCategory, URL: testURL + "/down"}
+	)
+	uc := &SourceUsecase{
+		SourceStateRepository: mockSourceStateRepository,
+		FetcherUtil:           &mockFetcherUtil{fetchDataByte: []byte(testProxy1)},
+	}
+	emit := func(proxy string) bool {
+		return true
+	}
+	uc.ProcessSource(context.Background(), &source, emit)
+
+	uc.FetcherUtil = &mockFetcherUtil{fetcherError: errors.New("error creating request")}
+	uc.ProcessSource(context.Background(), &failingSource, emit)
+
+	if got := mockSourceStateRepository.GetFetchedAt(&source); got.IsZero() {
+		t.Errorf(expectedButGotMessage, "GetFetchedAt()", "fetch time", got)
+	}
+
+	if got := mockSourceStateRepository.GetFetchedAt(&failingSource); !got.IsZero() {
+		t.Errorf(expectedButGotMessage, "GetFetchedAt()", time.Time{}, got)
+	}
+
+	// A source whose candidates were not all emitted is fetched again
+	stoppedSource := entity.Source{Method: testListMethod, Category: testCategory, URL: testURL + "/stopped"}
+	uc.FetcherUtil = &mockFetcherUtil{fetchDataByte: []byte(testProxy1 + "\n" + testProxy2)}
+	uc.ProcessSource(context.Background(), &stoppedSource, func(proxy string) bool {
+		return false
+	})
+	if got := mockSourceStateRepository.GetFetchedAt(&stoppedSource); !got.IsZero() {
+		t.Errorf(expectedButGotMessage, "GetFetchedAt()", time.Time{}, got)
+	}
+}
+
+func TestSelectDueSources(t *testing.T) {
+	sources := []entity.Source{
+		{Method: "LIST", Category: testCategory, URL: testURL + "/fresh"},
+		{Method: "LIST", Category: testCategory, URL: testURL + "/stale"},
+		{Method: "LIST", Category: testCategory, URL: testURL + "/new"},
+	}
+	mockSourceStateRepository := &mockSourceStateRepository{}
+	mockSourceStateRepository.SetFetchedAt(&sources[0], time.Now().Add(-time.Hour))
+	mockSourceStateRepository.SetFetchedAt(&sources[1], time.Now().Add(-7*time.Hour))
+
+	uc := &SourceUsecase{SourceStateRepository: mockSourceStateRepository}
+	want := sources[1:]
+	if got := uc.SelectDueSources(sources, 6*time.Hour); !reflect.DeepEqual(got, want) {
+		t.Errorf(expectedButGotMessage, "SelectDueSources()", want, got)
+	}
+
+	uc = &SourceUsecase{}
+	if got := uc.SelectDueSources(sources, 6*time.Hour); !reflect.DeepEqual(got, sources) {
+		t.Errorf(expectedButGotMessage, "SelectDueSources()", sources, got)
+	}
+}
+
+func TestSaveState(t *testing.T) {
+	wantError := errors.New("disk full")
+	uc := &SourceUsecase{
+		SourceStateRepository: &mockSourceStateRepository{
+			SaveFunc: func() error {
+				return wantError
+			},
+		},
+	}
+	if err := uc.SaveState(); err != wantError {
+		t.Errorf(expectedErrorButGotMessage, "SaveState()", wantError, err)
+	}
+
+	uc = &SourceUsecase{}
+	if err := uc.SaveState(); err != nil {
+		t.Errorf(expectedErrorButGotMessage, "SaveState()", nil, err)
+	}
+}
@@ -1,11 +1,15 @@
 package usecase
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/fyvri/fresh-proxy-list/internal/entity"
@@ -30,6 +34,7 @@ var (
 	testHTTPSCategory                 = "HTTPS"
 	testSOCKS4Category                = "SOCKS4"
 	testSOCKS5Category                = "SOCKS5"
+	testProxyCategories               = []string{testHTTPCategory, testHTTPSCategory, testSOCKS4Category, testSOCKS5Category}
 	testSpecialIPs                    = []string{"1.1.1.1", "2.2.2.2"}
 	testPrivateIPs                    = []net.IPNet{
 		{
@@ -44,7 +49,15 @@ var (
 			IP:   net.IP{5, 5, 5, 5},
 			Mask: net.CIDRMask(16, 32),
 		},
+		{
+			IP:   net.ParseIP("fc00::"),
+			Mask: net.CIDRMask(7, 128),
+		},
 	}
+	testIPv6     = "2606:4700::1111"
+	testIPv6Port = "1337"
+	testUsername = "user"
+	testPassword = "pass"
 
 	testIP1          = "13.37.0.1"
 	testPort1        = "1337"
@@ -140,19 +153,30 @@ var (
 )
 
 type mockFetcherUtil struct {
-	fetchDataByte  []byte
-	fetcherError   error
-	NewRequestFunc func(method, url string, body io.Reader) (*http.Request, error)
-	DoFunc         func(client *http.Client, req *http.Request) (*http.Response, error)
+	fetchDataByte   []byte
+	fetcherError    error
+	FetchStreamFunc func(ctx context.Context, url string) (io.ReadCloser, error)
+	NewRequestFunc  func(ctx context.Context, method, url string, body io.Reader) (*http.Request, error)
+	DoFunc          func(client *http.Client, req *http.Request) (*http.Response, error)
 }
 
-func (m *mockFetcherUtil) FetchData(url string) ([]byte, error) {
+func (m *mockFetcherUtil) FetchData(ctx context.Context, url string) ([]byte, error) {
 	if m.fetcherError != nil {
 		return nil, m.fetcherError
 	}
 	return m.fetchDataByte, nil
 }
 
+func (m *mockFetcherUtil) FetchStream(ctx context.Context, url string) (io.ReadCloser, error) {
+	if m.FetchStreamFunc != nil {
+		return m.FetchStreamFunc(ctx, url)
+	}
+	if m.fetcherError != nil {
+		return nil, m.fetcherError
+	}
+	return io.NopCloser(bytes.NewReader(m.fetchDataByte)), nil
+}
+
 func (m *mockFetcherUtil) Do(client *http.Client, req *http.Request) (*http.Response, error) {
 	if m.DoFunc != nil {
 		return m.DoFunc(client, req)
@@ -160,31 +184,38 @@ func (m *mockFetcherUtil) Do(client *http.Client, req *http.Request) (*http.Resp
 	return httptest.NewRecorder().Result(), nil
 }
 
-func (m *mockFetcherUtil) NewRequest(method string, url string, body io.Reader) (*http.Request, error) {
+func (m *mockFetcherUtil) NewRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
 	if m.NewRequestFunc != nil {
-		return m.NewRequestFunc(method, url, body)
+		return m.NewRequestFunc(ctx, method, url, body)
 	}
-	return http.NewRequest(method, url, body)
+	return http.NewRequestWithContext(ctx, method, url, body)
 }
 
 type mockProxyService struct {
-	CheckFunc              func(category string, ip string, port string) (*entity.Proxy, error)
-	GetTestingSiteFunc     func(category string) string
+	CheckFunc              func(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error)
+	GetTestingSiteFunc     func(category string) entity.TestingSite
 	GetRandomUserAgentFunc func() string
+	GetRealIPFunc          func(ctx context.Context) string
+	GetAnonymityFunc       func(judgeResponse *entity.JudgeResponse, realIP string) string
+	GetExitIPFunc          func(body []byte) string
+	ValidateResponseFunc   func(testingSite *entity.TestingSite, header http.Header, body []byte) error
+	IsInterceptedFunc      func(testingSite *entity.TestingSite, state *tls.ConnectionState) bool
+	ProbeConnectFunc       func(ctx context.Context, category string, proxy string, username string, password string) []int
+	DialFunc               func(ctx context.Context, category string, proxy string, username string, password string, addr string) (net.Conn, error)
 }
 
-func (m *mockProxyService) Check(category string, ip string, port string) (*entity.Proxy, error) {
+func (m *mockProxyService) Check(ctx context.Context, category string, ip string, port string, username string, password string) (*entity.Proxy, error) {
 	if m.CheckFunc != nil {
-		return m.CheckFunc(category, ip, port)
+		return m.CheckFunc(ctx, category, ip, port, username, password)
 	}
 	return nil, nil
 }
 
-func (m *mockProxyService) GetTestingSite(category string) string {
+func (m *mockProxyService) GetTestingSite(category string) entity.TestingSite {
 	if m.GetTestingSiteFunc != nil {
 		return m.GetTestingSiteFunc(category)
 	}
-	return ""
+	return entity.TestingSite{}
 }
 
 func (m *mockProxyService) GetRandomUserAgent() string {
@@ -194,6 +225,55 @@ func (m *mockProxyService) GetRandomUserAgent() string {
 	return ""
 }
 
+func (m *mockProxyService) GetRealIP(ctx context.Context) string {
+	if m.GetRealIPFunc != nil {
+		return m.GetRealIPFunc(ctx)
+	}
+	return ""
+}
+
+func (m *mockProxyService) GetAnonymity(judgeResponse *entity.JudgeResponse, realIP string) string {
+	if m.GetAnonymityFunc != nil {
+		return m.GetAnonymityFunc(judgeResponse, realIP)
+	}
+	return ""
+}
+
+func (m *mockProxyService) GetExitIP(body []byte) string {
+	if m.GetExitIPFunc != nil {
+		return m.GetExitIPFunc(body)
+	}
+	return ""
+}
+
+func (m *mockProxyService) ValidateResponse(testingSite *entity.TestingSite, header http.Header, body []byte) error {
+	if m.ValidateResponseFunc != nil {
+		return m.ValidateResponseFunc(testingSite, header, body)
+	}
+	return nil
+}
+
+func (m *mockProxyService) IsIntercepted(testingSite *entity.TestingSite, state *tls.ConnectionState) bool {
+	if m.IsInterceptedFunc != nil {
+		return m.IsInterceptedFunc(testingSite, state)
+	}
+	return false
+}
+
+func (m *mockProxyService) ProbeConnect(ctx context.Context, category string, proxy string, username string, password string) []int {
+	if m.ProbeConnectFunc != nil {
+		return m.ProbeConnectFunc(ctx, category, proxy, username, password)
+	}
+	return nil
+}
+
+func (m *mockProxyService) Dial(ctx context.Context, category string, proxy string, username string, password string, addr string) (net.Conn, error) {
+	if m.DialFunc != nil {
+		return m.DialFunc(ctx, category, proxy, username, password, addr)
+	}
+	return nil, nil
+}
+
 type mockSourceRepository struct {
 	LoadSourcesFunc func() ([]entity.Source, error)
 }
@@ -202,15 +282,81 @@ func (m *mockSourceRepository) LoadSources() ([]entity.Source, error) {
 	return m.LoadSourcesFunc()
 }
 
+type mockSourceStateRepository struct {
+	SaveFunc func() error
+
+	FetchedAt map[string]time.Time
+	Mutex     sync.Mutex
+}
+
+func (m *mockSourceStateRepository) Load() error {
+	return nil
+}
+
+func (m *mockSourceStateRepository) GetFetchedAt(source *entity.Source) time.Time {
+	m.Mutex.Lock()
+	defer m.Mutex.Unlock()
+	return m.FetchedAt[source.URL]
+}
+
+func (m *mockSourceStateRepository) SetFetchedAt(source *entity.Source, fetchedAt time.Time) {
+	m.Mutex.Lock()
+	defer m.Mutex.Unlock()
+	if m.FetchedAt == nil {
+		m.FetchedAt = map[string]time.Time{}
+	}
+	m.FetchedAt[source.URL] = fetchedAt
+}
+
+func (m *mockSourceStateRepository) Save() error {
+	if m.SaveFunc != nil {
+		return m.SaveFunc()
+	}
+	return nil
+}
+
+type mockCredentialRepository struct {
+	SaveFunc func(proxies []entity.AdvancedProxy) error
+
+	Credentials map[string]entity.Credential
+}
+
+func (m *mockCredentialRepository) Load() error {
+	return nil
+}
+
+func (m *mockCredentialRepository) Restore(proxy *entity.AdvancedProxy) {
+	if credential, found := m.Credentials[proxy.Proxy]; found && proxy.Username == "" {
+		proxy.Username, proxy.Password = credential.Username, credential.Password
+	}
+}
+
+func (m *mockCredentialRepository) Save(proxies []entity.AdvancedProxy) error {
+	if m.SaveFunc != nil {
+		return m.SaveFunc(proxies)
+	}
+	return nil
+}
+
 type mockFileRepository struct {
-	SaveFileFunc        func(filename string, data interface{}, format string) error
-	CreateDirectoryFunc func(filePath string) error
-	WriteTxtFunc        func(writer io.Writer, data interface{}) error
-	EncodeCSVFunc       func(writer io.Writer, data interface{}) error
-	WriteCSVFunc        func(writer io.Writer, header []string, rows [][]string) error
-	EncodeJSONFunc      func(writer io.Writer, data interface{}) error
-	EncodeXMLFunc       func(writer io.Writer, data interface{}) error
-	EncodeYAMLFunc      func(writer io.Writer, data interface{}) error
+	SaveFileFunc          func(filename string, data interface{}, format string) error
+	LoadFileFunc          func(filename string, data interface{}, format string) error
+	CreateDirectoryFunc   func(filePath string) error
+	ChecksumFunc          func(filePath string) (int64, string, error)
+	EncodeFunc            func(writer io.Writer, data interface{}, format string) error
+	WriteTxtFunc          func(writer io.Writer, data interface{}) error
+	EncodeCSVFunc         func(writer io.Writer, data interface{}) error
+	WriteCSVFunc          func(writer io.Writer, header []string, rows [][]string) error
+	EncodeJSONFunc        func(writer io.Writer, data interface{}) error
+	EncodeJSONLFunc       func(writer io.Writer, data interface{}) error
+	EncodeXMLFunc         func(writer io.Writer, data interface{}) error
+	EncodeYAMLFunc        func(writer io.Writer, data interface{}) error
+	EncodePACFunc         func(writer io.Writer, data interface{}) error
+	EncodeClashFunc       func(writer io.Writer, data interface{}) error
+	EncodeProxychainsFunc func(writer io.Writer, data interface{}) error
+	EncodeSingBoxFunc     func(writer io.Writer, data interface{}) error
+	EncodeGzipFunc        func(writer io.Writer, data interface{}, format string) error
+	EncodeZstdFunc        func(writer io.Writer, data interface{}, format string) error
 }
 
 func (m *mockFileRepository) SaveFile(filename string, data interface{}, ext string) error {
@@ -220,6 +366,27 @@ func (m *mockFileRepository) SaveFile(filename string, data interface{}, ext str
 	return nil
 }
 
+func (m *mockFileRepository) Checksum(filePath string) (int64, string, error) {
+	if m.ChecksumFunc != nil {
+		return m.ChecksumFunc(filePath)
+	}
+	return 0, "", nil
+}
+
+func (m *mockFileRepository) LoadFile(filename string, data interface{}, ext string) error {
+	if m.LoadFileFunc != nil {
+		return m.LoadFileFunc(filename, data, ext)
+	}
+	return nil
+}
+
+func (m *mockFileRepository) Encode(writer io.Writer, data interface{}, format string) error {
+	if m.EncodeFunc != nil {
+		return m.EncodeFunc(writer, data, format)
+	}
+	return nil
+}
+
 func (m *mockFileRepository) CreateDirectory(filePath string) error {
 	if m.CreateDirectoryFunc != nil {
 		return m.CreateDirectoryFunc(filePath)
@@ -255,6 +422,13 @@ func (m *mockFileRepository) EncodeJSON(writer io.Writer, data interface{}) erro
 	return nil
 }
 
+func (m *mockFileRepository) EncodeJSONL(writer io.Writer, data interface{}) error {
+	if m.EncodeJSONLFunc != nil {
+		return m.EncodeJSONLFunc(writer, data)
+	}
+	return nil
+}
+
 func (m *mockFileRepository) EncodeXML(writer io.Writer, data interface{}) error {
 	if m.EncodeXMLFunc != nil {
 		return m.EncodeXMLFunc(writer, data)
@@ -269,18 +443,59 @@ func (m *mockFileRepository) EncodeYAML(writer io.Writer, data interface{}) erro
 	return nil
 }
 
+func (m *mockFileRepository) EncodePAC(writer io.Writer, data interface{}) error {
+	if m.EncodePACFunc != nil {
+		return m.EncodePACFunc(writer, data)
+	}
+	return nil
+}
+
+func (m *mockFileRepository) EncodeClash(writer io.Writer, data interface{}) error {
+	if m.EncodeClashFunc != nil {
+		return m.EncodeClashFunc(writer, data)
+	}
+	return nil
+}
+
+func (m *mockFileRepository) EncodeProxychains(writer io.Writer, data interface{}) error {
+	if m.EncodeProxychainsFunc != nil {
+		return m.EncodeProxychainsFunc(writer, data)
+	}
+	return nil
+}
+
+func (m *mockFileRepository) EncodeSingBox(writer io.Writer, data interface{}) error {
+	if m.EncodeSingBoxFunc != nil {
+		return m.EncodeSingBoxFunc(writer, data)
+	}
+	return nil
+}
+
+func (m *mockFileRepository) EncodeGzip(writer io.Writer, data interface{}, format string) error {
+	if m.EncodeGzipFunc != nil {
+		return m.EncodeGzipFunc(writer, data, format)
+	}
+	return nil
+}
+
+func (m *mockFileRepository) EncodeZstd(writer io.Writer, data interface{}, format string) error {
+	if m.EncodeZstdFunc != nil {
+		return m.EncodeZstdFunc(writer, data, format)
+	}
+	return nil
+}
+
 type mockProxyRepository struct {
-	StoreFunc                 func(proxy *entity.Proxy)
-	GetAllClassicViewFunc     func() []string
-	GetHTTPClassicViewFunc    func() []string
-	GetHTTPSClassicViewFunc   func() []string
-	GetSOCKS4ClassicViewFunc  func() []string
-	GetSOCKS5ClassicViewFunc  func() []string
-	GetAllAdvancedViewFunc    func() []entity.AdvancedProxy
-	GetHTTPAdvancedViewFunc   func() []entity.Proxy
-	GetHTTPSAdvancedViewFunc  func() []entity.Proxy
-	GetSOCKS4AdvancedViewFunc func() []entity.Proxy
-	GetSOCKS5AdvancedViewFunc func() []entity.Proxy
+	StoreFunc                    func(proxy *entity.Proxy)
+	GetAllClassicViewFunc        func() []string
+	GetAllAdvancedViewFunc       func() []entity.AdvancedProxy
+	GetClassicViewFunc           func(category string) []string
+	GetAdvancedViewFunc          func(category string) []entity.Proxy
+	GetAnonymityClassicViewFunc  func(anonymity string) []string
+	GetAnonymityAdvancedViewFunc func(anonymity string) []entity.AdvancedProxy
+	GetRotatingClassicViewFunc   func() []string
+	GetRotatingAdvancedViewFunc  func() []entity.AdvancedProxy
+	SetHistoryFunc               func(proxy string, history *entity.ProxyHistory)
 
 	StoredProxies []entity.Proxy
 	Mutex         sync.Mutex
@@ -303,65 +518,116 @@ func (m *mockProxyRepository) GetAllClassicView() []string {
 	return nil
 }
 
-func (m *mockProxyRepository) GetHTTPClassicView() []string {
-	if m.GetHTTPClassicViewFunc != nil {
-		return m.GetHTTPClassicViewFunc()
+func (m *mockProxyRepository) GetAllAdvancedView() []entity.AdvancedProxy {
+	if m.GetAllAdvancedViewFunc != nil {
+		return m.GetAllAdvancedViewFunc()
 	}
 	return nil
 }
 
-func (m *mockProxyRepository) GetHTTPSClassicView() []string {
-	if m.GetHTTPSClassicViewFunc != nil {
-		return m.GetHTTPSClassicViewFunc()
+func (m *mockProxyRepository) GetClassicView(category string) []string {
+	if m.GetClassicViewFunc != nil {
+		return m.GetClassicViewFunc(category)
 	}
 	return nil
 }
 
-func (m *mockProxyRepository) GetSOCKS4ClassicView() []string {
-	if m.GetSOCKS4ClassicViewFunc != nil {
-		return m.GetSOCKS4ClassicViewFunc()
+func (m *mockProxyRepository) GetAdvancedView(category string) []entity.Proxy {
+	if m.GetAdvancedViewFunc != nil {
+		return m.GetAdvancedViewFunc(category)
 	}
 	return nil
 }
 
-func (m *mockProxyRepository) GetSOCKS5ClassicView() []string {
-	if m.GetSOCKS5ClassicViewFunc != nil {
-		return m.GetSOCKS5ClassicViewFunc()
+func (m *mockProxyRepository) GetAnonymityClassicView(anonymity string) []string {
+	if m.GetAnonymityClassicViewFunc != nil {
+		return m.GetAnonymityClassicViewFunc(anonymity)
 	}
 	return nil
 }
 
-func (m *mockProxyRepository) GetAllAdvancedView() []entity.AdvancedProxy {
-	if m.GetAllAdvancedViewFunc != nil {
-		return m.GetAllAdvancedViewFunc()
+func (m *mockProxyRepository) GetAnonymityAdvancedView(anonymity string) []entity.AdvancedProxy {
+	if m.GetAnonymityAdvancedViewFunc != nil {
+		return m.GetAnonymityAdvancedViewFunc(anonymity)
 	}
 	return nil
 }
 
-func (m *mockProxyRepository) GetHTTPAdvancedView() []entity.Proxy {
-	if m.GetHTTPAdvancedViewFunc != nil {
-		return m.GetHTTPAdvancedViewFunc()
+func (m *mockProxyRepository) GetRotatingClassicView() []string {
+	if m.GetRotatingClassicViewFunc != nil {
+		return m.GetRotatingClassicViewFunc()
 	}
 	return nil
 }
 
-func (m *mockProxyRepository) GetHTTPSAdvancedView() []entity.Proxy {
-	if m.GetHTTPSAdvancedViewFunc != nil {
-		return m.GetHTTPSAdvancedViewFunc()
+func (m *mockProxyRepository) GetRotatingAdvancedView() []entity.AdvancedProxy {
+	if m.GetRotatingAdvancedViewFunc != nil {
+		return m.GetRotatingAdvancedViewFunc()
 	}
 	return nil
 }
 
-func (m *mockProxyRepository) GetSOCKS4AdvancedView() []entity.Proxy {
-	if m.GetSOCKS4AdvancedViewFunc != nil {
-		return m.GetSOCKS4AdvancedViewFunc()
+func (m *mockProxyRepository) SetHistory(proxy string, history *entity.ProxyHistory) {
+	if m.SetHistoryFunc != nil {
+		m.SetHistoryFunc(proxy, history)
+	}
+}
+
+type mockHistoryRepository struct {
+	LoadFunc       func() error
+	SaveFunc       func() error
+	GetHistoryFunc func(proxy string, categories []string) *entity.ProxyHistory
+
+	Records []entity.CheckRecord
+	Mutex   sync.Mutex
+}
+
+func (m *mockHistoryRepository) Load() error {
+	if m.LoadFunc != nil {
+		return m.LoadFunc()
 	}
 	return nil
 }
 
-func (m *mockProxyRepository) GetSOCKS5AdvancedView() []entity.Proxy {
-	if m.GetSOCKS5AdvancedViewFunc != nil {
-		return m.GetSOCKS5AdvancedViewFunc()
+func (m *mockHistoryRepository) Record(record entity.CheckRecord) {
+	m.Mutex.Lock()
+	defer m.Mutex.Unlock()
+	m.Records = append(m.Records, record)
+}
+
+func (m *mockHistoryRepository) Save() error {
+	if m.SaveFunc != nil {
+		return m.SaveFunc()
 	}
 	return nil
 }
+
+func (m *mockHistoryRepository) GetHistory(proxy string, categories []string) *entity.ProxyHistory {
+	if m.GetHistoryFunc != nil {
+		return m.GetHistoryFunc(proxy, categories)
+	}
+	return nil
+}
+
+type mockTemplateRepository struct {
+	RenderFunc func(outputPath string, data interface{}, funcs template.FuncMap) error
+}
+
+func (m *mockTemplateRepository) Render(outputPath string, data interface{}, funcs template.FuncMap) error {
+	if m.RenderFunc != nil {
+		return m.RenderFunc(outputPath, data, funcs)
+	}
+	return nil
+}
+
+type mockGeoIPRepository struct {
+	Countries map[string]string
+}
+
+func (m *mockGeoIPRepository) Load() error {
+	return nil
+}
+
+func (m *mockGeoIPRepository) Country(ip string) string {
+	return m.Countries[ip]
+}
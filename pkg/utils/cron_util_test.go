@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewCron(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		wantError  error
+	}{
+		{
+			name:       "Valid",
+			expression: "*/15 0-6,22 1 * 1-5",
+			wantError:  nil,
+		},
+		{
+			name:       "Shortcut",
+			expression: "@hourly",
+			wantError:  nil,
+		},
+		{
+			name:       "MissingField",
+			expression: "0 * * *",
+			wantError:  errors.New(`invalid cron expression "0 * * *": expected 5 fields, got 4`),
+		},
+		{
+			name:       "OutOfRange",
+			expression: "60 * * * *",
+			wantError:  errors.New(`invalid cron expression "60 * * * *": value "60" out of range 0-59`),
+		},
+		{
+			name:       "InvalidStep",
+			expression: "*/0 * * * *",
+			wantError:  errors.New(`invalid cron expression "*/0 * * * *": invalid step "*/0"`),
+		},
+		{
+			name:       "InvalidValue",
+			expression: "0 * * jan *",
+			wantError:  errors.New(`invalid cron expression "0 * * jan *": invalid value "jan"`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewCron(tt.expression)
+			if (err != nil && tt.wantError == nil) ||
+				(err == nil && tt.wantError != nil) ||
+				(err != nil && tt.wantError != nil && err.Error() != tt.wantError.Error()) {
+				t.Errorf(expectedErrorButGotMessage, "NewCron()", tt.wantError, err)
+			}
+			if err == nil && got == nil {
+				t.Errorf(expectedReturnNonNil, "NewCron()", "CronUtilInterface")
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	from := time.Date(2024, time.January, 31, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		name       string
+		expression string
+		want       time.Time
+	}{
+		{
+			name:       "EveryMinute",
+			expression: "* * * * *",
+			want:       time.Date(2024, time.January, 31, 10, 8, 0, 0, time.UTC),
+		},
+		{
+			name:       "Step",
+			expression: "*/15 * * * *",
+			want:       time.Date(2024, time.January, 31, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name:       "Hourly",
+			expression: "@hourly",
+			want:       time.Date(2024, time.January, 31, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "NextMonth",
+			expression: "30 2 1 * *",
+			want:       time.Date(2024, time.February, 1, 2, 30, 0, 0, time.UTC),
+		},
+		{
+			name:       "LeapDay",
+			expression: "0 0 29 2 *",
+			want:       time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "SundayAsSeven",
+			expression: "0 12 * * 7",
+			want:       time.Date(2024, time.February, 4, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "DayOfMonthOrWeekday",
+			expression: "0 0 15 * 5",
+			want:       time.Date(2024, time.February, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "Never",
+			expression: "0 0 31 2 *",
+			want:       time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := NewCron(tt.expression)
+			if err != nil {
+				t.Fatalf(expectedErrorButGotMessage, "NewCron()", nil, err)
+			}
+
+			if got := u.Next(from); !got.Equal(tt.want) {
+				t.Errorf(expectedButGotMessage, "Next()", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,16 +9,17 @@ import (
 
 type FetcherUtil struct {
 	Client         *http.Client
-	NewRequestFunc func(method string, url string, body io.Reader) (*http.Request, error)
+	NewRequestFunc func(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error)
 }
 
 type FetcherUtilInterface interface {
-	NewRequest(method, url string, body io.Reader) (*http.Request, error)
+	NewRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error)
 	Do(client *http.Client, req *http.Request) (*http.Response, error)
-	FetchData(url string) ([]byte, error)
+	FetchData(ctx context.Context, url string) ([]byte, error)
+	FetchStream(ctx context.Context, url string) (io.ReadCloser, error)
 }
 
-func NewFetcher(client *http.Client, newRequestFunc func(method, url string, body io.Reader) (*http.Request, error)) FetcherUtilInterface {
+func NewFetcher(client *http.Client, newRequestFunc func(ctx context.Context, method, url string, body io.Reader) (*http.Request, error)) FetcherUtilInterface {
 	return &FetcherUtil{
 		Client:         client,
 		NewRequestFunc: newRequestFunc,
@@ -28,12 +30,12 @@ func (u *FetcherUtil) Do(client *http.Client, req *http.Request) (*http.Response
 	return client.Do(req)
 }
 
-func (u *FetcherUtil) NewRequest(method string, url string, body io.Reader) (*http.Request, error) {
-	return http.NewRequest(method, url, body)
+func (u *FetcherUtil) NewRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, url, body)
 }
 
-func (u *FetcherUtil) FetchData(url string) ([]byte, error) {
-	req, err := u.NewRequestFunc("GET", url, nil)
+func (u *FetcherUtil) FetchData(ctx context.Context, url string) ([]byte, error) {
+	req, err := u.NewRequestFunc(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -56,3 +58,23 @@ func (u *FetcherUtil) FetchData(url string) ([]byte, error) {
 
 	return body, nil
 }
+
+// FetchStream returns the response body of a successful request, which the caller reads as it arrives and closes
+func (u *FetcherUtil) FetchStream(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := u.NewRequestFunc(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := u.Do(u.Client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch data: %s", http.StatusText(resp.StatusCode))
+	}
+
+	return resp.Body, nil
+}
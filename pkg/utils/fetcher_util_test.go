@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -14,7 +15,7 @@ var (
 	testGETMethod  = "GET"
 	testPOSTMethod = "POST"
 	testClient     = http.DefaultClient
-	testNewRequest = http.NewRequest
+	testNewRequest = http.NewRequestWithContext
 )
 
 func TestNewFetcher(t *testing.T) {
@@ -29,7 +30,7 @@ func TestNewFetcher(t *testing.T) {
 		t.Errorf(expectedTypeAssertionErrorMessage, "*FetcherUtil")
 	}
 
-	req, err := fetcherUtilInstance.NewRequestFunc(testGETMethod, testRawURL, nil)
+	req, err := fetcherUtilInstance.NewRequestFunc(context.Background(), testGETMethod, testRawURL, nil)
 	if err != nil {
 		t.Errorf(expectedButGotMessage, "newRequest", "no error", err)
 	}
@@ -50,7 +51,7 @@ func TestNewFetcher(t *testing.T) {
 func TestFetchData(t *testing.T) {
 	type fields struct {
 		transport      *mockTransport
-		newRequestFunc func(method string, url string, body io.Reader) (*http.Request, error)
+		newRequestFunc func(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error)
 	}
 
 	type args struct {
@@ -91,7 +92,7 @@ func TestFetchData(t *testing.T) {
 					response: nil,
 					err:      nil,
 				},
-				newRequestFunc: func(method string, url string, body io.Reader) (*http.Request, error) {
+				newRequestFunc: func(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
 					return nil, fmt.Errorf("new request error")
 				},
 			},
@@ -166,7 +167,7 @@ func TestFetchData(t *testing.T) {
 				},
 				NewRequestFunc: tt.fields.newRequestFunc,
 			}
-			got, err := fetcherUtil.FetchData(tt.args.url)
+			got, err := fetcherUtil.FetchData(context.Background(), tt.args.url)
 
 			if (err != nil && tt.wantErr != nil && err.Error() != tt.wantErr.Error()) ||
 				(err == nil && tt.wantErr != nil) ||
@@ -181,6 +182,77 @@ func TestFetchData(t *testing.T) {
 	}
 }
 
+func TestFetchStream(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport *mockTransport
+		want      []byte
+		wantErr   error
+	}{
+		{
+			name: "Success",
+			transport: &mockTransport{
+				response: &http.Response{
+					StatusCode: http.StatusOK,
+					Body: io.NopCloser(&mockReadCloser{
+						data: []byte("response data"),
+					}),
+				},
+			},
+			want:    []byte("response data"),
+			wantErr: nil,
+		},
+		{
+			name: "RequestError",
+			transport: &mockTransport{
+				err: fmt.Errorf("request error"),
+			},
+			want:    nil,
+			wantErr: fmt.Errorf("Get \"%s\": request error", testRawURL),
+		},
+		{
+			name: "ResponseError",
+			transport: &mockTransport{
+				response: &http.Response{
+					StatusCode: http.StatusInternalServerError,
+					Body: io.NopCloser(&mockReadCloser{
+						data: []byte("error response"),
+					}),
+				},
+			},
+			want:    nil,
+			wantErr: errors.New("failed to fetch data: Internal Server Error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcherUtil := &FetcherUtil{
+				Client: &http.Client{
+					Transport: tt.transport,
+				},
+				NewRequestFunc: testNewRequest,
+			}
+			body, err := fetcherUtil.FetchStream(context.Background(), testRawURL)
+
+			if (err != nil && tt.wantErr != nil && err.Error() != tt.wantErr.Error()) ||
+				(err == nil && tt.wantErr != nil) ||
+				(err != nil && tt.wantErr == nil) {
+				t.Errorf(expectedErrorButGotMessage, "FetchStream()", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			defer body.Close()
+
+			got, _ := io.ReadAll(body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf(expectedButGotMessage, "FetchStream()", tt.want, got)
+			}
+		})
+	}
+}
+
 func TestNewRequest(t *testing.T) {
 	type args struct {
 		method string
@@ -231,7 +303,7 @@ func TestNewRequest(t *testing.T) {
 			u := NewFetcher(&http.Client{
 				Transport: &mockTransport{},
 			}, testNewRequest)
-			req, err := u.NewRequest(tt.args.method, tt.args.url, tt.args.body)
+			req, err := u.NewRequest(context.Background(), tt.args.method, tt.args.url, tt.args.body)
 
 			if err != nil {
 				t.Errorf(expectedErrorButGotMessage, "NewRequest()", nil, err)